@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/auth"
+)
+
+// apikey generates an API key and prints the store entry holding its hash
+func main() {
+	id := flag.String("id", "", "key ID, e.g. the caller's name")
+	principal := flag.String("principal", "", "principal name (default: the key ID)")
+	scopes := flag.String("scopes", "", "comma separated procedures the key may call, e.g. ProcessData,GetInfo (default: all)")
+	ttl := flag.Duration("ttl", 0, "how long the key is valid (default: no expiry)")
+	flag.Parse()
+
+	if *id == "" {
+		log.Fatal("❌ -id is required")
+	}
+
+	var scopeList []string
+	if *scopes != "" {
+		scopeList = strings.Split(*scopes, ",")
+	} else {
+		scopeList = []string{"*"}
+	}
+	var expiresAt time.Time
+	if *ttl > 0 {
+		expiresAt = time.Now().Add(*ttl).UTC().Truncate(time.Second)
+	}
+
+	key, entry, err := auth.NewAPIKey(*id, scopeList, expiresAt)
+	if err != nil {
+		log.Fatalf("❌ Failed to generate API key: %v", err)
+	}
+	entry.Principal = *principal
+
+	fmt.Fprintf(os.Stderr, "🔑 API key (give this to the caller, it is not stored):\n%s\n\n", key)
+	fmt.Fprintln(os.Stderr, "📄 Add this entry under `keys:` in the API key store:")
+	out, err := yaml.Marshal([]auth.APIKey{entry})
+	if err != nil {
+		log.Fatalf("❌ Failed to encode entry: %v", err)
+	}
+	fmt.Print(string(out))
+}
@@ -13,105 +13,128 @@ import (
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/reflection"
 
-	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api/apiv1connect"
-	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/server"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/app"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/auth"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/config"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/health"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/logging"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/tlsutil"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/tracing"
 )
 
-const (
-	grpcPort   = 9090
-	healthPort = 8080
-)
+// abortGracePeriod is how long canceled RPCs get to return their final status,
+// and how long the health server gets to close its connections
+const abortGracePeriod = 2 * time.Second
 
 func main() {
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
-	logger.SetLevel(logrus.InfoLevel)
-
-	// Create gRPC server
-	grpcServer := grpc.NewServer()
-
-	// Create Connect server
-	grpcService := server.NewGrpcService(logger)
-	path, handler := apiv1connect.NewGrpcServiceHandler(grpcService)
-
-	// Register reflection service on gRPC server
-	reflection.Register(grpcServer)
-
-	// CORS middleware
-	corsMiddleware := func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Allow all origins for demo purposes
-			w.Header().Set("Access-Control-Allow-Origin", "*")
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-
-			// Handle preflight requests
-			if r.Method == "OPTIONS" {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-
-			next.ServeHTTP(w, r)
-		})
-	}
 
-	// Create HTTP server with gRPC and Connect handlers
-	mux := http.NewServeMux()
-	mux.Handle(path, corsMiddleware(handler))
-
-	// Add health check endpoints
-	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"healthy"}`))
-	})
+	// Load and validate configuration before anything else starts
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		logger.Fatalf("Invalid configuration: %v", err)
+	}
 
-	mux.HandleFunc("/ready", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(`{"status":"ready"}`))
+	level, _ := logrus.ParseLevel(cfg.Log.Level)
+	logger.SetLevel(level)
+	switch cfg.Log.Format {
+	case "text":
+		logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	case "cloud":
+		logger.SetFormatter(&logging.CloudFormatter{ProjectID: cfg.Log.ProjectID})
+	}
+	logger.AddHook(tracing.LogHook{})
+	logger.WithField("config", cfg.Redacted()).Debug("Loaded configuration")
+
+	// Set up tracing; spans are exported only when an exporter is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing, tracing.Service{
+		Name:        "grpc-service",
+		Version:     "1.0.0",
+		Environment: cfg.Environment,
 	})
+	if err != nil {
+		logger.Fatalf("Failed to set up tracing: %v", err)
+	}
 
-	// Add metrics endpoint
-	mux.Handle("/metrics", promhttp.Handler())
+	// Build the service with its interceptors, health, reflection and metrics
+	application, err := app.New(cfg, logger)
+	if err != nil {
+		logger.Fatalf("Failed to set up service: %v", err)
+	}
+	healthRegistry := application.Health
+	drainer := application.Drainer
+	mux := application.Handler
+
+	// Create HTTP server with h2c support for gRPC. Configuring the HTTP/2
+	// server against it makes Shutdown send GOAWAY on h2c connections too.
+	// Its flow control windows decide how far clients of streaming RPCs can
+	// send ahead of the handlers.
+	h2Server := &http2.Server{
+		MaxConcurrentStreams:         uint32(cfg.Server.HTTP2.MaxConcurrentStreams),
+		MaxUploadBufferPerConnection: int32(cfg.Server.HTTP2.MaxUploadBufferPerConnection),
+		MaxUploadBufferPerStream:     int32(cfg.Server.HTTP2.MaxUploadBufferPerStream),
+	}
+	httpServer := &http.Server{
+		Addr:    fmt.Sprintf(":%d", cfg.Server.GRPCPort),
+		Handler: h2c.NewHandler(mux, h2Server),
+	}
 
-	// Serve the demo HTML page at root
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		// Only serve the demo page for GET requests to root
-		if r.Method == "GET" && r.URL.Path == "/" {
-			w.Header().Set("Content-Type", "text/html")
-			http.ServeFile(w, r, "web/demo.html")
-		} else {
-			http.NotFound(w, r)
+	// Serve TLS instead of h2c when enabled, reloading certificates from disk
+	if cfg.TLS.Enabled {
+		reloader, err := tlsutil.NewReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile, cfg.TLS.ClientCAFile, logger)
+		if err != nil {
+			logger.Fatalf("Failed to load TLS certificates: %v", err)
+		}
+		clientAuth, err := tlsutil.ParseClientAuth(cfg.TLS.ClientAuth)
+		if err != nil {
+			logger.Fatalf("Invalid TLS client auth: %v", err)
+		}
+		certPolicy, err := auth.NewCertPolicy(cfg.TLS.PrincipalRules)
+		if err != nil {
+			logger.Fatalf("Invalid TLS principal rules: %v", err)
 		}
-	})
 
-	// Create HTTP server with h2c support for gRPC
-	httpServer := &http.Server{
-		Addr:    fmt.Sprintf(":%d", grpcPort),
-		Handler: h2c.NewHandler(mux, &http2.Server{}),
+		reloadCtx, stopReload := context.WithCancel(context.Background())
+		defer stopReload()
+		go reloader.Run(reloadCtx, cfg.TLS.ReloadInterval)
+
+		httpServer.TLSConfig = reloader.ServerConfig(clientAuth)
+		httpServer.Handler = certPolicy.Middleware(mux)
+	}
+
+	if err := http2.ConfigureServer(httpServer, h2Server); err != nil {
+		logger.Fatalf("Failed to configure HTTP/2: %v", err)
 	}
 
-	// Create health check server
+	// Create health check server for the Kubernetes probes
+	healthMux := http.NewServeMux()
+	healthMux.Handle("/", health.LivenessHandler())
+	healthMux.Handle("/ready", health.ReadinessHandler(healthRegistry))
+	healthMux.Handle("/metrics", promhttp.Handler())
+
 	healthServer := &http.Server{
-		Addr: fmt.Sprintf(":%d", healthPort),
-		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte(`{"status":"healthy"}`))
-		}),
+		Addr:    fmt.Sprintf(":%d", cfg.Server.HealthPort),
+		Handler: healthMux,
 	}
 
 	// Start servers
 	go func() {
-		logger.Infof("Starting gRPC server on port %d", grpcPort)
-		if err := httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.WithField("tls", cfg.TLS.Enabled).Infof("Starting gRPC server on port %d", cfg.Server.GRPCPort)
+		var err error
+		if cfg.TLS.Enabled {
+			err = httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logger.Fatalf("Failed to start gRPC server: %v", err)
 		}
 	}()
 
 	go func() {
-		logger.Infof("Starting health check server on port %d", healthPort)
+		logger.Infof("Starting health check server on port %d", cfg.Server.HealthPort)
 		if err := healthServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			logger.Fatalf("Failed to start health server: %v", err)
 		}
@@ -122,19 +145,57 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	logger.Info("Shutting down servers...")
+	// Fail readiness first so Kubernetes stops routing new traffic here
+	logger.Info("Shutting down: marking service NOT_SERVING")
+	healthRegistry.Shutdown()
+
+	// Keep serving while endpoints are updated; a second signal skips the wait
+	logger.Infof("Waiting %s before draining", cfg.Server.PreStopDelay)
+	select {
+	case <-time.After(cfg.Server.PreStopDelay):
+	case <-quit:
+	}
 
 	// Graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
+	// Stop accepting new RPCs and connections, then let in-flight RPCs finish
+	drainer.Drain()
 	if err := httpServer.Shutdown(ctx); err != nil {
 		logger.Errorf("HTTP server shutdown error: %v", err)
 	}
 
-	if err := healthServer.Shutdown(ctx); err != nil {
+	logger.Infof("Draining %d in-flight RPCs", drainer.InFlight())
+	if err := drainer.Wait(ctx); err != nil {
+		logger.Warnf("Canceling %d RPCs still running after %s", drainer.InFlight(), cfg.Server.ShutdownTimeout)
+		drainer.Abort()
+
+		abortCtx, abortCancel := context.WithTimeout(context.Background(), abortGracePeriod)
+		defer abortCancel()
+		if err := drainer.Wait(abortCtx); err != nil {
+			logger.Errorf("RPCs did not stop after cancellation: %v", err)
+		}
+	}
+
+	// Let running jobs finish in what is left of the timeout; queued jobs
+	// fail. Then close the store.
+	if err := application.Close(ctx); err != nil {
+		logger.Warnf("Closing jobs and storage: %v", err)
+	}
+
+	healthCtx, healthCancel := context.WithTimeout(context.Background(), abortGracePeriod)
+	defer healthCancel()
+	if err := healthServer.Shutdown(healthCtx); err != nil {
 		logger.Errorf("Health server shutdown error: %v", err)
 	}
 
+	// Flush buffered spans
+	tracingCtx, tracingCancel := context.WithTimeout(context.Background(), abortGracePeriod)
+	defer tracingCancel()
+	if err := shutdownTracing(tracingCtx); err != nil {
+		logger.Errorf("Tracing shutdown error: %v", err)
+	}
+
 	logger.Info("Servers stopped")
 }
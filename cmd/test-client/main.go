@@ -1,29 +1,65 @@
 package main
 
 import (
+	"bufio"
 	"context"
+	"crypto/tls"
+	"flag"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
+	"os"
 	"os/exec"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/bufbuild/connect-go"
+	"go.opentelemetry.io/otel"
+	"golang.org/x/net/http2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
 
 	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
 	apiv1connect "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api/apiv1connect"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/config"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/tlsutil"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/tracing"
 )
 
 func main() {
 	// Configuration
 	timeout := 30 * time.Second
 
-	// Dynamically discover the service URL
-	serviceURL, err := discoverServiceURL()
-	if err != nil {
-		log.Fatalf("❌ Failed to discover service URL: %v", err)
+	serviceURLFlag := flag.String("url", "", "service URL (default: discovered with kubectl)")
+	caFile := flag.String("ca", "", "CA certificate to verify the server with; enables TLS")
+	certFile := flag.String("cert", "", "client certificate for mutual TLS")
+	keyFile := flag.String("key", "", "client private key for mutual TLS")
+	token := flag.String("token", "", "JWT bearer token sent in the Authorization header")
+	apiKey := flag.String("api-key", "", "API key sent in the x-api-key header")
+	traceExporter := flag.String("trace-exporter", "none", "trace exporter (none, stdout, otlp-grpc, otlp-http)")
+	traceEndpoint := flag.String("trace-endpoint", "", "OTLP collector host:port")
+	data := flag.String("data", "test-data", "data sent to ProcessData")
+	pipeline := flag.String("pipeline", "", "comma separated ProcessData processors, e.g. uppercase,base64")
+	seed := flag.String("seed", "", "seed for the ProcessData random number, to replay a result")
+	uploadFile := flag.String("upload", "", "upload the NDJSON records in this file with UploadData instead of running the smoke test")
+	flag.Parse()
+
+	useTLS := *caFile != "" || *certFile != ""
+
+	// Use the given URL or dynamically discover the service URL
+	serviceURL := *serviceURLFlag
+	if serviceURL == "" {
+		discovered, err := discoverServiceURL()
+		if err != nil {
+			log.Fatalf("❌ Failed to discover service URL: %v", err)
+		}
+		serviceURL = discovered
+		if useTLS {
+			serviceURL = "https" + strings.TrimPrefix(serviceURL, "http")
+		}
 	}
 
 	fmt.Printf("🔍 Testing GCP Service at: %s\n", serviceURL)
@@ -33,26 +69,116 @@ func main() {
 	client := &http.Client{
 		Timeout: timeout,
 	}
+	if useTLS {
+		tlsConfig, err := tlsutil.ClientConfig(*caFile, *certFile, *keyFile)
+		if err != nil {
+			log.Fatalf("❌ Failed to configure TLS: %v", err)
+		}
+		client.Transport = &http.Transport{
+			TLSClientConfig:   tlsConfig,
+			ForceAttemptHTTP2: true,
+		}
+		if *certFile != "" {
+			fmt.Println("🔐 Using mutual TLS")
+		} else {
+			fmt.Println("🔐 Using TLS")
+		}
+	}
+
+	// Set up tracing; all calls below belong to one trace
+	shutdownTracing, err := tracing.Setup(context.Background(), config.TracingConfig{
+		Exporter:    *traceExporter,
+		Endpoint:    *traceEndpoint,
+		Insecure:    true,
+		SampleRatio: 1,
+	}, tracing.Service{Name: "test-client", Version: "1.0.0"})
+	if err != nil {
+		log.Fatalf("❌ Failed to set up tracing: %v", err)
+	}
+	rootCtx, span := otel.Tracer("test-client").Start(context.Background(), "test-client smoke test")
+	finish := func() {
+		span.End()
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer flushCancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			log.Printf("⚠️  Failed to flush traces: %v", err)
+		}
+	}
+	// fatalf exits after printing the google.rpc details of any error in args
+	fatalf := func(format string, args ...any) {
+		finish()
+		message := fmt.Sprintf(format, args...)
+		for _, arg := range args {
+			if err, ok := arg.(error); ok {
+				if details := apierror.DetailsOf(err).String(); details != "" {
+					message += "\n   " + strings.ReplaceAll(details, "\n", "\n   ")
+				}
+			}
+		}
+		log.Fatal(message)
+	}
+	if *traceExporter != "none" {
+		fmt.Printf("🔭 Trace ID: %s\n", span.SpanContext().TraceID())
+	}
 
 	// Create Connect client
-	connectClient := apiv1connect.NewGrpcServiceClient(client, serviceURL)
+	clientOpts := []connect.ClientOption{connect.WithInterceptors(tracing.NewInterceptor())}
+	if *token != "" {
+		clientOpts = append(clientOpts, connect.WithInterceptors(&headerInterceptor{
+			header: "Authorization",
+			value:  "Bearer " + *token,
+		}))
+	}
+	if *apiKey != "" {
+		clientOpts = append(clientOpts, connect.WithInterceptors(&headerInterceptor{
+			header: "X-Api-Key",
+			value:  *apiKey,
+		}))
+	}
+	connectClient := apiv1connect.NewGrpcServiceClient(client, serviceURL, clientOpts...)
+
+	// Upload mode sends one record per line and prints the summary
+	if *uploadFile != "" {
+		fmt.Printf("📤 Uploading records from %s...\n", *uploadFile)
+		summary, err := uploadNDJSON(rootCtx, connectClient, *uploadFile)
+		if err != nil {
+			fatalf("❌ Upload failed: %v", err)
+		}
+		fmt.Printf("✅ UploadData Response:\n")
+		fmt.Printf("   Received: %d (%d bytes)\n", summary.Received, summary.BytesReceived)
+		fmt.Printf("   Succeeded: %d\n", summary.Succeeded)
+		fmt.Printf("   Failed: %d\n", summary.Failed)
+		for _, failure := range summary.Failures {
+			fmt.Printf("     record %d: %s\n", failure.Index, failure.ErrorMessage)
+		}
+		fmt.Printf("   Duration: %s\n", summary.Duration.AsDuration())
+		finish()
+		return
+	}
 
 	// Test ProcessData endpoint
 	fmt.Println("📊 Testing ProcessData endpoint...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	ctx, cancel := context.WithTimeout(rootCtx, timeout)
 	defer cancel()
 
+	options := map[string]string{
+		"test": "true",
+	}
+	if *pipeline != "" {
+		options["pipeline"] = *pipeline
+	}
+	if *seed != "" {
+		options["seed"] = *seed
+	}
 	req := connect.NewRequest(&apiv1.ProcessDataRequest{
-		Data: "test-data",
-		Options: map[string]string{
-			"test": "true",
-		},
+		Data:    *data,
+		Options: options,
 	})
 
 	resp, err := connectClient.ProcessData(ctx, req)
 	if err != nil {
-		log.Fatalf("❌ Failed to call ProcessData: %v", err)
+		fatalf("❌ Failed to call ProcessData: %v", err)
 	}
 
 	fmt.Printf("✅ ProcessData Response:\n")
@@ -60,6 +186,92 @@ func main() {
 	fmt.Printf("   Success: %t\n", resp.Msg.Success)
 	fmt.Printf("   Error Message: %s\n", resp.Msg.ErrorMessage)
 	fmt.Printf("   Processed At: %s\n", resp.Msg.ProcessedAt.AsTime().Format(time.RFC3339))
+	fmt.Printf("   Request ID: %s\n", resp.Header().Get("X-Request-Id"))
+	if seed := resp.Header().Get("X-Random-Seed"); seed != "" {
+		fmt.Printf("   Seed: %s (replay with -seed %s)\n", seed, seed)
+	}
+
+	// Test BatchProcessData; the unknown processor fails only its own item
+	fmt.Println("\n📦 Testing BatchProcessData endpoint...")
+
+	batchResp, err := connectClient.BatchProcessData(ctx, connect.NewRequest(&apiv1.BatchProcessDataRequest{
+		Items: []*apiv1.ProcessDataRequest{
+			{Data: *data, Options: map[string]string{"processor": "uppercase"}},
+			{Data: *data, Options: map[string]string{"processor": "word_count"}},
+			{Data: *data, Options: map[string]string{"processor": "no_such_processor"}},
+		},
+	}))
+	if err != nil {
+		fatalf("❌ Failed to call BatchProcessData: %v", err)
+	}
+	for i, result := range batchResp.Msg.Results {
+		if result.Success {
+			fmt.Printf("   #%d: %s\n", i, result.Result)
+		} else {
+			fmt.Printf("   #%d: failed (%s, %s) %s\n", i, result.ErrorCode, result.ErrorReason, result.ErrorMessage)
+		}
+	}
+	stats := batchResp.Msg.Stats
+	if stats.Succeeded != 2 || stats.Failed != 1 {
+		fatalf("❌ BatchProcessData expected 2 successes and 1 failure, got %d and %d", stats.Succeeded, stats.Failed)
+	}
+	fmt.Printf("✅ BatchProcessData: %d of %d succeeded in %s\n", stats.Succeeded, stats.Total, stats.Duration.AsDuration())
+
+	// Test error details: a missing option fails with google.rpc details that
+	// name the reason and the field at fault
+	fmt.Println("\n🧾 Testing error details...")
+
+	_, err = connectClient.ProcessData(ctx, connect.NewRequest(&apiv1.ProcessDataRequest{
+		Data:    *data,
+		Options: map[string]string{"processor": "regex_extract"},
+	}))
+	details := apierror.DetailsOf(err)
+	if connect.CodeOf(err) != connect.CodeInvalidArgument || details.Info == nil || details.BadRequest == nil {
+		fatalf("❌ Expected INVALID_ARGUMENT with ErrorInfo and BadRequest details, got %v", err)
+	}
+	fmt.Printf("   %s: %s\n", connect.CodeOf(err), err.(*connect.Error).Message())
+	fmt.Printf("   %s\n", strings.ReplaceAll(details.String(), "\n", "\n   "))
+	fmt.Println("✅ Error details decoded")
+
+	// Test the job API: submit ProcessData as a job and wait for it
+	fmt.Println("\n⏳ Testing SubmitJob and WaitJob...")
+
+	job, err := connectClient.SubmitJob(ctx, connect.NewRequest(&apiv1.SubmitJobRequest{
+		Request: &apiv1.ProcessDataRequest{Data: *data, Options: map[string]string{"pipeline": "uppercase,base64"}},
+	}))
+	if err != nil {
+		fatalf("❌ Failed to call SubmitJob: %v", err)
+	}
+	fmt.Printf("   Submitted %s (%s)\n", job.Msg.Name, job.Msg.State)
+	waited, err := connectClient.WaitJob(ctx, connect.NewRequest(&apiv1.WaitJobRequest{
+		Name:    job.Msg.Name,
+		Timeout: durationpb.New(30 * time.Second),
+	}))
+	if err != nil {
+		fatalf("❌ Failed to call WaitJob: %v", err)
+	}
+	if waited.Msg.State != apiv1.JobState_JOB_STATE_SUCCEEDED {
+		fatalf("❌ Job %s ended %s: %v", waited.Msg.Name, waited.Msg.State, waited.Msg.GetError())
+	}
+	fmt.Printf("✅ Job succeeded: %s\n", waited.Msg.GetResponse().Result)
+
+	// Test ListResults, which returns the calls recorded above
+	fmt.Println("\n🗄️  Testing ListResults endpoint...")
+
+	results, err := connectClient.ListResults(ctx, connect.NewRequest(&apiv1.ListResultsRequest{
+		PageSize: 5,
+		Filter:   `method = "ProcessData" AND status = "ok"`,
+	}))
+	if err != nil {
+		fatalf("❌ Failed to call ListResults: %v", err)
+	}
+	if len(results.Msg.Results) == 0 {
+		fatalf("❌ ListResults returned no successful ProcessData results")
+	}
+	for _, result := range results.Msg.Results {
+		fmt.Printf("   #%d %s %q -> %q (status: %s)\n", result.Id, result.CreateTime.AsTime().Format(time.RFC3339), result.Input, result.Output, result.Status)
+	}
+	fmt.Println("✅ ListResults returned the recorded calls")
 
 	// Test GetHealth endpoint
 	fmt.Println("\n🏥 Testing GetHealth endpoint...")
@@ -67,7 +279,7 @@ func main() {
 	healthReq := connect.NewRequest(&apiv1.GetHealthRequest{})
 	healthResp, err := connectClient.GetHealth(ctx, healthReq)
 	if err != nil {
-		log.Fatalf("❌ Failed to call GetHealth: %v", err)
+		fatalf("❌ Failed to call GetHealth: %v", err)
 	}
 
 	fmt.Printf("✅ GetHealth Response:\n")
@@ -81,7 +293,7 @@ func main() {
 	infoReq := connect.NewRequest(&apiv1.GetInfoRequest{})
 	infoResp, err := connectClient.GetInfo(ctx, infoReq)
 	if err != nil {
-		log.Fatalf("❌ Failed to call GetInfo: %v", err)
+		fatalf("❌ Failed to call GetInfo: %v", err)
 	}
 
 	fmt.Printf("✅ GetInfo Response:\n")
@@ -90,9 +302,116 @@ func main() {
 	fmt.Printf("   Start Time: %s\n", infoResp.Msg.StartTime.AsTime().Format(time.RFC3339))
 	fmt.Printf("   Metadata: %v\n", infoResp.Msg.Metadata)
 
+	// Test StreamData, disconnecting part way through and resuming
+	fmt.Println("\n🌊 Testing StreamData endpoint with resume...")
+
+	streamReq := &apiv1.StreamDataRequest{Query: "test-query", Limit: 6}
+	streamCtx, streamCancel := context.WithCancel(ctx)
+	stream, err := connectClient.StreamData(streamCtx, connect.NewRequest(streamReq))
+	if err != nil {
+		fatalf("❌ Failed to call StreamData: %v", err)
+	}
+	var cursor string
+	for i := 0; i < 3 && stream.Receive(); i++ {
+		fmt.Printf("   #%d %s\n", stream.Msg().Sequence, stream.Msg().Data)
+		cursor = stream.Msg().Cursor
+	}
+	if err := stream.Err(); err != nil {
+		fatalf("❌ StreamData failed: %v", err)
+	}
+	streamCancel()
+	_ = stream.Close()
+	fmt.Println("   ✂️  Disconnected, resuming from the last cursor")
+
+	streamReq.ResumeToken = cursor
+	resumed, err := connectClient.StreamData(ctx, connect.NewRequest(streamReq))
+	if err != nil {
+		fatalf("❌ Failed to resume StreamData: %v", err)
+	}
+	for resumed.Receive() {
+		fmt.Printf("   #%d %s\n", resumed.Msg().Sequence, resumed.Msg().Data)
+	}
+	if err := resumed.Err(); err != nil {
+		fatalf("❌ Resumed StreamData failed: %v", err)
+	}
+	_ = resumed.Close()
+	fmt.Println("✅ StreamData resumed without repeating messages")
+
+	// Test ProcessStream, which needs HTTP/2 end to end; without TLS that
+	// means h2c with prior knowledge
+	fmt.Println("\n🔀 Testing ProcessStream...")
+	streamHTTPClient := client
+	if !useTLS {
+		streamHTTPClient = &http.Client{Transport: &http2.Transport{
+			AllowHTTP: true,
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, addr)
+			},
+		}}
+	}
+	streamClient := apiv1connect.NewGrpcServiceClient(streamHTTPClient, serviceURL, clientOpts...)
+	bidiCtx, bidiCancel := context.WithTimeout(rootCtx, timeout)
+	defer bidiCancel()
+	bidi := streamClient.ProcessStream(bidiCtx)
+	pending := map[string]bool{}
+	for i, processorName := range []string{"uppercase", "base64", "word_count", "hash"} {
+		id := fmt.Sprintf("req-%d", i+1)
+		pending[id] = true
+		req := &apiv1.ProcessStreamRequest{Id: id, Data: "hello from the test client", Options: map[string]string{"processor": processorName}}
+		if err := bidi.Send(req); err != nil {
+			fatalf("❌ ProcessStream send failed: %v", err)
+		}
+	}
+	if err := bidi.CloseRequest(); err != nil {
+		fatalf("❌ ProcessStream close failed: %v", err)
+	}
+	for len(pending) > 0 {
+		result, err := bidi.Receive()
+		if err != nil {
+			fatalf("❌ ProcessStream failed: %v", err)
+		}
+		if !pending[result.Id] {
+			fatalf("❌ ProcessStream returned unexpected id %q", result.Id)
+		}
+		delete(pending, result.Id)
+		if !result.Success {
+			fatalf("❌ ProcessStream request %s failed: %s", result.Id, result.ErrorMessage)
+		}
+		fmt.Printf("   %s: %s\n", result.Id, result.Result)
+	}
+	_ = bidi.CloseResponse()
+	fmt.Println("✅ ProcessStream answered every request")
+
+	finish()
 	fmt.Println("\n🎉 All tests passed! Your GCP deployment is working correctly!")
 }
 
+// headerInterceptor adds a fixed header, such as credentials, to every call
+type headerInterceptor struct {
+	header string
+	value  string
+}
+
+func (i *headerInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		req.Header().Set(i.header, i.value)
+		return next(ctx, req)
+	}
+}
+
+func (i *headerInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+		conn.RequestHeader().Set(i.header, i.value)
+		return conn
+	}
+}
+
+func (i *headerInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
+
 // discoverServiceURL dynamically discovers the service URL by querying Kubernetes
 func discoverServiceURL() (string, error) {
 	// Get the node external IP
@@ -146,3 +465,44 @@ func getServiceNodePort() (int, error) {
 
 	return port, nil
 }
+
+// uploadNDJSON streams the records of an NDJSON file, one UploadDataRequest
+// per line such as {"data": "hello", "options": {"processor": "uppercase"}}.
+// Blank lines are skipped, so failure indexes count records rather than lines.
+func uploadNDJSON(ctx context.Context, client apiv1connect.GrpcServiceClient, path string) (*apiv1.UploadDataResponse, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Canceling, unlike closing, makes the server drop the records sent so far
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream := client.UploadData(ctx)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		record := &apiv1.UploadDataRequest{}
+		if err := protojson.Unmarshal([]byte(text), record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := stream.Send(record); err != nil {
+			// The server ended the upload; CloseAndReceive returns its error
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	resp, err := stream.CloseAndReceive()
+	if err != nil {
+		return nil, err
+	}
+	return resp.Msg, nil
+}
@@ -39,8 +39,28 @@ const (
 	GrpcServiceGetInfoProcedure = "/api.v1.GrpcService/GetInfo"
 	// GrpcServiceProcessDataProcedure is the fully-qualified name of the GrpcService's ProcessData RPC.
 	GrpcServiceProcessDataProcedure = "/api.v1.GrpcService/ProcessData"
+	// GrpcServiceBatchProcessDataProcedure is the fully-qualified name of the GrpcService's
+	// BatchProcessData RPC.
+	GrpcServiceBatchProcessDataProcedure = "/api.v1.GrpcService/BatchProcessData"
 	// GrpcServiceStreamDataProcedure is the fully-qualified name of the GrpcService's StreamData RPC.
 	GrpcServiceStreamDataProcedure = "/api.v1.GrpcService/StreamData"
+	// GrpcServiceUploadDataProcedure is the fully-qualified name of the GrpcService's UploadData RPC.
+	GrpcServiceUploadDataProcedure = "/api.v1.GrpcService/UploadData"
+	// GrpcServiceProcessStreamProcedure is the fully-qualified name of the GrpcService's ProcessStream
+	// RPC.
+	GrpcServiceProcessStreamProcedure = "/api.v1.GrpcService/ProcessStream"
+	// GrpcServiceSubmitJobProcedure is the fully-qualified name of the GrpcService's SubmitJob RPC.
+	GrpcServiceSubmitJobProcedure = "/api.v1.GrpcService/SubmitJob"
+	// GrpcServiceGetJobProcedure is the fully-qualified name of the GrpcService's GetJob RPC.
+	GrpcServiceGetJobProcedure = "/api.v1.GrpcService/GetJob"
+	// GrpcServiceListJobsProcedure is the fully-qualified name of the GrpcService's ListJobs RPC.
+	GrpcServiceListJobsProcedure = "/api.v1.GrpcService/ListJobs"
+	// GrpcServiceCancelJobProcedure is the fully-qualified name of the GrpcService's CancelJob RPC.
+	GrpcServiceCancelJobProcedure = "/api.v1.GrpcService/CancelJob"
+	// GrpcServiceWaitJobProcedure is the fully-qualified name of the GrpcService's WaitJob RPC.
+	GrpcServiceWaitJobProcedure = "/api.v1.GrpcService/WaitJob"
+	// GrpcServiceListResultsProcedure is the fully-qualified name of the GrpcService's ListResults RPC.
+	GrpcServiceListResultsProcedure = "/api.v1.GrpcService/ListResults"
 )
 
 // GrpcServiceClient is a client for the api.v1.GrpcService service.
@@ -51,8 +71,38 @@ type GrpcServiceClient interface {
 	GetInfo(context.Context, *connect_go.Request[api.GetInfoRequest]) (*connect_go.Response[api.GetInfoResponse], error)
 	// ProcessData processes some data and returns a result
 	ProcessData(context.Context, *connect_go.Request[api.ProcessDataRequest]) (*connect_go.Response[api.ProcessDataResponse], error)
-	// StreamData streams data processing results
+	// BatchProcessData processes several items like ProcessData and returns
+	// one result per item, in order. Items fail individually without failing
+	// the batch.
+	BatchProcessData(context.Context, *connect_go.Request[api.BatchProcessDataRequest]) (*connect_go.Response[api.BatchProcessDataResponse], error)
+	// StreamData streams data processing results. An interrupted stream can be
+	// resumed by sending the cursor of the last message received.
 	StreamData(context.Context, *connect_go.Request[api.StreamDataRequest]) (*connect_go.ServerStreamForClient[api.StreamDataResponse], error)
+	// UploadData processes a stream of records like ProcessData and returns a
+	// summary once the client closes the stream
+	UploadData(context.Context) *connect_go.ClientStreamForClient[api.UploadDataRequest, api.UploadDataResponse]
+	// ProcessStream processes requests sent on one stream like ProcessData and
+	// sends each result as soon as it is ready, so results may arrive out of
+	// order. Every result carries the id of its request.
+	ProcessStream(context.Context) *connect_go.BidiStreamForClient[api.ProcessStreamRequest, api.ProcessStreamResponse]
+	// SubmitJob starts processing a request in the background and returns the
+	// pending job. Jobs follow google.longrunning.Operation semantics: poll
+	// them with GetJob or block with WaitJob until done is true. A job belongs
+	// to the caller that submitted it; to other callers it is NOT_FOUND.
+	SubmitJob(context.Context, *connect_go.Request[api.SubmitJobRequest]) (*connect_go.Response[api.Job], error)
+	// GetJob returns the latest state of a job
+	GetJob(context.Context, *connect_go.Request[api.GetJobRequest]) (*connect_go.Response[api.Job], error)
+	// ListJobs lists the caller's jobs in submission order
+	ListJobs(context.Context, *connect_go.Request[api.ListJobsRequest]) (*connect_go.Response[api.ListJobsResponse], error)
+	// CancelJob asks for a job to be cancelled. Cancellation is asynchronous:
+	// a running job may still finish before it stops.
+	CancelJob(context.Context, *connect_go.Request[api.CancelJobRequest]) (*connect_go.Response[api.Job], error)
+	// WaitJob returns a job once it is done or the timeout passes, whichever
+	// comes first. The returned job is not necessarily done.
+	WaitJob(context.Context, *connect_go.Request[api.WaitJobRequest]) (*connect_go.Response[api.Job], error)
+	// ListResults lists the caller's recorded ProcessData and StreamData
+	// calls, newest first
+	ListResults(context.Context, *connect_go.Request[api.ListResultsRequest]) (*connect_go.Response[api.ListResultsResponse], error)
 }
 
 // NewGrpcServiceClient constructs a client for the api.v1.GrpcService service. By default, it uses
@@ -80,20 +130,74 @@ func NewGrpcServiceClient(httpClient connect_go.HTTPClient, baseURL string, opts
 			baseURL+GrpcServiceProcessDataProcedure,
 			opts...,
 		),
+		batchProcessData: connect_go.NewClient[api.BatchProcessDataRequest, api.BatchProcessDataResponse](
+			httpClient,
+			baseURL+GrpcServiceBatchProcessDataProcedure,
+			opts...,
+		),
 		streamData: connect_go.NewClient[api.StreamDataRequest, api.StreamDataResponse](
 			httpClient,
 			baseURL+GrpcServiceStreamDataProcedure,
 			opts...,
 		),
+		uploadData: connect_go.NewClient[api.UploadDataRequest, api.UploadDataResponse](
+			httpClient,
+			baseURL+GrpcServiceUploadDataProcedure,
+			opts...,
+		),
+		processStream: connect_go.NewClient[api.ProcessStreamRequest, api.ProcessStreamResponse](
+			httpClient,
+			baseURL+GrpcServiceProcessStreamProcedure,
+			opts...,
+		),
+		submitJob: connect_go.NewClient[api.SubmitJobRequest, api.Job](
+			httpClient,
+			baseURL+GrpcServiceSubmitJobProcedure,
+			opts...,
+		),
+		getJob: connect_go.NewClient[api.GetJobRequest, api.Job](
+			httpClient,
+			baseURL+GrpcServiceGetJobProcedure,
+			opts...,
+		),
+		listJobs: connect_go.NewClient[api.ListJobsRequest, api.ListJobsResponse](
+			httpClient,
+			baseURL+GrpcServiceListJobsProcedure,
+			opts...,
+		),
+		cancelJob: connect_go.NewClient[api.CancelJobRequest, api.Job](
+			httpClient,
+			baseURL+GrpcServiceCancelJobProcedure,
+			opts...,
+		),
+		waitJob: connect_go.NewClient[api.WaitJobRequest, api.Job](
+			httpClient,
+			baseURL+GrpcServiceWaitJobProcedure,
+			opts...,
+		),
+		listResults: connect_go.NewClient[api.ListResultsRequest, api.ListResultsResponse](
+			httpClient,
+			baseURL+GrpcServiceListResultsProcedure,
+			opts...,
+		),
 	}
 }
 
 // grpcServiceClient implements GrpcServiceClient.
 type grpcServiceClient struct {
-	getHealth   *connect_go.Client[api.GetHealthRequest, api.GetHealthResponse]
-	getInfo     *connect_go.Client[api.GetInfoRequest, api.GetInfoResponse]
-	processData *connect_go.Client[api.ProcessDataRequest, api.ProcessDataResponse]
-	streamData  *connect_go.Client[api.StreamDataRequest, api.StreamDataResponse]
+	getHealth        *connect_go.Client[api.GetHealthRequest, api.GetHealthResponse]
+	getInfo          *connect_go.Client[api.GetInfoRequest, api.GetInfoResponse]
+	processData      *connect_go.Client[api.ProcessDataRequest, api.ProcessDataResponse]
+	batchProcessData *connect_go.Client[api.BatchProcessDataRequest, api.BatchProcessDataResponse]
+	streamData       *connect_go.Client[api.StreamDataRequest, api.StreamDataResponse]
+	uploadData       *connect_go.Client[api.UploadDataRequest, api.UploadDataResponse]
+	processStream    *connect_go.Client[api.ProcessStreamRequest, api.ProcessStreamResponse]
+	submitJob        *connect_go.Client[api.SubmitJobRequest, api.Job]
+	getJob           *connect_go.Client[api.GetJobRequest, api.Job]
+	listJobs         *connect_go.Client[api.ListJobsRequest, api.ListJobsResponse]
+	cancelJob        *connect_go.Client[api.CancelJobRequest, api.Job]
+	waitJob          *connect_go.Client[api.WaitJobRequest, api.Job]
+	listResults      *connect_go.Client[api.ListResultsRequest, api.ListResultsResponse]
 }
 
 // GetHealth calls api.v1.GrpcService.GetHealth.
@@ -111,11 +215,56 @@ func (c *grpcServiceClient) ProcessData(ctx context.Context, req *connect_go.Req
 	return c.processData.CallUnary(ctx, req)
 }
 
+// BatchProcessData calls api.v1.GrpcService.BatchProcessData.
+func (c *grpcServiceClient) BatchProcessData(ctx context.Context, req *connect_go.Request[api.BatchProcessDataRequest]) (*connect_go.Response[api.BatchProcessDataResponse], error) {
+	return c.batchProcessData.CallUnary(ctx, req)
+}
+
 // StreamData calls api.v1.GrpcService.StreamData.
 func (c *grpcServiceClient) StreamData(ctx context.Context, req *connect_go.Request[api.StreamDataRequest]) (*connect_go.ServerStreamForClient[api.StreamDataResponse], error) {
 	return c.streamData.CallServerStream(ctx, req)
 }
 
+// UploadData calls api.v1.GrpcService.UploadData.
+func (c *grpcServiceClient) UploadData(ctx context.Context) *connect_go.ClientStreamForClient[api.UploadDataRequest, api.UploadDataResponse] {
+	return c.uploadData.CallClientStream(ctx)
+}
+
+// ProcessStream calls api.v1.GrpcService.ProcessStream.
+func (c *grpcServiceClient) ProcessStream(ctx context.Context) *connect_go.BidiStreamForClient[api.ProcessStreamRequest, api.ProcessStreamResponse] {
+	return c.processStream.CallBidiStream(ctx)
+}
+
+// SubmitJob calls api.v1.GrpcService.SubmitJob.
+func (c *grpcServiceClient) SubmitJob(ctx context.Context, req *connect_go.Request[api.SubmitJobRequest]) (*connect_go.Response[api.Job], error) {
+	return c.submitJob.CallUnary(ctx, req)
+}
+
+// GetJob calls api.v1.GrpcService.GetJob.
+func (c *grpcServiceClient) GetJob(ctx context.Context, req *connect_go.Request[api.GetJobRequest]) (*connect_go.Response[api.Job], error) {
+	return c.getJob.CallUnary(ctx, req)
+}
+
+// ListJobs calls api.v1.GrpcService.ListJobs.
+func (c *grpcServiceClient) ListJobs(ctx context.Context, req *connect_go.Request[api.ListJobsRequest]) (*connect_go.Response[api.ListJobsResponse], error) {
+	return c.listJobs.CallUnary(ctx, req)
+}
+
+// CancelJob calls api.v1.GrpcService.CancelJob.
+func (c *grpcServiceClient) CancelJob(ctx context.Context, req *connect_go.Request[api.CancelJobRequest]) (*connect_go.Response[api.Job], error) {
+	return c.cancelJob.CallUnary(ctx, req)
+}
+
+// WaitJob calls api.v1.GrpcService.WaitJob.
+func (c *grpcServiceClient) WaitJob(ctx context.Context, req *connect_go.Request[api.WaitJobRequest]) (*connect_go.Response[api.Job], error) {
+	return c.waitJob.CallUnary(ctx, req)
+}
+
+// ListResults calls api.v1.GrpcService.ListResults.
+func (c *grpcServiceClient) ListResults(ctx context.Context, req *connect_go.Request[api.ListResultsRequest]) (*connect_go.Response[api.ListResultsResponse], error) {
+	return c.listResults.CallUnary(ctx, req)
+}
+
 // GrpcServiceHandler is an implementation of the api.v1.GrpcService service.
 type GrpcServiceHandler interface {
 	// GetHealth returns the health status of the service
@@ -124,8 +273,38 @@ type GrpcServiceHandler interface {
 	GetInfo(context.Context, *connect_go.Request[api.GetInfoRequest]) (*connect_go.Response[api.GetInfoResponse], error)
 	// ProcessData processes some data and returns a result
 	ProcessData(context.Context, *connect_go.Request[api.ProcessDataRequest]) (*connect_go.Response[api.ProcessDataResponse], error)
-	// StreamData streams data processing results
+	// BatchProcessData processes several items like ProcessData and returns
+	// one result per item, in order. Items fail individually without failing
+	// the batch.
+	BatchProcessData(context.Context, *connect_go.Request[api.BatchProcessDataRequest]) (*connect_go.Response[api.BatchProcessDataResponse], error)
+	// StreamData streams data processing results. An interrupted stream can be
+	// resumed by sending the cursor of the last message received.
 	StreamData(context.Context, *connect_go.Request[api.StreamDataRequest], *connect_go.ServerStream[api.StreamDataResponse]) error
+	// UploadData processes a stream of records like ProcessData and returns a
+	// summary once the client closes the stream
+	UploadData(context.Context, *connect_go.ClientStream[api.UploadDataRequest]) (*connect_go.Response[api.UploadDataResponse], error)
+	// ProcessStream processes requests sent on one stream like ProcessData and
+	// sends each result as soon as it is ready, so results may arrive out of
+	// order. Every result carries the id of its request.
+	ProcessStream(context.Context, *connect_go.BidiStream[api.ProcessStreamRequest, api.ProcessStreamResponse]) error
+	// SubmitJob starts processing a request in the background and returns the
+	// pending job. Jobs follow google.longrunning.Operation semantics: poll
+	// them with GetJob or block with WaitJob until done is true. A job belongs
+	// to the caller that submitted it; to other callers it is NOT_FOUND.
+	SubmitJob(context.Context, *connect_go.Request[api.SubmitJobRequest]) (*connect_go.Response[api.Job], error)
+	// GetJob returns the latest state of a job
+	GetJob(context.Context, *connect_go.Request[api.GetJobRequest]) (*connect_go.Response[api.Job], error)
+	// ListJobs lists the caller's jobs in submission order
+	ListJobs(context.Context, *connect_go.Request[api.ListJobsRequest]) (*connect_go.Response[api.ListJobsResponse], error)
+	// CancelJob asks for a job to be cancelled. Cancellation is asynchronous:
+	// a running job may still finish before it stops.
+	CancelJob(context.Context, *connect_go.Request[api.CancelJobRequest]) (*connect_go.Response[api.Job], error)
+	// WaitJob returns a job once it is done or the timeout passes, whichever
+	// comes first. The returned job is not necessarily done.
+	WaitJob(context.Context, *connect_go.Request[api.WaitJobRequest]) (*connect_go.Response[api.Job], error)
+	// ListResults lists the caller's recorded ProcessData and StreamData
+	// calls, newest first
+	ListResults(context.Context, *connect_go.Request[api.ListResultsRequest]) (*connect_go.Response[api.ListResultsResponse], error)
 }
 
 // NewGrpcServiceHandler builds an HTTP handler from the service implementation. It returns the path
@@ -149,11 +328,56 @@ func NewGrpcServiceHandler(svc GrpcServiceHandler, opts ...connect_go.HandlerOpt
 		svc.ProcessData,
 		opts...,
 	)
+	grpcServiceBatchProcessDataHandler := connect_go.NewUnaryHandler(
+		GrpcServiceBatchProcessDataProcedure,
+		svc.BatchProcessData,
+		opts...,
+	)
 	grpcServiceStreamDataHandler := connect_go.NewServerStreamHandler(
 		GrpcServiceStreamDataProcedure,
 		svc.StreamData,
 		opts...,
 	)
+	grpcServiceUploadDataHandler := connect_go.NewClientStreamHandler(
+		GrpcServiceUploadDataProcedure,
+		svc.UploadData,
+		opts...,
+	)
+	grpcServiceProcessStreamHandler := connect_go.NewBidiStreamHandler(
+		GrpcServiceProcessStreamProcedure,
+		svc.ProcessStream,
+		opts...,
+	)
+	grpcServiceSubmitJobHandler := connect_go.NewUnaryHandler(
+		GrpcServiceSubmitJobProcedure,
+		svc.SubmitJob,
+		opts...,
+	)
+	grpcServiceGetJobHandler := connect_go.NewUnaryHandler(
+		GrpcServiceGetJobProcedure,
+		svc.GetJob,
+		opts...,
+	)
+	grpcServiceListJobsHandler := connect_go.NewUnaryHandler(
+		GrpcServiceListJobsProcedure,
+		svc.ListJobs,
+		opts...,
+	)
+	grpcServiceCancelJobHandler := connect_go.NewUnaryHandler(
+		GrpcServiceCancelJobProcedure,
+		svc.CancelJob,
+		opts...,
+	)
+	grpcServiceWaitJobHandler := connect_go.NewUnaryHandler(
+		GrpcServiceWaitJobProcedure,
+		svc.WaitJob,
+		opts...,
+	)
+	grpcServiceListResultsHandler := connect_go.NewUnaryHandler(
+		GrpcServiceListResultsProcedure,
+		svc.ListResults,
+		opts...,
+	)
 	return "/api.v1.GrpcService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case GrpcServiceGetHealthProcedure:
@@ -162,8 +386,26 @@ func NewGrpcServiceHandler(svc GrpcServiceHandler, opts ...connect_go.HandlerOpt
 			grpcServiceGetInfoHandler.ServeHTTP(w, r)
 		case GrpcServiceProcessDataProcedure:
 			grpcServiceProcessDataHandler.ServeHTTP(w, r)
+		case GrpcServiceBatchProcessDataProcedure:
+			grpcServiceBatchProcessDataHandler.ServeHTTP(w, r)
 		case GrpcServiceStreamDataProcedure:
 			grpcServiceStreamDataHandler.ServeHTTP(w, r)
+		case GrpcServiceUploadDataProcedure:
+			grpcServiceUploadDataHandler.ServeHTTP(w, r)
+		case GrpcServiceProcessStreamProcedure:
+			grpcServiceProcessStreamHandler.ServeHTTP(w, r)
+		case GrpcServiceSubmitJobProcedure:
+			grpcServiceSubmitJobHandler.ServeHTTP(w, r)
+		case GrpcServiceGetJobProcedure:
+			grpcServiceGetJobHandler.ServeHTTP(w, r)
+		case GrpcServiceListJobsProcedure:
+			grpcServiceListJobsHandler.ServeHTTP(w, r)
+		case GrpcServiceCancelJobProcedure:
+			grpcServiceCancelJobHandler.ServeHTTP(w, r)
+		case GrpcServiceWaitJobProcedure:
+			grpcServiceWaitJobHandler.ServeHTTP(w, r)
+		case GrpcServiceListResultsProcedure:
+			grpcServiceListResultsHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -185,6 +427,42 @@ func (UnimplementedGrpcServiceHandler) ProcessData(context.Context, *connect_go.
 	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("api.v1.GrpcService.ProcessData is not implemented"))
 }
 
+func (UnimplementedGrpcServiceHandler) BatchProcessData(context.Context, *connect_go.Request[api.BatchProcessDataRequest]) (*connect_go.Response[api.BatchProcessDataResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("api.v1.GrpcService.BatchProcessData is not implemented"))
+}
+
 func (UnimplementedGrpcServiceHandler) StreamData(context.Context, *connect_go.Request[api.StreamDataRequest], *connect_go.ServerStream[api.StreamDataResponse]) error {
 	return connect_go.NewError(connect_go.CodeUnimplemented, errors.New("api.v1.GrpcService.StreamData is not implemented"))
 }
+
+func (UnimplementedGrpcServiceHandler) UploadData(context.Context, *connect_go.ClientStream[api.UploadDataRequest]) (*connect_go.Response[api.UploadDataResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("api.v1.GrpcService.UploadData is not implemented"))
+}
+
+func (UnimplementedGrpcServiceHandler) ProcessStream(context.Context, *connect_go.BidiStream[api.ProcessStreamRequest, api.ProcessStreamResponse]) error {
+	return connect_go.NewError(connect_go.CodeUnimplemented, errors.New("api.v1.GrpcService.ProcessStream is not implemented"))
+}
+
+func (UnimplementedGrpcServiceHandler) SubmitJob(context.Context, *connect_go.Request[api.SubmitJobRequest]) (*connect_go.Response[api.Job], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("api.v1.GrpcService.SubmitJob is not implemented"))
+}
+
+func (UnimplementedGrpcServiceHandler) GetJob(context.Context, *connect_go.Request[api.GetJobRequest]) (*connect_go.Response[api.Job], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("api.v1.GrpcService.GetJob is not implemented"))
+}
+
+func (UnimplementedGrpcServiceHandler) ListJobs(context.Context, *connect_go.Request[api.ListJobsRequest]) (*connect_go.Response[api.ListJobsResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("api.v1.GrpcService.ListJobs is not implemented"))
+}
+
+func (UnimplementedGrpcServiceHandler) CancelJob(context.Context, *connect_go.Request[api.CancelJobRequest]) (*connect_go.Response[api.Job], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("api.v1.GrpcService.CancelJob is not implemented"))
+}
+
+func (UnimplementedGrpcServiceHandler) WaitJob(context.Context, *connect_go.Request[api.WaitJobRequest]) (*connect_go.Response[api.Job], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("api.v1.GrpcService.WaitJob is not implemented"))
+}
+
+func (UnimplementedGrpcServiceHandler) ListResults(context.Context, *connect_go.Request[api.ListResultsRequest]) (*connect_go.Response[api.ListResultsResponse], error) {
+	return nil, connect_go.NewError(connect_go.CodeUnimplemented, errors.New("api.v1.GrpcService.ListResults is not implemented"))
+}
@@ -9,6 +9,7 @@ package apiv1
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
@@ -22,6 +23,66 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// JobState is the lifecycle state of a job
+type JobState int32
+
+const (
+	JobState_JOB_STATE_UNSPECIFIED JobState = 0
+	// PENDING jobs are waiting for a worker
+	JobState_JOB_STATE_PENDING   JobState = 1
+	JobState_JOB_STATE_RUNNING   JobState = 2
+	JobState_JOB_STATE_SUCCEEDED JobState = 3
+	JobState_JOB_STATE_FAILED    JobState = 4
+	JobState_JOB_STATE_CANCELLED JobState = 5
+)
+
+// Enum value maps for JobState.
+var (
+	JobState_name = map[int32]string{
+		0: "JOB_STATE_UNSPECIFIED",
+		1: "JOB_STATE_PENDING",
+		2: "JOB_STATE_RUNNING",
+		3: "JOB_STATE_SUCCEEDED",
+		4: "JOB_STATE_FAILED",
+		5: "JOB_STATE_CANCELLED",
+	}
+	JobState_value = map[string]int32{
+		"JOB_STATE_UNSPECIFIED": 0,
+		"JOB_STATE_PENDING":     1,
+		"JOB_STATE_RUNNING":     2,
+		"JOB_STATE_SUCCEEDED":   3,
+		"JOB_STATE_FAILED":      4,
+		"JOB_STATE_CANCELLED":   5,
+	}
+)
+
+func (x JobState) Enum() *JobState {
+	p := new(JobState)
+	*p = x
+	return p
+}
+
+func (x JobState) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (JobState) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_grpc_service_proto_enumTypes[0].Descriptor()
+}
+
+func (JobState) Type() protoreflect.EnumType {
+	return &file_api_grpc_service_proto_enumTypes[0]
+}
+
+func (x JobState) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use JobState.Descriptor instead.
+func (JobState) EnumDescriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{0}
+}
+
 // GetHealthRequest is the request for GetHealth
 type GetHealthRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -281,11 +342,16 @@ func (x *ProcessDataRequest) GetOptions() map[string]string {
 
 // ProcessDataResponse is the response for ProcessData
 type ProcessDataResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Result        string                 `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
-	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
-	ErrorMessage  string                 `protobuf:"bytes,3,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
-	ProcessedAt   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=processed_at,json=processedAt,proto3" json:"processed_at,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Result string                 `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	// success is false when a processor rejected the input; other failures
+	// are returned as errors with google.rpc details
+	Success      bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	ErrorMessage string                 `protobuf:"bytes,3,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	ProcessedAt  *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=processed_at,json=processedAt,proto3" json:"processed_at,omitempty"`
+	// error_reason is the google.rpc.ErrorInfo reason of a rejected input,
+	// INVALID_INPUT
+	ErrorReason   string `protobuf:"bytes,5,opt,name=error_reason,json=errorReason,proto3" json:"error_reason,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -348,18 +414,302 @@ func (x *ProcessDataResponse) GetProcessedAt() *timestamppb.Timestamp {
 	return nil
 }
 
-// StreamDataRequest is the request for StreamData
-type StreamDataRequest struct {
+func (x *ProcessDataResponse) GetErrorReason() string {
+	if x != nil {
+		return x.ErrorReason
+	}
+	return ""
+}
+
+// BatchProcessDataRequest is the request for BatchProcessData
+type BatchProcessDataRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// items are processed concurrently, up to a server-defined number per batch
+	Items         []*ProcessDataRequest `protobuf:"bytes,1,rep,name=items,proto3" json:"items,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchProcessDataRequest) Reset() {
+	*x = BatchProcessDataRequest{}
+	mi := &file_api_grpc_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchProcessDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchProcessDataRequest) ProtoMessage() {}
+
+func (x *BatchProcessDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchProcessDataRequest.ProtoReflect.Descriptor instead.
+func (*BatchProcessDataRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BatchProcessDataRequest) GetItems() []*ProcessDataRequest {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+// BatchProcessDataResponse is the response for BatchProcessData
+type BatchProcessDataResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// results has one entry per item, in the order of the items
+	Results       []*BatchProcessDataResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	Stats         *BatchProcessDataStats    `protobuf:"bytes,2,opt,name=stats,proto3" json:"stats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchProcessDataResponse) Reset() {
+	*x = BatchProcessDataResponse{}
+	mi := &file_api_grpc_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchProcessDataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchProcessDataResponse) ProtoMessage() {}
+
+func (x *BatchProcessDataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchProcessDataResponse.ProtoReflect.Descriptor instead.
+func (*BatchProcessDataResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *BatchProcessDataResponse) GetResults() []*BatchProcessDataResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *BatchProcessDataResponse) GetStats() *BatchProcessDataStats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+// BatchProcessDataResult is the result of one BatchProcessData item
+type BatchProcessDataResult struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Result       string                 `protobuf:"bytes,1,opt,name=result,proto3" json:"result,omitempty"`
+	Success      bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	ErrorMessage string                 `protobuf:"bytes,3,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	// error_code is the status code ProcessData would have failed with, such
+	// as invalid_argument; it is empty when the item succeeded or its input
+	// was rejected by a processor
+	ErrorCode   string                 `protobuf:"bytes,4,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	ProcessedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=processed_at,json=processedAt,proto3" json:"processed_at,omitempty"`
+	// seed replays the random result when sent as the seed option
+	Seed string `protobuf:"bytes,6,opt,name=seed,proto3" json:"seed,omitempty"`
+	// error_reason is the google.rpc.ErrorInfo reason of the failure, such as
+	// INVALID_INPUT or UNKNOWN_PROCESSOR
+	ErrorReason   string `protobuf:"bytes,7,opt,name=error_reason,json=errorReason,proto3" json:"error_reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchProcessDataResult) Reset() {
+	*x = BatchProcessDataResult{}
+	mi := &file_api_grpc_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchProcessDataResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchProcessDataResult) ProtoMessage() {}
+
+func (x *BatchProcessDataResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchProcessDataResult.ProtoReflect.Descriptor instead.
+func (*BatchProcessDataResult) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *BatchProcessDataResult) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *BatchProcessDataResult) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *BatchProcessDataResult) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *BatchProcessDataResult) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *BatchProcessDataResult) GetProcessedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ProcessedAt
+	}
+	return nil
+}
+
+func (x *BatchProcessDataResult) GetSeed() string {
+	if x != nil {
+		return x.Seed
+	}
+	return ""
+}
+
+func (x *BatchProcessDataResult) GetErrorReason() string {
+	if x != nil {
+		return x.ErrorReason
+	}
+	return ""
+}
+
+// BatchProcessDataStats summarizes a BatchProcessData call
+type BatchProcessDataStats struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
-	Limit         int32                  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	Total         int32                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Succeeded     int32                  `protobuf:"varint,2,opt,name=succeeded,proto3" json:"succeeded,omitempty"`
+	Failed        int32                  `protobuf:"varint,3,opt,name=failed,proto3" json:"failed,omitempty"`
+	Duration      *durationpb.Duration   `protobuf:"bytes,4,opt,name=duration,proto3" json:"duration,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
+func (x *BatchProcessDataStats) Reset() {
+	*x = BatchProcessDataStats{}
+	mi := &file_api_grpc_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchProcessDataStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchProcessDataStats) ProtoMessage() {}
+
+func (x *BatchProcessDataStats) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchProcessDataStats.ProtoReflect.Descriptor instead.
+func (*BatchProcessDataStats) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *BatchProcessDataStats) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *BatchProcessDataStats) GetSucceeded() int32 {
+	if x != nil {
+		return x.Succeeded
+	}
+	return 0
+}
+
+func (x *BatchProcessDataStats) GetFailed() int32 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *BatchProcessDataStats) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+// StreamDataRequest is the request for StreamData
+type StreamDataRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Query string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	// limit is the sequence number of the last message; resumed streams must
+	// send the same limit. 0 uses the default of 10; negative values and values
+	// above the server maximum are rejected, as for page_size.
+	Limit int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	// resume_token is the cursor of the last message received. The stream
+	// continues after it. It must come from a stream with the same query.
+	ResumeToken string `protobuf:"bytes,3,opt,name=resume_token,json=resumeToken,proto3" json:"resume_token,omitempty"`
+	// start_after_sequence continues the stream after this sequence number;
+	// it cannot be combined with resume_token
+	StartAfterSequence int32 `protobuf:"varint,4,opt,name=start_after_sequence,json=startAfterSequence,proto3" json:"start_after_sequence,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
 func (x *StreamDataRequest) Reset() {
 	*x = StreamDataRequest{}
-	mi := &file_api_grpc_service_proto_msgTypes[6]
+	mi := &file_api_grpc_service_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -371,7 +721,7 @@ func (x *StreamDataRequest) String() string {
 func (*StreamDataRequest) ProtoMessage() {}
 
 func (x *StreamDataRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_grpc_service_proto_msgTypes[6]
+	mi := &file_api_grpc_service_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -384,7 +734,7 @@ func (x *StreamDataRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StreamDataRequest.ProtoReflect.Descriptor instead.
 func (*StreamDataRequest) Descriptor() ([]byte, []int) {
-	return file_api_grpc_service_proto_rawDescGZIP(), []int{6}
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *StreamDataRequest) GetQuery() string {
@@ -401,19 +751,35 @@ func (x *StreamDataRequest) GetLimit() int32 {
 	return 0
 }
 
+func (x *StreamDataRequest) GetResumeToken() string {
+	if x != nil {
+		return x.ResumeToken
+	}
+	return ""
+}
+
+func (x *StreamDataRequest) GetStartAfterSequence() int32 {
+	if x != nil {
+		return x.StartAfterSequence
+	}
+	return 0
+}
+
 // StreamDataResponse is the response for StreamData
 type StreamDataResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Data          string                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
-	Sequence      int32                  `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
-	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Data      string                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Sequence  int32                  `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// cursor is an opaque token that resumes the stream after this message
+	Cursor        string `protobuf:"bytes,4,opt,name=cursor,proto3" json:"cursor,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *StreamDataResponse) Reset() {
 	*x = StreamDataResponse{}
-	mi := &file_api_grpc_service_proto_msgTypes[7]
+	mi := &file_api_grpc_service_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -425,7 +791,7 @@ func (x *StreamDataResponse) String() string {
 func (*StreamDataResponse) ProtoMessage() {}
 
 func (x *StreamDataResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_grpc_service_proto_msgTypes[7]
+	mi := &file_api_grpc_service_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -438,7 +804,7 @@ func (x *StreamDataResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use StreamDataResponse.ProtoReflect.Descriptor instead.
 func (*StreamDataResponse) Descriptor() ([]byte, []int) {
-	return file_api_grpc_service_proto_rawDescGZIP(), []int{7}
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *StreamDataResponse) GetData() string {
@@ -462,53 +828,1436 @@ func (x *StreamDataResponse) GetTimestamp() *timestamppb.Timestamp {
 	return nil
 }
 
-var File_api_grpc_service_proto protoreflect.FileDescriptor
+func (x *StreamDataResponse) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
 
-const file_api_grpc_service_proto_rawDesc = "" +
-	"\n" +
-	"\x16api/grpc_service.proto\x12\x06api.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x12\n" +
-	"\x10GetHealthRequest\"\xe3\x01\n" +
-	"\x11GetHealthResponse\x12\x16\n" +
-	"\x06status\x18\x01 \x01(\tR\x06status\x128\n" +
-	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12@\n" +
-	"\adetails\x18\x03 \x03(\v2&.api.v1.GetHealthResponse.DetailsEntryR\adetails\x1a:\n" +
-	"\fDetailsEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x10\n" +
-	"\x0eGetInfoRequest\"\x88\x02\n" +
-	"\x0fGetInfoResponse\x12\x18\n" +
-	"\aversion\x18\x01 \x01(\tR\aversion\x12 \n" +
-	"\venvironment\x18\x02 \x01(\tR\venvironment\x129\n" +
-	"\n" +
-	"start_time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tstartTime\x12A\n" +
-	"\bmetadata\x18\x04 \x03(\v2%.api.v1.GetInfoResponse.MetadataEntryR\bmetadata\x1a;\n" +
-	"\rMetadataEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa7\x01\n" +
-	"\x12ProcessDataRequest\x12\x12\n" +
-	"\x04data\x18\x01 \x01(\tR\x04data\x12A\n" +
-	"\aoptions\x18\x02 \x03(\v2'.api.v1.ProcessDataRequest.OptionsEntryR\aoptions\x1a:\n" +
-	"\fOptionsEntry\x12\x10\n" +
-	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xab\x01\n" +
-	"\x13ProcessDataResponse\x12\x16\n" +
-	"\x06result\x18\x01 \x01(\tR\x06result\x12\x18\n" +
+// UploadDataRequest is one record of an UploadData stream
+type UploadDataRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          string                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Options       map[string]string      `protobuf:"bytes,2,rep,name=options,proto3" json:"options,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadDataRequest) Reset() {
+	*x = UploadDataRequest{}
+	mi := &file_api_grpc_service_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadDataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadDataRequest) ProtoMessage() {}
+
+func (x *UploadDataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadDataRequest.ProtoReflect.Descriptor instead.
+func (*UploadDataRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *UploadDataRequest) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *UploadDataRequest) GetOptions() map[string]string {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+// UploadDataResponse summarizes an UploadData stream
+type UploadDataResponse struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Received  int32                  `protobuf:"varint,1,opt,name=received,proto3" json:"received,omitempty"`
+	Succeeded int32                  `protobuf:"varint,2,opt,name=succeeded,proto3" json:"succeeded,omitempty"`
+	Failed    int32                  `protobuf:"varint,3,opt,name=failed,proto3" json:"failed,omitempty"`
+	// failures lists failed records in order, up to a server-defined maximum;
+	// failed counts all of them
+	Failures      []*UploadFailure       `protobuf:"bytes,4,rep,name=failures,proto3" json:"failures,omitempty"`
+	BytesReceived int64                  `protobuf:"varint,5,opt,name=bytes_received,json=bytesReceived,proto3" json:"bytes_received,omitempty"`
+	StartedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	Duration      *durationpb.Duration   `protobuf:"bytes,7,opt,name=duration,proto3" json:"duration,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadDataResponse) Reset() {
+	*x = UploadDataResponse{}
+	mi := &file_api_grpc_service_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadDataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadDataResponse) ProtoMessage() {}
+
+func (x *UploadDataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadDataResponse.ProtoReflect.Descriptor instead.
+func (*UploadDataResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *UploadDataResponse) GetReceived() int32 {
+	if x != nil {
+		return x.Received
+	}
+	return 0
+}
+
+func (x *UploadDataResponse) GetSucceeded() int32 {
+	if x != nil {
+		return x.Succeeded
+	}
+	return 0
+}
+
+func (x *UploadDataResponse) GetFailed() int32 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *UploadDataResponse) GetFailures() []*UploadFailure {
+	if x != nil {
+		return x.Failures
+	}
+	return nil
+}
+
+func (x *UploadDataResponse) GetBytesReceived() int64 {
+	if x != nil {
+		return x.BytesReceived
+	}
+	return 0
+}
+
+func (x *UploadDataResponse) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *UploadDataResponse) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+// UploadFailure describes a record that could not be processed
+type UploadFailure struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// index is the zero-based position of the record in the stream
+	Index         int32  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	ErrorMessage  string `protobuf:"bytes,2,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadFailure) Reset() {
+	*x = UploadFailure{}
+	mi := &file_api_grpc_service_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadFailure) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadFailure) ProtoMessage() {}
+
+func (x *UploadFailure) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadFailure.ProtoReflect.Descriptor instead.
+func (*UploadFailure) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *UploadFailure) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *UploadFailure) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+// ProcessStreamRequest is one request of a ProcessStream stream
+type ProcessStreamRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// id correlates the request with its result; clients should keep ids
+	// unique among the requests in flight
+	Id            string            `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Data          string            `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	Options       map[string]string `protobuf:"bytes,3,rep,name=options,proto3" json:"options,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessStreamRequest) Reset() {
+	*x = ProcessStreamRequest{}
+	mi := &file_api_grpc_service_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessStreamRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessStreamRequest) ProtoMessage() {}
+
+func (x *ProcessStreamRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessStreamRequest.ProtoReflect.Descriptor instead.
+func (*ProcessStreamRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ProcessStreamRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ProcessStreamRequest) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *ProcessStreamRequest) GetOptions() map[string]string {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+// ProcessStreamResponse is the result of one ProcessStream request
+type ProcessStreamResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// id is the id of the request this result answers
+	Id           string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Result       string `protobuf:"bytes,2,opt,name=result,proto3" json:"result,omitempty"`
+	Success      bool   `protobuf:"varint,3,opt,name=success,proto3" json:"success,omitempty"`
+	ErrorMessage string `protobuf:"bytes,4,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	// error_code is the status code ProcessData would have failed with, such
+	// as invalid_argument; it is empty when the request succeeded or its input
+	// was rejected by a processor
+	ErrorCode   string                 `protobuf:"bytes,5,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	ProcessedAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=processed_at,json=processedAt,proto3" json:"processed_at,omitempty"`
+	// seed replays the random result when sent as the seed option
+	Seed string `protobuf:"bytes,7,opt,name=seed,proto3" json:"seed,omitempty"`
+	// error_reason is the google.rpc.ErrorInfo reason of the failure, such as
+	// INVALID_INPUT or UNKNOWN_PROCESSOR
+	ErrorReason   string `protobuf:"bytes,8,opt,name=error_reason,json=errorReason,proto3" json:"error_reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessStreamResponse) Reset() {
+	*x = ProcessStreamResponse{}
+	mi := &file_api_grpc_service_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessStreamResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessStreamResponse) ProtoMessage() {}
+
+func (x *ProcessStreamResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessStreamResponse.ProtoReflect.Descriptor instead.
+func (*ProcessStreamResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ProcessStreamResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ProcessStreamResponse) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+func (x *ProcessStreamResponse) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ProcessStreamResponse) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *ProcessStreamResponse) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *ProcessStreamResponse) GetProcessedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ProcessedAt
+	}
+	return nil
+}
+
+func (x *ProcessStreamResponse) GetSeed() string {
+	if x != nil {
+		return x.Seed
+	}
+	return ""
+}
+
+func (x *ProcessStreamResponse) GetErrorReason() string {
+	if x != nil {
+		return x.ErrorReason
+	}
+	return ""
+}
+
+// Job is an asynchronous ProcessData call, modeled on
+// google.longrunning.Operation
+type Job struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name is the job's resource name, "jobs/{id}"
+	Name  string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	State JobState `protobuf:"varint,2,opt,name=state,proto3,enum=api.v1.JobState" json:"state,omitempty"`
+	// done is true once the job has succeeded, failed or been cancelled;
+	// exactly one of response and error is then set
+	Done bool `protobuf:"varint,3,opt,name=done,proto3" json:"done,omitempty"`
+	// Types that are valid to be assigned to Result:
+	//
+	//	*Job_Response
+	//	*Job_Error
+	Result        isJob_Result `protobuf_oneof:"result"`
+	Metadata      *JobMetadata `protobuf:"bytes,6,opt,name=metadata,proto3" json:"metadata,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Job) Reset() {
+	*x = Job{}
+	mi := &file_api_grpc_service_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Job) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Job) ProtoMessage() {}
+
+func (x *Job) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Job.ProtoReflect.Descriptor instead.
+func (*Job) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Job) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Job) GetState() JobState {
+	if x != nil {
+		return x.State
+	}
+	return JobState_JOB_STATE_UNSPECIFIED
+}
+
+func (x *Job) GetDone() bool {
+	if x != nil {
+		return x.Done
+	}
+	return false
+}
+
+func (x *Job) GetResult() isJob_Result {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *Job) GetResponse() *ProcessDataResponse {
+	if x != nil {
+		if x, ok := x.Result.(*Job_Response); ok {
+			return x.Response
+		}
+	}
+	return nil
+}
+
+func (x *Job) GetError() *JobError {
+	if x != nil {
+		if x, ok := x.Result.(*Job_Error); ok {
+			return x.Error
+		}
+	}
+	return nil
+}
+
+func (x *Job) GetMetadata() *JobMetadata {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type isJob_Result interface {
+	isJob_Result()
+}
+
+type Job_Response struct {
+	// response is what ProcessData would have returned
+	Response *ProcessDataResponse `protobuf:"bytes,4,opt,name=response,proto3,oneof"`
+}
+
+type Job_Error struct {
+	// error is why the job failed or that it was cancelled
+	Error *JobError `protobuf:"bytes,5,opt,name=error,proto3,oneof"`
+}
+
+func (*Job_Response) isJob_Result() {}
+
+func (*Job_Error) isJob_Result() {}
+
+// JobError is the status a failed or cancelled job ended with
+type JobError struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// code is the status code name, such as invalid_argument or canceled
+	Code    string `protobuf:"bytes,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// reason is the google.rpc.ErrorInfo reason of the failure, such as
+	// UNKNOWN_PROCESSOR or CANCELED
+	Reason        string `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobError) Reset() {
+	*x = JobError{}
+	mi := &file_api_grpc_service_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobError) ProtoMessage() {}
+
+func (x *JobError) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobError.ProtoReflect.Descriptor instead.
+func (*JobError) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *JobError) GetCode() string {
+	if x != nil {
+		return x.Code
+	}
+	return ""
+}
+
+func (x *JobError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *JobError) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// JobMetadata reports the progress of a job
+type JobMetadata struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// progress_percent is the completed share of the job, from 0 to 100
+	ProgressPercent int32                  `protobuf:"varint,1,opt,name=progress_percent,json=progressPercent,proto3" json:"progress_percent,omitempty"`
+	CreateTime      *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	StartTime       *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime         *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	// seed replays the random result when sent as the seed option
+	Seed          string `protobuf:"bytes,5,opt,name=seed,proto3" json:"seed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobMetadata) Reset() {
+	*x = JobMetadata{}
+	mi := &file_api_grpc_service_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobMetadata) ProtoMessage() {}
+
+func (x *JobMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobMetadata.ProtoReflect.Descriptor instead.
+func (*JobMetadata) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *JobMetadata) GetProgressPercent() int32 {
+	if x != nil {
+		return x.ProgressPercent
+	}
+	return 0
+}
+
+func (x *JobMetadata) GetCreateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreateTime
+	}
+	return nil
+}
+
+func (x *JobMetadata) GetStartTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartTime
+	}
+	return nil
+}
+
+func (x *JobMetadata) GetEndTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndTime
+	}
+	return nil
+}
+
+func (x *JobMetadata) GetSeed() string {
+	if x != nil {
+		return x.Seed
+	}
+	return ""
+}
+
+// SubmitJobRequest is the request for SubmitJob
+type SubmitJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Request       *ProcessDataRequest    `protobuf:"bytes,1,opt,name=request,proto3" json:"request,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitJobRequest) Reset() {
+	*x = SubmitJobRequest{}
+	mi := &file_api_grpc_service_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitJobRequest) ProtoMessage() {}
+
+func (x *SubmitJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitJobRequest.ProtoReflect.Descriptor instead.
+func (*SubmitJobRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *SubmitJobRequest) GetRequest() *ProcessDataRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+// GetJobRequest is the request for GetJob
+type GetJobRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// name is the job's resource name, "jobs/{id}"
+	Name          string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetJobRequest) Reset() {
+	*x = GetJobRequest{}
+	mi := &file_api_grpc_service_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetJobRequest) ProtoMessage() {}
+
+func (x *GetJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetJobRequest.ProtoReflect.Descriptor instead.
+func (*GetJobRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *GetJobRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// ListJobsRequest is the request for ListJobs
+type ListJobsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// page_size is the most jobs to return; 0 uses the server default
+	PageSize int32 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// page_token is the next_page_token of a previous response made with the
+	// same filter
+	PageToken string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// filter is an AIP-160 style expression over state, done, create_time,
+	// start_time and end_time, e.g. state = "JOB_STATE_FAILED" AND
+	// end_time > "2025-01-01"
+	Filter        string `protobuf:"bytes,3,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJobsRequest) Reset() {
+	*x = ListJobsRequest{}
+	mi := &file_api_grpc_service_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJobsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobsRequest) ProtoMessage() {}
+
+func (x *ListJobsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobsRequest.ProtoReflect.Descriptor instead.
+func (*ListJobsRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ListJobsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListJobsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListJobsRequest) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+// ListJobsResponse is the response for ListJobs
+type ListJobsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Jobs  []*Job                 `protobuf:"bytes,1,rep,name=jobs,proto3" json:"jobs,omitempty"`
+	// next_page_token is empty on the last page
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListJobsResponse) Reset() {
+	*x = ListJobsResponse{}
+	mi := &file_api_grpc_service_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListJobsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListJobsResponse) ProtoMessage() {}
+
+func (x *ListJobsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListJobsResponse.ProtoReflect.Descriptor instead.
+func (*ListJobsResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ListJobsResponse) GetJobs() []*Job {
+	if x != nil {
+		return x.Jobs
+	}
+	return nil
+}
+
+func (x *ListJobsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// CancelJobRequest is the request for CancelJob
+type CancelJobRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelJobRequest) Reset() {
+	*x = CancelJobRequest{}
+	mi := &file_api_grpc_service_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelJobRequest) ProtoMessage() {}
+
+func (x *CancelJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelJobRequest.ProtoReflect.Descriptor instead.
+func (*CancelJobRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *CancelJobRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+// WaitJobRequest is the request for WaitJob
+type WaitJobRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// timeout bounds the wait; it defaults to and is capped at a server
+	// maximum
+	Timeout       *durationpb.Duration `protobuf:"bytes,2,opt,name=timeout,proto3" json:"timeout,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WaitJobRequest) Reset() {
+	*x = WaitJobRequest{}
+	mi := &file_api_grpc_service_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WaitJobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WaitJobRequest) ProtoMessage() {}
+
+func (x *WaitJobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WaitJobRequest.ProtoReflect.Descriptor instead.
+func (*WaitJobRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *WaitJobRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WaitJobRequest) GetTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.Timeout
+	}
+	return nil
+}
+
+// ListResultsRequest is the request for ListResults. Filters combine with
+// AND; unset filters match every result.
+type ListResultsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// page_size is the most results to return; 0 uses the server default
+	PageSize int32 `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// page_token is the next_page_token of a previous response made with the
+	// same filters
+	PageToken string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// method keeps results of one RPC, ProcessData or StreamData
+	Method string `protobuf:"bytes,3,opt,name=method,proto3" json:"method,omitempty"`
+	// success keeps results that did or did not succeed
+	Success *bool `protobuf:"varint,4,opt,name=success,proto3,oneof" json:"success,omitempty"`
+	// start_time keeps results recorded at or after it
+	StartTime *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	// end_time keeps results recorded before it
+	EndTime *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	// filter is an AIP-160 style expression over id, method, status, success,
+	// input, output, error_code, error_message and create_time (or its alias
+	// processed_at), e.g. status = "ok" AND processed_at > "2025-01-01". It is
+	// combined with the fields above.
+	Filter        string `protobuf:"bytes,7,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResultsRequest) Reset() {
+	*x = ListResultsRequest{}
+	mi := &file_api_grpc_service_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResultsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResultsRequest) ProtoMessage() {}
+
+func (x *ListResultsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResultsRequest.ProtoReflect.Descriptor instead.
+func (*ListResultsRequest) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ListResultsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListResultsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListResultsRequest) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *ListResultsRequest) GetSuccess() bool {
+	if x != nil && x.Success != nil {
+		return *x.Success
+	}
+	return false
+}
+
+func (x *ListResultsRequest) GetStartTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartTime
+	}
+	return nil
+}
+
+func (x *ListResultsRequest) GetEndTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndTime
+	}
+	return nil
+}
+
+func (x *ListResultsRequest) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+// ListResultsResponse is the response for ListResults
+type ListResultsResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Results []*ProcessedResult     `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	// next_page_token is empty on the last page
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListResultsResponse) Reset() {
+	*x = ListResultsResponse{}
+	mi := &file_api_grpc_service_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListResultsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListResultsResponse) ProtoMessage() {}
+
+func (x *ListResultsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListResultsResponse.ProtoReflect.Descriptor instead.
+func (*ListResultsResponse) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ListResultsResponse) GetResults() []*ProcessedResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *ListResultsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// ProcessedResult is a recorded call and its outcome
+type ProcessedResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// method is the RPC that was called
+	Method string `protobuf:"bytes,2,opt,name=method,proto3" json:"method,omitempty"`
+	// input is the data of a ProcessData call or the query of a StreamData
+	// call
+	Input   string            `protobuf:"bytes,3,opt,name=input,proto3" json:"input,omitempty"`
+	Options map[string]string `protobuf:"bytes,4,rep,name=options,proto3" json:"options,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// output is the ProcessData result or a summary of the stream
+	Output  string `protobuf:"bytes,5,opt,name=output,proto3" json:"output,omitempty"`
+	Success bool   `protobuf:"varint,6,opt,name=success,proto3" json:"success,omitempty"`
+	// error_code is the status code name the call failed with, such as
+	// invalid_argument; it is empty when the call returned normally
+	ErrorCode    string                 `protobuf:"bytes,7,opt,name=error_code,json=errorCode,proto3" json:"error_code,omitempty"`
+	ErrorMessage string                 `protobuf:"bytes,8,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`
+	CreateTime   *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=create_time,json=createTime,proto3" json:"create_time,omitempty"`
+	// status is "ok" for calls that succeeded and the error code otherwise
+	Status        string `protobuf:"bytes,10,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProcessedResult) Reset() {
+	*x = ProcessedResult{}
+	mi := &file_api_grpc_service_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProcessedResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProcessedResult) ProtoMessage() {}
+
+func (x *ProcessedResult) ProtoReflect() protoreflect.Message {
+	mi := &file_api_grpc_service_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProcessedResult.ProtoReflect.Descriptor instead.
+func (*ProcessedResult) Descriptor() ([]byte, []int) {
+	return file_api_grpc_service_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ProcessedResult) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ProcessedResult) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *ProcessedResult) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+func (x *ProcessedResult) GetOptions() map[string]string {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+func (x *ProcessedResult) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *ProcessedResult) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ProcessedResult) GetErrorCode() string {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return ""
+}
+
+func (x *ProcessedResult) GetErrorMessage() string {
+	if x != nil {
+		return x.ErrorMessage
+	}
+	return ""
+}
+
+func (x *ProcessedResult) GetCreateTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreateTime
+	}
+	return nil
+}
+
+func (x *ProcessedResult) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+var File_api_grpc_service_proto protoreflect.FileDescriptor
+
+const file_api_grpc_service_proto_rawDesc = "" +
+	"\n" +
+	"\x16api/grpc_service.proto\x12\x06api.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x12\n" +
+	"\x10GetHealthRequest\"\xe3\x01\n" +
+	"\x11GetHealthResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x128\n" +
+	"\ttimestamp\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12@\n" +
+	"\adetails\x18\x03 \x03(\v2&.api.v1.GetHealthResponse.DetailsEntryR\adetails\x1a:\n" +
+	"\fDetailsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x10\n" +
+	"\x0eGetInfoRequest\"\x88\x02\n" +
+	"\x0fGetInfoResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12 \n" +
+	"\venvironment\x18\x02 \x01(\tR\venvironment\x129\n" +
+	"\n" +
+	"start_time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tstartTime\x12A\n" +
+	"\bmetadata\x18\x04 \x03(\v2%.api.v1.GetInfoResponse.MetadataEntryR\bmetadata\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xa7\x01\n" +
+	"\x12ProcessDataRequest\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\tR\x04data\x12A\n" +
+	"\aoptions\x18\x02 \x03(\v2'.api.v1.ProcessDataRequest.OptionsEntryR\aoptions\x1a:\n" +
+	"\fOptionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xce\x01\n" +
+	"\x13ProcessDataResponse\x12\x16\n" +
+	"\x06result\x18\x01 \x01(\tR\x06result\x12\x18\n" +
 	"\asuccess\x18\x02 \x01(\bR\asuccess\x12#\n" +
 	"\rerror_message\x18\x03 \x01(\tR\ferrorMessage\x12=\n" +
-	"\fprocessed_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vprocessedAt\"?\n" +
+	"\fprocessed_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\vprocessedAt\x12!\n" +
+	"\ferror_reason\x18\x05 \x01(\tR\verrorReason\"K\n" +
+	"\x17BatchProcessDataRequest\x120\n" +
+	"\x05items\x18\x01 \x03(\v2\x1a.api.v1.ProcessDataRequestR\x05items\"\x89\x01\n" +
+	"\x18BatchProcessDataResponse\x128\n" +
+	"\aresults\x18\x01 \x03(\v2\x1e.api.v1.BatchProcessDataResultR\aresults\x123\n" +
+	"\x05stats\x18\x02 \x01(\v2\x1d.api.v1.BatchProcessDataStatsR\x05stats\"\x84\x02\n" +
+	"\x16BatchProcessDataResult\x12\x16\n" +
+	"\x06result\x18\x01 \x01(\tR\x06result\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12#\n" +
+	"\rerror_message\x18\x03 \x01(\tR\ferrorMessage\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\x04 \x01(\tR\terrorCode\x12=\n" +
+	"\fprocessed_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\vprocessedAt\x12\x12\n" +
+	"\x04seed\x18\x06 \x01(\tR\x04seed\x12!\n" +
+	"\ferror_reason\x18\a \x01(\tR\verrorReason\"\x9a\x01\n" +
+	"\x15BatchProcessDataStats\x12\x14\n" +
+	"\x05total\x18\x01 \x01(\x05R\x05total\x12\x1c\n" +
+	"\tsucceeded\x18\x02 \x01(\x05R\tsucceeded\x12\x16\n" +
+	"\x06failed\x18\x03 \x01(\x05R\x06failed\x125\n" +
+	"\bduration\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\bduration\"\x94\x01\n" +
 	"\x11StreamDataRequest\x12\x14\n" +
 	"\x05query\x18\x01 \x01(\tR\x05query\x12\x14\n" +
-	"\x05limit\x18\x02 \x01(\x05R\x05limit\"~\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\x12!\n" +
+	"\fresume_token\x18\x03 \x01(\tR\vresumeToken\x120\n" +
+	"\x14start_after_sequence\x18\x04 \x01(\x05R\x12startAfterSequence\"\x96\x01\n" +
 	"\x12StreamDataResponse\x12\x12\n" +
 	"\x04data\x18\x01 \x01(\tR\x04data\x12\x1a\n" +
 	"\bsequence\x18\x02 \x01(\x05R\bsequence\x128\n" +
-	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp2\x9a\x02\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x16\n" +
+	"\x06cursor\x18\x04 \x01(\tR\x06cursor\"\xa5\x01\n" +
+	"\x11UploadDataRequest\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\tR\x04data\x12@\n" +
+	"\aoptions\x18\x02 \x03(\v2&.api.v1.UploadDataRequest.OptionsEntryR\aoptions\x1a:\n" +
+	"\fOptionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb2\x02\n" +
+	"\x12UploadDataResponse\x12\x1a\n" +
+	"\breceived\x18\x01 \x01(\x05R\breceived\x12\x1c\n" +
+	"\tsucceeded\x18\x02 \x01(\x05R\tsucceeded\x12\x16\n" +
+	"\x06failed\x18\x03 \x01(\x05R\x06failed\x121\n" +
+	"\bfailures\x18\x04 \x03(\v2\x15.api.v1.UploadFailureR\bfailures\x12%\n" +
+	"\x0ebytes_received\x18\x05 \x01(\x03R\rbytesReceived\x129\n" +
+	"\n" +
+	"started_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x125\n" +
+	"\bduration\x18\a \x01(\v2\x19.google.protobuf.DurationR\bduration\"J\n" +
+	"\rUploadFailure\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12#\n" +
+	"\rerror_message\x18\x02 \x01(\tR\ferrorMessage\"\xbb\x01\n" +
+	"\x14ProcessStreamRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\tR\x04data\x12C\n" +
+	"\aoptions\x18\x03 \x03(\v2).api.v1.ProcessStreamRequest.OptionsEntryR\aoptions\x1a:\n" +
+	"\fOptionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x93\x02\n" +
+	"\x15ProcessStreamResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x16\n" +
+	"\x06result\x18\x02 \x01(\tR\x06result\x12\x18\n" +
+	"\asuccess\x18\x03 \x01(\bR\asuccess\x12#\n" +
+	"\rerror_message\x18\x04 \x01(\tR\ferrorMessage\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\x05 \x01(\tR\terrorCode\x12=\n" +
+	"\fprocessed_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\vprocessedAt\x12\x12\n" +
+	"\x04seed\x18\a \x01(\tR\x04seed\x12!\n" +
+	"\ferror_reason\x18\b \x01(\tR\verrorReason\"\xf5\x01\n" +
+	"\x03Job\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12&\n" +
+	"\x05state\x18\x02 \x01(\x0e2\x10.api.v1.JobStateR\x05state\x12\x12\n" +
+	"\x04done\x18\x03 \x01(\bR\x04done\x129\n" +
+	"\bresponse\x18\x04 \x01(\v2\x1b.api.v1.ProcessDataResponseH\x00R\bresponse\x12(\n" +
+	"\x05error\x18\x05 \x01(\v2\x10.api.v1.JobErrorH\x00R\x05error\x12/\n" +
+	"\bmetadata\x18\x06 \x01(\v2\x13.api.v1.JobMetadataR\bmetadataB\b\n" +
+	"\x06result\"P\n" +
+	"\bJobError\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\tR\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\"\xfb\x01\n" +
+	"\vJobMetadata\x12)\n" +
+	"\x10progress_percent\x18\x01 \x01(\x05R\x0fprogressPercent\x12;\n" +
+	"\vcreate_time\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"createTime\x129\n" +
+	"\n" +
+	"start_time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tstartTime\x125\n" +
+	"\bend_time\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\aendTime\x12\x12\n" +
+	"\x04seed\x18\x05 \x01(\tR\x04seed\"H\n" +
+	"\x10SubmitJobRequest\x124\n" +
+	"\arequest\x18\x01 \x01(\v2\x1a.api.v1.ProcessDataRequestR\arequest\"#\n" +
+	"\rGetJobRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"e\n" +
+	"\x0fListJobsRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\x12\x16\n" +
+	"\x06filter\x18\x03 \x01(\tR\x06filter\"[\n" +
+	"\x10ListJobsResponse\x12\x1f\n" +
+	"\x04jobs\x18\x01 \x03(\v2\v.api.v1.JobR\x04jobs\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"&\n" +
+	"\x10CancelJobRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"Y\n" +
+	"\x0eWaitJobRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x123\n" +
+	"\atimeout\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\atimeout\"\x9d\x02\n" +
+	"\x12ListResultsRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\x12\x16\n" +
+	"\x06method\x18\x03 \x01(\tR\x06method\x12\x1d\n" +
+	"\asuccess\x18\x04 \x01(\bH\x00R\asuccess\x88\x01\x01\x129\n" +
+	"\n" +
+	"start_time\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tstartTime\x125\n" +
+	"\bend_time\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\aendTime\x12\x16\n" +
+	"\x06filter\x18\a \x01(\tR\x06filterB\n" +
+	"\n" +
+	"\b_success\"p\n" +
+	"\x13ListResultsResponse\x121\n" +
+	"\aresults\x18\x01 \x03(\v2\x17.api.v1.ProcessedResultR\aresults\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\x96\x03\n" +
+	"\x0fProcessedResult\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x16\n" +
+	"\x06method\x18\x02 \x01(\tR\x06method\x12\x14\n" +
+	"\x05input\x18\x03 \x01(\tR\x05input\x12>\n" +
+	"\aoptions\x18\x04 \x03(\v2$.api.v1.ProcessedResult.OptionsEntryR\aoptions\x12\x16\n" +
+	"\x06output\x18\x05 \x01(\tR\x06output\x12\x18\n" +
+	"\asuccess\x18\x06 \x01(\bR\asuccess\x12\x1d\n" +
+	"\n" +
+	"error_code\x18\a \x01(\tR\terrorCode\x12#\n" +
+	"\rerror_message\x18\b \x01(\tR\ferrorMessage\x12;\n" +
+	"\vcreate_time\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"createTime\x12\x16\n" +
+	"\x06status\x18\n" +
+	" \x01(\tR\x06status\x1a:\n" +
+	"\fOptionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01*\x9b\x01\n" +
+	"\bJobState\x12\x19\n" +
+	"\x15JOB_STATE_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11JOB_STATE_PENDING\x10\x01\x12\x15\n" +
+	"\x11JOB_STATE_RUNNING\x10\x02\x12\x17\n" +
+	"\x13JOB_STATE_SUCCEEDED\x10\x03\x12\x14\n" +
+	"\x10JOB_STATE_FAILED\x10\x04\x12\x17\n" +
+	"\x13JOB_STATE_CANCELLED\x10\x052\xd7\x06\n" +
 	"\vGrpcService\x12@\n" +
 	"\tGetHealth\x12\x18.api.v1.GetHealthRequest\x1a\x19.api.v1.GetHealthResponse\x12:\n" +
 	"\aGetInfo\x12\x16.api.v1.GetInfoRequest\x1a\x17.api.v1.GetInfoResponse\x12F\n" +
-	"\vProcessData\x12\x1a.api.v1.ProcessDataRequest\x1a\x1b.api.v1.ProcessDataResponse\x12E\n" +
+	"\vProcessData\x12\x1a.api.v1.ProcessDataRequest\x1a\x1b.api.v1.ProcessDataResponse\x12U\n" +
+	"\x10BatchProcessData\x12\x1f.api.v1.BatchProcessDataRequest\x1a .api.v1.BatchProcessDataResponse\x12E\n" +
 	"\n" +
-	"StreamData\x12\x19.api.v1.StreamDataRequest\x1a\x1a.api.v1.StreamDataResponse0\x01B\xa1\x01\n" +
+	"StreamData\x12\x19.api.v1.StreamDataRequest\x1a\x1a.api.v1.StreamDataResponse0\x01\x12E\n" +
+	"\n" +
+	"UploadData\x12\x19.api.v1.UploadDataRequest\x1a\x1a.api.v1.UploadDataResponse(\x01\x12P\n" +
+	"\rProcessStream\x12\x1c.api.v1.ProcessStreamRequest\x1a\x1d.api.v1.ProcessStreamResponse(\x010\x01\x122\n" +
+	"\tSubmitJob\x12\x18.api.v1.SubmitJobRequest\x1a\v.api.v1.Job\x12,\n" +
+	"\x06GetJob\x12\x15.api.v1.GetJobRequest\x1a\v.api.v1.Job\x12=\n" +
+	"\bListJobs\x12\x17.api.v1.ListJobsRequest\x1a\x18.api.v1.ListJobsResponse\x122\n" +
+	"\tCancelJob\x12\x18.api.v1.CancelJobRequest\x1a\v.api.v1.Job\x12.\n" +
+	"\aWaitJob\x12\x16.api.v1.WaitJobRequest\x1a\v.api.v1.Job\x12F\n" +
+	"\vListResults\x12\x1a.api.v1.ListResultsRequest\x1a\x1b.api.v1.ListResultsResponseB\xa1\x01\n" +
 	"\n" +
 	"com.api.v1B\x10GrpcServiceProtoP\x01ZHgithub.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api;apiv1\xa2\x02\x03AXX\xaa\x02\x06Api.V1\xca\x02\x06Api\\V1\xe2\x02\x12Api\\V1\\GPBMetadata\xea\x02\aApi::V1b\x06proto3"
 
@@ -524,42 +2273,113 @@ func file_api_grpc_service_proto_rawDescGZIP() []byte {
 	return file_api_grpc_service_proto_rawDescData
 }
 
-var file_api_grpc_service_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_api_grpc_service_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_api_grpc_service_proto_msgTypes = make([]protoimpl.MessageInfo, 35)
 var file_api_grpc_service_proto_goTypes = []any{
-	(*GetHealthRequest)(nil),      // 0: api.v1.GetHealthRequest
-	(*GetHealthResponse)(nil),     // 1: api.v1.GetHealthResponse
-	(*GetInfoRequest)(nil),        // 2: api.v1.GetInfoRequest
-	(*GetInfoResponse)(nil),       // 3: api.v1.GetInfoResponse
-	(*ProcessDataRequest)(nil),    // 4: api.v1.ProcessDataRequest
-	(*ProcessDataResponse)(nil),   // 5: api.v1.ProcessDataResponse
-	(*StreamDataRequest)(nil),     // 6: api.v1.StreamDataRequest
-	(*StreamDataResponse)(nil),    // 7: api.v1.StreamDataResponse
-	nil,                           // 8: api.v1.GetHealthResponse.DetailsEntry
-	nil,                           // 9: api.v1.GetInfoResponse.MetadataEntry
-	nil,                           // 10: api.v1.ProcessDataRequest.OptionsEntry
-	(*timestamppb.Timestamp)(nil), // 11: google.protobuf.Timestamp
+	(JobState)(0),                    // 0: api.v1.JobState
+	(*GetHealthRequest)(nil),         // 1: api.v1.GetHealthRequest
+	(*GetHealthResponse)(nil),        // 2: api.v1.GetHealthResponse
+	(*GetInfoRequest)(nil),           // 3: api.v1.GetInfoRequest
+	(*GetInfoResponse)(nil),          // 4: api.v1.GetInfoResponse
+	(*ProcessDataRequest)(nil),       // 5: api.v1.ProcessDataRequest
+	(*ProcessDataResponse)(nil),      // 6: api.v1.ProcessDataResponse
+	(*BatchProcessDataRequest)(nil),  // 7: api.v1.BatchProcessDataRequest
+	(*BatchProcessDataResponse)(nil), // 8: api.v1.BatchProcessDataResponse
+	(*BatchProcessDataResult)(nil),   // 9: api.v1.BatchProcessDataResult
+	(*BatchProcessDataStats)(nil),    // 10: api.v1.BatchProcessDataStats
+	(*StreamDataRequest)(nil),        // 11: api.v1.StreamDataRequest
+	(*StreamDataResponse)(nil),       // 12: api.v1.StreamDataResponse
+	(*UploadDataRequest)(nil),        // 13: api.v1.UploadDataRequest
+	(*UploadDataResponse)(nil),       // 14: api.v1.UploadDataResponse
+	(*UploadFailure)(nil),            // 15: api.v1.UploadFailure
+	(*ProcessStreamRequest)(nil),     // 16: api.v1.ProcessStreamRequest
+	(*ProcessStreamResponse)(nil),    // 17: api.v1.ProcessStreamResponse
+	(*Job)(nil),                      // 18: api.v1.Job
+	(*JobError)(nil),                 // 19: api.v1.JobError
+	(*JobMetadata)(nil),              // 20: api.v1.JobMetadata
+	(*SubmitJobRequest)(nil),         // 21: api.v1.SubmitJobRequest
+	(*GetJobRequest)(nil),            // 22: api.v1.GetJobRequest
+	(*ListJobsRequest)(nil),          // 23: api.v1.ListJobsRequest
+	(*ListJobsResponse)(nil),         // 24: api.v1.ListJobsResponse
+	(*CancelJobRequest)(nil),         // 25: api.v1.CancelJobRequest
+	(*WaitJobRequest)(nil),           // 26: api.v1.WaitJobRequest
+	(*ListResultsRequest)(nil),       // 27: api.v1.ListResultsRequest
+	(*ListResultsResponse)(nil),      // 28: api.v1.ListResultsResponse
+	(*ProcessedResult)(nil),          // 29: api.v1.ProcessedResult
+	nil,                              // 30: api.v1.GetHealthResponse.DetailsEntry
+	nil,                              // 31: api.v1.GetInfoResponse.MetadataEntry
+	nil,                              // 32: api.v1.ProcessDataRequest.OptionsEntry
+	nil,                              // 33: api.v1.UploadDataRequest.OptionsEntry
+	nil,                              // 34: api.v1.ProcessStreamRequest.OptionsEntry
+	nil,                              // 35: api.v1.ProcessedResult.OptionsEntry
+	(*timestamppb.Timestamp)(nil),    // 36: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),      // 37: google.protobuf.Duration
 }
 var file_api_grpc_service_proto_depIdxs = []int32{
-	11, // 0: api.v1.GetHealthResponse.timestamp:type_name -> google.protobuf.Timestamp
-	8,  // 1: api.v1.GetHealthResponse.details:type_name -> api.v1.GetHealthResponse.DetailsEntry
-	11, // 2: api.v1.GetInfoResponse.start_time:type_name -> google.protobuf.Timestamp
-	9,  // 3: api.v1.GetInfoResponse.metadata:type_name -> api.v1.GetInfoResponse.MetadataEntry
-	10, // 4: api.v1.ProcessDataRequest.options:type_name -> api.v1.ProcessDataRequest.OptionsEntry
-	11, // 5: api.v1.ProcessDataResponse.processed_at:type_name -> google.protobuf.Timestamp
-	11, // 6: api.v1.StreamDataResponse.timestamp:type_name -> google.protobuf.Timestamp
-	0,  // 7: api.v1.GrpcService.GetHealth:input_type -> api.v1.GetHealthRequest
-	2,  // 8: api.v1.GrpcService.GetInfo:input_type -> api.v1.GetInfoRequest
-	4,  // 9: api.v1.GrpcService.ProcessData:input_type -> api.v1.ProcessDataRequest
-	6,  // 10: api.v1.GrpcService.StreamData:input_type -> api.v1.StreamDataRequest
-	1,  // 11: api.v1.GrpcService.GetHealth:output_type -> api.v1.GetHealthResponse
-	3,  // 12: api.v1.GrpcService.GetInfo:output_type -> api.v1.GetInfoResponse
-	5,  // 13: api.v1.GrpcService.ProcessData:output_type -> api.v1.ProcessDataResponse
-	7,  // 14: api.v1.GrpcService.StreamData:output_type -> api.v1.StreamDataResponse
-	11, // [11:15] is the sub-list for method output_type
-	7,  // [7:11] is the sub-list for method input_type
-	7,  // [7:7] is the sub-list for extension type_name
-	7,  // [7:7] is the sub-list for extension extendee
-	0,  // [0:7] is the sub-list for field type_name
+	36, // 0: api.v1.GetHealthResponse.timestamp:type_name -> google.protobuf.Timestamp
+	30, // 1: api.v1.GetHealthResponse.details:type_name -> api.v1.GetHealthResponse.DetailsEntry
+	36, // 2: api.v1.GetInfoResponse.start_time:type_name -> google.protobuf.Timestamp
+	31, // 3: api.v1.GetInfoResponse.metadata:type_name -> api.v1.GetInfoResponse.MetadataEntry
+	32, // 4: api.v1.ProcessDataRequest.options:type_name -> api.v1.ProcessDataRequest.OptionsEntry
+	36, // 5: api.v1.ProcessDataResponse.processed_at:type_name -> google.protobuf.Timestamp
+	5,  // 6: api.v1.BatchProcessDataRequest.items:type_name -> api.v1.ProcessDataRequest
+	9,  // 7: api.v1.BatchProcessDataResponse.results:type_name -> api.v1.BatchProcessDataResult
+	10, // 8: api.v1.BatchProcessDataResponse.stats:type_name -> api.v1.BatchProcessDataStats
+	36, // 9: api.v1.BatchProcessDataResult.processed_at:type_name -> google.protobuf.Timestamp
+	37, // 10: api.v1.BatchProcessDataStats.duration:type_name -> google.protobuf.Duration
+	36, // 11: api.v1.StreamDataResponse.timestamp:type_name -> google.protobuf.Timestamp
+	33, // 12: api.v1.UploadDataRequest.options:type_name -> api.v1.UploadDataRequest.OptionsEntry
+	15, // 13: api.v1.UploadDataResponse.failures:type_name -> api.v1.UploadFailure
+	36, // 14: api.v1.UploadDataResponse.started_at:type_name -> google.protobuf.Timestamp
+	37, // 15: api.v1.UploadDataResponse.duration:type_name -> google.protobuf.Duration
+	34, // 16: api.v1.ProcessStreamRequest.options:type_name -> api.v1.ProcessStreamRequest.OptionsEntry
+	36, // 17: api.v1.ProcessStreamResponse.processed_at:type_name -> google.protobuf.Timestamp
+	0,  // 18: api.v1.Job.state:type_name -> api.v1.JobState
+	6,  // 19: api.v1.Job.response:type_name -> api.v1.ProcessDataResponse
+	19, // 20: api.v1.Job.error:type_name -> api.v1.JobError
+	20, // 21: api.v1.Job.metadata:type_name -> api.v1.JobMetadata
+	36, // 22: api.v1.JobMetadata.create_time:type_name -> google.protobuf.Timestamp
+	36, // 23: api.v1.JobMetadata.start_time:type_name -> google.protobuf.Timestamp
+	36, // 24: api.v1.JobMetadata.end_time:type_name -> google.protobuf.Timestamp
+	5,  // 25: api.v1.SubmitJobRequest.request:type_name -> api.v1.ProcessDataRequest
+	18, // 26: api.v1.ListJobsResponse.jobs:type_name -> api.v1.Job
+	37, // 27: api.v1.WaitJobRequest.timeout:type_name -> google.protobuf.Duration
+	36, // 28: api.v1.ListResultsRequest.start_time:type_name -> google.protobuf.Timestamp
+	36, // 29: api.v1.ListResultsRequest.end_time:type_name -> google.protobuf.Timestamp
+	29, // 30: api.v1.ListResultsResponse.results:type_name -> api.v1.ProcessedResult
+	35, // 31: api.v1.ProcessedResult.options:type_name -> api.v1.ProcessedResult.OptionsEntry
+	36, // 32: api.v1.ProcessedResult.create_time:type_name -> google.protobuf.Timestamp
+	1,  // 33: api.v1.GrpcService.GetHealth:input_type -> api.v1.GetHealthRequest
+	3,  // 34: api.v1.GrpcService.GetInfo:input_type -> api.v1.GetInfoRequest
+	5,  // 35: api.v1.GrpcService.ProcessData:input_type -> api.v1.ProcessDataRequest
+	7,  // 36: api.v1.GrpcService.BatchProcessData:input_type -> api.v1.BatchProcessDataRequest
+	11, // 37: api.v1.GrpcService.StreamData:input_type -> api.v1.StreamDataRequest
+	13, // 38: api.v1.GrpcService.UploadData:input_type -> api.v1.UploadDataRequest
+	16, // 39: api.v1.GrpcService.ProcessStream:input_type -> api.v1.ProcessStreamRequest
+	21, // 40: api.v1.GrpcService.SubmitJob:input_type -> api.v1.SubmitJobRequest
+	22, // 41: api.v1.GrpcService.GetJob:input_type -> api.v1.GetJobRequest
+	23, // 42: api.v1.GrpcService.ListJobs:input_type -> api.v1.ListJobsRequest
+	25, // 43: api.v1.GrpcService.CancelJob:input_type -> api.v1.CancelJobRequest
+	26, // 44: api.v1.GrpcService.WaitJob:input_type -> api.v1.WaitJobRequest
+	27, // 45: api.v1.GrpcService.ListResults:input_type -> api.v1.ListResultsRequest
+	2,  // 46: api.v1.GrpcService.GetHealth:output_type -> api.v1.GetHealthResponse
+	4,  // 47: api.v1.GrpcService.GetInfo:output_type -> api.v1.GetInfoResponse
+	6,  // 48: api.v1.GrpcService.ProcessData:output_type -> api.v1.ProcessDataResponse
+	8,  // 49: api.v1.GrpcService.BatchProcessData:output_type -> api.v1.BatchProcessDataResponse
+	12, // 50: api.v1.GrpcService.StreamData:output_type -> api.v1.StreamDataResponse
+	14, // 51: api.v1.GrpcService.UploadData:output_type -> api.v1.UploadDataResponse
+	17, // 52: api.v1.GrpcService.ProcessStream:output_type -> api.v1.ProcessStreamResponse
+	18, // 53: api.v1.GrpcService.SubmitJob:output_type -> api.v1.Job
+	18, // 54: api.v1.GrpcService.GetJob:output_type -> api.v1.Job
+	24, // 55: api.v1.GrpcService.ListJobs:output_type -> api.v1.ListJobsResponse
+	18, // 56: api.v1.GrpcService.CancelJob:output_type -> api.v1.Job
+	18, // 57: api.v1.GrpcService.WaitJob:output_type -> api.v1.Job
+	28, // 58: api.v1.GrpcService.ListResults:output_type -> api.v1.ListResultsResponse
+	46, // [46:59] is the sub-list for method output_type
+	33, // [33:46] is the sub-list for method input_type
+	33, // [33:33] is the sub-list for extension type_name
+	33, // [33:33] is the sub-list for extension extendee
+	0,  // [0:33] is the sub-list for field type_name
 }
 
 func init() { file_api_grpc_service_proto_init() }
@@ -567,18 +2387,24 @@ func file_api_grpc_service_proto_init() {
 	if File_api_grpc_service_proto != nil {
 		return
 	}
+	file_api_grpc_service_proto_msgTypes[17].OneofWrappers = []any{
+		(*Job_Response)(nil),
+		(*Job_Error)(nil),
+	}
+	file_api_grpc_service_proto_msgTypes[26].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_grpc_service_proto_rawDesc), len(file_api_grpc_service_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   11,
+			NumEnums:      1,
+			NumMessages:   35,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_api_grpc_service_proto_goTypes,
 		DependencyIndexes: file_api_grpc_service_proto_depIdxs,
+		EnumInfos:         file_api_grpc_service_proto_enumTypes,
 		MessageInfos:      file_api_grpc_service_proto_msgTypes,
 	}.Build()
 	File_api_grpc_service_proto = out.File
@@ -60,3 +60,26 @@ Create the name of the service account to use
 {{- default "default" .Values.serviceAccount.name }}
 {{- end }}
 {{- end }}
+
+{{/*
+Whether the pod mounts a PersistentVolumeClaim for SQLite storage
+*/}}
+{{- define "grpc-service.persistentStorage" -}}
+{{- if and (eq .Values.storage.driver "sqlite") .Values.storage.persistence.enabled -}}
+true
+{{- end -}}
+{{- end }}
+
+{{/*
+Refuse settings that would run more than one pod against SQLite storage
+*/}}
+{{- define "grpc-service.validateStorage" -}}
+{{- if eq .Values.storage.driver "sqlite" -}}
+{{- if gt (int .Values.replicaCount) 1 -}}
+{{- fail "storage.driver=sqlite supports a single replica: set replicaCount to 1" -}}
+{{- end -}}
+{{- if .Values.autoscaling.enabled -}}
+{{- fail "storage.driver=sqlite supports a single replica: disable autoscaling" -}}
+{{- end -}}
+{{- end -}}
+{{- end }}
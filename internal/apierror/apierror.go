@@ -0,0 +1,227 @@
+// Package apierror builds the errors the service returns, following the
+// google.rpc error model. Every error carries an ErrorInfo with a reason from
+// this package and a LocalizedMessage; errors about request fields add a
+// BadRequest with field violations, and errors worth retrying add RetryInfo.
+// From maps the errors of the domain packages to such errors.
+package apierror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/jobs"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/logging"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/pagination"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/processor"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/storage"
+)
+
+// Domain is the ErrorInfo domain of the service's errors
+const Domain = "example-backend"
+
+// Locale is the locale of LocalizedMessage details
+const Locale = "en-US"
+
+// ErrorInfo reasons. Clients may rely on them, so they never change once
+// published.
+const (
+	ReasonInvalidArgument  = "INVALID_ARGUMENT"
+	ReasonUnknownProcessor = "UNKNOWN_PROCESSOR"
+	ReasonInvalidOption    = "INVALID_OPTION"
+	ReasonInvalidInput     = "INVALID_INPUT"
+	ReasonInvalidFilter    = "INVALID_FILTER"
+	ReasonInvalidPageToken = "INVALID_PAGE_TOKEN"
+	ReasonLimitExceeded    = "LIMIT_EXCEEDED"
+	ReasonStreamTimeout    = "STREAM_TIMEOUT"
+	ReasonJobNotFound      = "JOB_NOT_FOUND"
+	ReasonQueueFull        = "QUEUE_FULL"
+	ReasonRateLimited      = "RATE_LIMITED"
+	ReasonShuttingDown     = "SHUTTING_DOWN"
+	ReasonCanceled         = "CANCELED"
+	ReasonDeadlineExceeded = "DEADLINE_EXCEEDED"
+	ReasonInternal         = "INTERNAL"
+
+	// Reasons of the auth package
+	ReasonUnauthenticated    = "UNAUTHENTICATED"
+	ReasonCredentialsInvalid = "CREDENTIALS_INVALID"
+	ReasonAPIKeyInvalid      = "API_KEY_INVALID"
+	ReasonAPIKeyExpired      = "API_KEY_EXPIRED"
+	ReasonScopeDenied        = "SCOPE_DENIED"
+	ReasonCertNotAuthorized  = "CERT_NOT_AUTHORIZED"
+)
+
+// RetryDelay is the RetryInfo delay of errors caused by a full queue or a
+// server shutting down
+const RetryDelay = time.Second
+
+// messages are the LocalizedMessage texts of the reasons. Reasons without one
+// use the error message.
+var messages = map[string]string{
+	ReasonInvalidArgument:  "The request is not valid. Check the highlighted fields and try again.",
+	ReasonUnknownProcessor: "The selected processor does not exist.",
+	ReasonInvalidOption:    "One of the processing options is not valid.",
+	ReasonInvalidInput:     "The processor could not handle this input.",
+	ReasonInvalidFilter:    "The filter expression could not be understood.",
+	ReasonInvalidPageToken: "The page token is no longer valid. Start again from the first page.",
+	ReasonLimitExceeded:    "The request is larger than the server allows.",
+	ReasonStreamTimeout:    "The stream ran for too long. Resume it from the last cursor.",
+	ReasonJobNotFound:      "The job does not exist or has expired.",
+	ReasonQueueFull:        "The server is busy. Try again shortly.",
+	ReasonRateLimited:      "You are sending requests too quickly. Try again shortly.",
+	ReasonShuttingDown:     "The server is restarting. Try again shortly.",
+	ReasonCanceled:         "The request was canceled.",
+	ReasonDeadlineExceeded: "The request took too long.",
+	ReasonInternal:         "Something went wrong on the server.",
+
+	ReasonUnauthenticated:    "Sign in to use this feature.",
+	ReasonCredentialsInvalid: "Your credentials are not valid. Sign in again.",
+	ReasonAPIKeyInvalid:      "The API key is not valid.",
+	ReasonAPIKeyExpired:      "The API key has expired. Ask for a new one.",
+	ReasonScopeDenied:        "You are not allowed to use this feature.",
+	ReasonCertNotAuthorized:  "Your client certificate is not allowed to call this service.",
+}
+
+// Option adds details to an error created by New
+type Option func(*details)
+
+type details struct {
+	metadata   map[string]string
+	violations []*errdetails.BadRequest_FieldViolation
+	retryDelay time.Duration
+	localized  string
+}
+
+// WithMetadata adds a key to the ErrorInfo metadata
+func WithMetadata(key, value string) Option {
+	return func(d *details) {
+		if d.metadata == nil {
+			d.metadata = make(map[string]string)
+		}
+		d.metadata[key] = value
+	}
+}
+
+// WithFieldViolation adds a BadRequest field violation. field is the path of
+// the field in the request, e.g. options.pattern.
+func WithFieldViolation(field, description string) Option {
+	return func(d *details) {
+		d.violations = append(d.violations, &errdetails.BadRequest_FieldViolation{Field: field, Description: description})
+	}
+}
+
+// WithRetryDelay adds RetryInfo telling clients to retry after delay
+func WithRetryDelay(delay time.Duration) Option {
+	return func(d *details) {
+		d.retryDelay = delay
+	}
+}
+
+// WithLocalizedMessage replaces the LocalizedMessage of the reason
+func WithLocalizedMessage(message string) Option {
+	return func(d *details) {
+		d.localized = message
+	}
+}
+
+// New returns a connect error with the message of err and details for
+// reason and opts
+func New(code connect.Code, reason string, err error, opts ...Option) *connect.Error {
+	d := &details{}
+	for _, opt := range opts {
+		opt(d)
+	}
+	connectErr := connect.NewError(code, err)
+	add := func(msg proto.Message) {
+		if detail, detailErr := connect.NewErrorDetail(msg); detailErr == nil {
+			connectErr.AddDetail(detail)
+		}
+	}
+	add(&errdetails.ErrorInfo{Reason: reason, Domain: Domain, Metadata: d.metadata})
+	if len(d.violations) > 0 {
+		add(&errdetails.BadRequest{FieldViolations: d.violations})
+	}
+	if d.retryDelay > 0 {
+		add(&errdetails.RetryInfo{RetryDelay: durationpb.New(d.retryDelay)})
+	}
+	localized := d.localized
+	if localized == "" {
+		localized = messages[reason]
+	}
+	if localized == "" {
+		localized = err.Error()
+	}
+	add(&errdetails.LocalizedMessage{Locale: Locale, Message: localized})
+	return connectErr
+}
+
+// InvalidField returns an INVALID_ARGUMENT error about one request field.
+// The message is prefixed with the field unless it already starts with it.
+func InvalidField(reason, field string, err error, opts ...Option) *connect.Error {
+	message := err.Error()
+	if !strings.HasPrefix(message, field+":") {
+		err = fmt.Errorf("%s: %w", field, err)
+	}
+	return New(connect.CodeInvalidArgument, reason, err, append([]Option{WithFieldViolation(field, message)}, opts...)...)
+}
+
+// From converts err to a connect error with details. Connect errors are
+// returned unchanged; errors of the jobs, pagination, processor and storage
+// packages and context errors get their code and reason. Anything else is
+// INTERNAL with a fixed message, and err is logged with the request's entry
+// from ctx instead of being sent to the client.
+func From(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	var connectErr *connect.Error
+	if errors.As(err, &connectErr) {
+		return connectErr
+	}
+
+	var optionErr *processor.OptionError
+	var inputErr *processor.InputError
+	var pageErr *pagination.Error
+	switch {
+	case errors.Is(err, context.Canceled):
+		return New(connect.CodeCanceled, ReasonCanceled, err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return New(connect.CodeDeadlineExceeded, ReasonDeadlineExceeded, err)
+	case errors.Is(err, processor.ErrUnknownProcessor):
+		return New(connect.CodeInvalidArgument, ReasonUnknownProcessor, err, WithFieldViolation("options", err.Error()))
+	case errors.As(err, &optionErr):
+		return New(connect.CodeInvalidArgument, ReasonInvalidOption, err,
+			WithFieldViolation("options."+optionErr.Option, optionErr.Err.Error()))
+	case errors.As(err, &inputErr):
+		return New(connect.CodeInvalidArgument, ReasonInvalidInput, err,
+			WithFieldViolation("data", err.Error()), WithMetadata("processor", inputErr.Processor))
+	case errors.As(err, &pageErr):
+		reason := ReasonInvalidArgument
+		switch {
+		case pageErr.Field == "filter":
+			reason = ReasonInvalidFilter
+		case pageErr.OverMax:
+			reason = ReasonLimitExceeded
+		}
+		return InvalidField(reason, pageErr.Field, errors.New(pageErr.Message))
+	case errors.Is(err, pagination.ErrInvalidToken):
+		return InvalidField(ReasonInvalidPageToken, "page_token", errors.New("invalid or issued for a request with different parameters"))
+	case errors.Is(err, jobs.ErrNotFound):
+		return New(connect.CodeNotFound, ReasonJobNotFound, err)
+	case errors.Is(err, jobs.ErrQueueFull):
+		return New(connect.CodeResourceExhausted, ReasonQueueFull, err, WithRetryDelay(RetryDelay))
+	case errors.Is(err, jobs.ErrClosed), errors.Is(err, storage.ErrClosed):
+		return New(connect.CodeUnavailable, ReasonShuttingDown, err, WithRetryDelay(RetryDelay))
+	default:
+		logging.FromContext(ctx, logrus.StandardLogger()).WithError(err).Error("Internal error")
+		return New(connect.CodeInternal, ReasonInternal, errors.New("internal error"))
+	}
+}
@@ -0,0 +1,126 @@
+package apierror
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/jobs"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/logging"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/pagination"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/processor"
+)
+
+func TestNew(t *testing.T) {
+	err := New(connect.CodeResourceExhausted, ReasonQueueFull, errors.New("queue is full"),
+		WithMetadata("queue_size", "100"), WithRetryDelay(2*time.Second))
+
+	assert.Equal(t, connect.CodeResourceExhausted, err.Code())
+	assert.Equal(t, "queue is full", err.Message())
+	d := DetailsOf(err)
+	require.NotNil(t, d.Info)
+	assert.Equal(t, ReasonQueueFull, d.Info.Reason)
+	assert.Equal(t, Domain, d.Info.Domain)
+	assert.Equal(t, map[string]string{"queue_size": "100"}, d.Info.Metadata)
+	require.NotNil(t, d.Retry)
+	assert.Equal(t, 2*time.Second, d.Retry.RetryDelay.AsDuration())
+	require.NotNil(t, d.Localized)
+	assert.Equal(t, Locale, d.Localized.Locale)
+	assert.Equal(t, "The server is busy. Try again shortly.", d.Localized.Message)
+	assert.Nil(t, d.BadRequest)
+}
+
+func TestInvalidField(t *testing.T) {
+	err := InvalidField(ReasonInvalidArgument, "timeout", errors.New("must not be negative"))
+
+	assert.Equal(t, connect.CodeInvalidArgument, err.Code())
+	assert.Equal(t, "timeout: must not be negative", err.Message())
+	d := DetailsOf(err)
+	require.NotNil(t, d.BadRequest)
+	require.Len(t, d.BadRequest.FieldViolations, 1)
+	assert.Equal(t, "timeout", d.BadRequest.FieldViolations[0].Field)
+	assert.Equal(t, "must not be negative", d.BadRequest.FieldViolations[0].Description)
+
+	err = InvalidField(ReasonInvalidArgument, "limit", errors.New("limit: too large"))
+	assert.Equal(t, "limit: too large", err.Message(), "the field is not repeated")
+}
+
+func TestFrom(t *testing.T) {
+	tests := map[string]struct {
+		err    error
+		code   connect.Code
+		reason string
+		field  string
+		retry  bool
+	}{
+		"unknown processor": {fmt.Errorf("%w %q", processor.ErrUnknownProcessor, "nope"), connect.CodeInvalidArgument, ReasonUnknownProcessor, "options", false},
+		"invalid option":    {&processor.OptionError{Option: "pattern", Err: errors.New("required")}, connect.CodeInvalidArgument, ReasonInvalidOption, "options.pattern", false},
+		"invalid input":     {&processor.InputError{Processor: "base64", Err: errors.New("not base64")}, connect.CodeInvalidArgument, ReasonInvalidInput, "data", false},
+		"page size":         {&pagination.Error{Field: "page_size", Message: "must not be negative"}, connect.CodeInvalidArgument, ReasonInvalidArgument, "page_size", false},
+		"filter":            {&pagination.Error{Field: "filter", Message: "unknown field"}, connect.CodeInvalidArgument, ReasonInvalidFilter, "filter", false},
+		"size over max":     {&pagination.Error{Field: "limit", Message: "must be at most 1000", OverMax: true}, connect.CodeInvalidArgument, ReasonLimitExceeded, "limit", false},
+		"page token":        {pagination.ErrInvalidToken, connect.CodeInvalidArgument, ReasonInvalidPageToken, "page_token", false},
+		"job not found":     {jobs.ErrNotFound, connect.CodeNotFound, ReasonJobNotFound, "", false},
+		"queue full":        {jobs.ErrQueueFull, connect.CodeResourceExhausted, ReasonQueueFull, "", true},
+		"closed":            {jobs.ErrClosed, connect.CodeUnavailable, ReasonShuttingDown, "", true},
+		"canceled":          {context.Canceled, connect.CodeCanceled, ReasonCanceled, "", false},
+		"deadline":          {fmt.Errorf("waiting: %w", context.DeadlineExceeded), connect.CodeDeadlineExceeded, ReasonDeadlineExceeded, "", false},
+		"other":             {errors.New("disk on fire"), connect.CodeInternal, ReasonInternal, "", false},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := From(context.Background(), tt.err)
+
+			assert.Equal(t, tt.code, connect.CodeOf(err))
+			assert.Equal(t, tt.reason, Reason(err))
+			d := DetailsOf(err)
+			if tt.field != "" {
+				require.NotNil(t, d.BadRequest)
+				assert.Equal(t, tt.field, d.BadRequest.FieldViolations[0].Field)
+			} else {
+				assert.Nil(t, d.BadRequest)
+			}
+			assert.Equal(t, tt.retry, d.Retry != nil)
+			assert.NotNil(t, d.Localized)
+		})
+	}
+
+	assert.NoError(t, From(context.Background(), nil))
+	existing := connect.NewError(connect.CodeAborted, errors.New("conflict"))
+	assert.Same(t, existing, From(context.Background(), fmt.Errorf("wrapped: %w", existing)), "connect errors pass through")
+}
+
+func TestFrom_Internal(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	ctx := logging.WithEntry(context.Background(), logger.WithField("request_id", "req-1"))
+
+	err := From(ctx, errors.New("open /var/lib/app.db: disk on fire"))
+
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	assert.Equal(t, "internal error", connectErr.Message(), "the cause is not sent to the client")
+	assert.Equal(t, ReasonInternal, Reason(err))
+	assert.Contains(t, buf.String(), "disk on fire")
+	assert.Contains(t, buf.String(), "request_id=req-1")
+}
+
+func TestDetails_String(t *testing.T) {
+	err := InvalidField(ReasonInvalidOption, "options.seed", errors.New("must be a 64-bit integer"),
+		WithRetryDelay(time.Second), WithLocalizedMessage("Pick a number."))
+
+	assert.Equal(t, `reason: INVALID_OPTION (example-backend)
+field options.seed: must be a 64-bit integer
+retry after: 1s
+message (en-US): Pick a number.`, DetailsOf(err).String())
+	assert.Empty(t, DetailsOf(errors.New("plain")).String())
+}
@@ -0,0 +1,74 @@
+package apierror
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bufbuild/connect-go"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// Details are the google.rpc details of a connect error. Missing details are
+// nil.
+type Details struct {
+	Info       *errdetails.ErrorInfo
+	BadRequest *errdetails.BadRequest
+	Retry      *errdetails.RetryInfo
+	Localized  *errdetails.LocalizedMessage
+}
+
+// DetailsOf decodes the details of err, which clients get from a failed
+// call. Details of other types are skipped.
+func DetailsOf(err error) Details {
+	var d Details
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) {
+		return d
+	}
+	for _, detail := range connectErr.Details() {
+		value, err := detail.Value()
+		if err != nil {
+			continue
+		}
+		switch value := value.(type) {
+		case *errdetails.ErrorInfo:
+			d.Info = value
+		case *errdetails.BadRequest:
+			d.BadRequest = value
+		case *errdetails.RetryInfo:
+			d.Retry = value
+		case *errdetails.LocalizedMessage:
+			d.Localized = value
+		}
+	}
+	return d
+}
+
+// Reason returns the ErrorInfo reason of err, or "" without one
+func Reason(err error) string {
+	return DetailsOf(err).Info.GetReason()
+}
+
+// String describes the details on one line per detail, for logs and command
+// line tools
+func (d Details) String() string {
+	var lines []string
+	if d.Info != nil {
+		line := fmt.Sprintf("reason: %s (%s)", d.Info.Reason, d.Info.Domain)
+		if len(d.Info.Metadata) > 0 {
+			line += fmt.Sprintf(" %v", d.Info.Metadata)
+		}
+		lines = append(lines, line)
+	}
+	for _, violation := range d.BadRequest.GetFieldViolations() {
+		lines = append(lines, fmt.Sprintf("field %s: %s", violation.Field, violation.Description))
+	}
+	if d.Retry != nil {
+		lines = append(lines, fmt.Sprintf("retry after: %s", d.Retry.RetryDelay.AsDuration()))
+	}
+	if d.Localized != nil {
+		lines = append(lines, fmt.Sprintf("message (%s): %s", d.Localized.Locale, d.Localized.Message))
+	}
+	return strings.Join(lines, "\n")
+}
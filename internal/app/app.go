@@ -0,0 +1,308 @@
+// Package app assembles the service's HTTP handler: the Connect service
+// behind its interceptor chain and CORS middleware, the gRPC health service,
+// reflection, probes, metrics and the demo page. The server binary and
+// in-process tests build the same stack from it.
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	grpcreflect "github.com/bufbuild/connect-grpcreflect-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api/apiv1connect"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/auth"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/config"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/health"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/jobs"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/logging"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/metrics"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/ratelimit"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/recovery"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/server"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/shutdown"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/storage"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/tracing"
+)
+
+// App is the assembled service
+type App struct {
+	// Handler serves every route of the main port
+	Handler http.Handler
+	Health  *health.Registry
+	Drainer *shutdown.Drainer
+	Service *server.GrpcService
+	// Store records processed calls and, with SQLite, jobs
+	Store storage.Store
+}
+
+// Close stops the service's job manager, giving running jobs until ctx ends,
+// then closes the store
+func (a *App) Close(ctx context.Context) error {
+	return errors.Join(a.Service.Close(ctx), a.Store.Close())
+}
+
+type options struct {
+	registerer     prometheus.Registerer
+	gatherer       prometheus.Gatherer
+	serviceOptions []server.Option
+}
+
+// Option configures New
+type Option func(*options)
+
+// WithRegistry registers metrics with reg and serves them from /metrics
+// instead of the default Prometheus registry
+func WithRegistry(reg *prometheus.Registry) Option {
+	return func(o *options) {
+		o.registerer = reg
+		o.gatherer = reg
+	}
+}
+
+// WithServiceOptions passes options to server.NewGrpcService after the
+// configuration and health registry
+func WithServiceOptions(opts ...server.Option) Option {
+	return func(o *options) {
+		o.serviceOptions = append(o.serviceOptions, opts...)
+	}
+}
+
+// New builds the handler stack described by cfg
+func New(cfg *config.Config, logger *logrus.Logger, opts ...Option) (_ *App, err error) {
+	o := &options{
+		registerer: prometheus.DefaultRegisterer,
+		gatherer:   prometheus.DefaultGatherer,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	// Health registry shared by the gRPC health service and HTTP probes
+	healthRegistry := health.NewRegistry()
+
+	// Drainer rejects new RPCs and tracks in-flight ones during shutdown
+	drainer := shutdown.NewDrainer()
+
+	// Record RPC metrics first so rejected calls are counted too
+	rpcMetrics := metrics.NewInterceptor(o.registerer)
+
+	// Trace every RPC, continuing the caller's trace when one is propagated
+	rpcTracing := tracing.NewInterceptor()
+
+	// Log one access line per RPC with a request-scoped logger in the context
+	accessLog := logging.NewInterceptor(logger)
+
+	// Turn handler panics into Internal errors; inside the access log so the
+	// failure is logged with its request ID
+	panicRecovery := recovery.NewInterceptor(
+		logger,
+		recovery.WithDebug(cfg.Debug),
+		recovery.WithRegisterer(o.registerer),
+	)
+
+	interceptors := []connect.Interceptor{rpcTracing, rpcMetrics, accessLog, panicRecovery, drainer.Interceptor()}
+
+	// Authenticate callers when any credential type is enabled
+	authInterceptor, err := newAuthInterceptor(cfg.Auth, logger)
+	if err != nil {
+		return nil, err
+	}
+	if authInterceptor != nil {
+		interceptors = append(interceptors, authInterceptor)
+	}
+
+	// Limit request rates per caller after authentication identified them
+	if cfg.RateLimit.Enabled {
+		limits, err := ratelimit.ParseLimits(
+			ratelimit.Limit{Rate: cfg.RateLimit.Rate, Burst: cfg.RateLimit.Burst},
+			cfg.RateLimit.Procedures,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate limits: %w", err)
+		}
+		interceptors = append(interceptors, ratelimit.NewInterceptor(
+			ratelimit.NewMemoryStore(),
+			limits,
+			ratelimit.WithTrustForwardedFor(cfg.RateLimit.TrustForwardedFor),
+			ratelimit.WithTrustedProxies(cfg.RateLimit.TrustedProxies),
+			ratelimit.WithRegisterer(o.registerer),
+		))
+		logger.WithField("default", limits.Default.String()).Info("Rate limiting enabled")
+	}
+
+	// Open storage for results and jobs
+	store, storeOptions, err := openStore(cfg.Storage, logger)
+	if err != nil {
+		return nil, err
+	}
+	// Close the store again when New fails after opening it
+	defer func() {
+		if err != nil {
+			store.Close()
+		}
+	}()
+
+	// Create Connect server
+	serviceOptions := append([]server.Option{
+		server.WithConfig(cfg),
+		server.WithHealth(healthRegistry),
+		server.WithOwner(auth.CallerID),
+		server.WithJobOptions(jobs.WithRegisterer(o.registerer)),
+	}, storeOptions...)
+	grpcService := server.NewGrpcService(logger, append(serviceOptions, o.serviceOptions...)...)
+	// Likewise stop the job manager, before the store its workers write to
+	defer func() {
+		if err != nil {
+			if closeErr := grpcService.Close(context.Background()); closeErr != nil {
+				logger.WithError(closeErr).Warn("Failed to stop the job manager")
+			}
+		}
+	}()
+	if cfg.Storage.Driver == "sqlite" {
+		recovered, err := grpcService.RecoverJobs(context.Background())
+		if err != nil {
+			return nil, fmt.Errorf("recovering jobs: %w", err)
+		}
+		if recovered > 0 {
+			logger.WithField("jobs", recovered).Warn("Failed jobs left unfinished by a previous run")
+		}
+	}
+	path, handler := apiv1connect.NewGrpcServiceHandler(
+		grpcService,
+		connect.WithInterceptors(interceptors...),
+	)
+
+	// UploadData gets its own handler so only its records are bounded by
+	// upload.max_bytes as they are read
+	uploadHandler := connect.NewClientStreamHandler(
+		apiv1connect.GrpcServiceUploadDataProcedure,
+		grpcService.UploadData,
+		connect.WithInterceptors(interceptors...),
+		grpcService.UploadReadMaxBytes(),
+	)
+
+	// Create HTTP server with gRPC and Connect handlers
+	mux := http.NewServeMux()
+	mux.Handle(path, corsMiddleware(handler))
+	mux.Handle(apiv1connect.GrpcServiceUploadDataProcedure, corsMiddleware(uploadHandler))
+
+	// Register the standard grpc.health.v1 service for grpc-health-probe
+	mux.Handle(health.NewHandler(healthRegistry, connect.WithInterceptors(rpcTracing, rpcMetrics)))
+
+	// Register gRPC server reflection (v1 and v1alpha) so grpcurl and
+	// grpcui can discover the service over the same h2c listener
+	if cfg.Server.Reflection {
+		reflector := grpcreflect.NewStaticReflector(apiv1connect.GrpcServiceName, health.ServiceName)
+		mux.Handle(grpcreflect.NewHandlerV1(reflector))
+		mux.Handle(grpcreflect.NewHandlerV1Alpha(reflector))
+	}
+
+	// Add health check endpoints
+	mux.Handle("/health", health.LivenessHandler())
+	mux.Handle("/ready", health.ReadinessHandler(healthRegistry))
+
+	// Add metrics endpoint
+	mux.Handle("/metrics", promhttp.HandlerFor(o.gatherer, promhttp.HandlerOpts{}))
+
+	// Serve the demo HTML page at root
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		// Only serve the demo page for GET requests to root
+		if r.Method == "GET" && r.URL.Path == "/" {
+			w.Header().Set("Content-Type", "text/html")
+			http.ServeFile(w, r, "web/demo.html")
+		} else {
+			http.NotFound(w, r)
+		}
+	})
+
+	return &App{
+		Handler: mux,
+		Health:  healthRegistry,
+		Drainer: drainer,
+		Service: grpcService,
+		Store:   store,
+	}, nil
+}
+
+// openStore opens the store selected by cfg and returns the service options
+// that use it
+func openStore(cfg config.StorageConfig, logger *logrus.Logger) (storage.Store, []server.Option, error) {
+	if cfg.Driver != "sqlite" {
+		store := storage.NewMemoryStore(cfg.MaxResults)
+		return store, []server.Option{server.WithResultStore(store)}, nil
+	}
+	db, err := storage.OpenSQLite(context.Background(), cfg.Path, storage.WithMaxResults(cfg.MaxResults))
+	if err != nil {
+		return nil, nil, err
+	}
+	logger.WithFields(logrus.Fields{
+		"path":           cfg.Path,
+		"schema_version": db.SchemaVersion(),
+		"max_results":    cfg.MaxResults,
+	}).Info("Opened SQLite storage")
+	return db, []server.Option{server.WithResultStore(db), server.WithJobStore(db.Jobs())}, nil
+}
+
+// newAuthInterceptor returns nil when no credential type is enabled
+func newAuthInterceptor(cfg config.AuthConfig, logger *logrus.Logger) (*auth.Interceptor, error) {
+	var authenticators []auth.Authenticator
+	if cfg.JWT.Enabled {
+		var jwks *auth.JWKS
+		if cfg.JWT.JWKSURL != "" {
+			jwks = auth.NewJWKSFromURL(cfg.JWT.JWKSURL, &http.Client{Timeout: 10 * time.Second}, cfg.JWT.JWKSRefreshInterval)
+		} else {
+			jwks = auth.NewJWKSFromFile(cfg.JWT.JWKSFile, cfg.JWT.JWKSRefreshInterval)
+		}
+		if err := jwks.Refresh(context.Background()); err != nil {
+			logger.WithError(err).Warn("Failed to load JWKS, will retry on the first request")
+		}
+		authenticators = append(authenticators, auth.NewJWTAuthenticator(
+			jwks, cfg.JWT.Issuer, cfg.JWT.Audience, cfg.JWT.ClockSkew,
+		))
+	}
+	if cfg.APIKeys.Enabled {
+		var store *auth.APIKeyStore
+		var err error
+		if cfg.APIKeys.File != "" {
+			store, err = auth.LoadAPIKeyStore(cfg.APIKeys.File)
+		} else {
+			store, err = auth.ParseAPIKeyStore([]byte(cfg.APIKeys.Keys))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load API keys: %w", err)
+		}
+		logger.WithField("keys", store.Len()).Info("Loaded API keys")
+		authenticators = append(authenticators, auth.NewAPIKeyAuthenticator(store))
+	}
+	if len(authenticators) == 0 {
+		return nil, nil
+	}
+	return auth.NewInterceptor(auth.NewPolicy(cfg.PublicProcedures), authenticators...), nil
+}
+
+// corsMiddleware lets browsers, such as the demo page, call the service
+func corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Allow all origins for demo purposes
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Api-Key, X-Request-Id, Traceparent, Tracestate")
+		w.Header().Set("Access-Control-Expose-Headers", "Retry-After, X-Request-Id, X-Random-Seed")
+
+		// Handle preflight requests
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
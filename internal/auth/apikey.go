@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"gopkg.in/yaml.v3"
+
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+)
+
+// MethodAPIKey is the authentication method of API key principals
+const MethodAPIKey = "api_key"
+
+// APIKeyHeader carries an API key, as an alternative to "Authorization: ApiKey"
+const APIKeyHeader = "X-Api-Key"
+
+// ErrorInfo reasons attached to API key rejections, from the apierror
+// catalog
+const (
+	ReasonAPIKeyInvalid = apierror.ReasonAPIKeyInvalid
+	ReasonAPIKeyExpired = apierror.ReasonAPIKeyExpired
+	ReasonScopeDenied   = apierror.ReasonScopeDenied
+)
+
+// APIKey is a stored API key. Keys are presented as "<id>.<secret>"; only a
+// salted SHA-256 hash of the secret is stored.
+type APIKey struct {
+	ID string `yaml:"id" json:"id"`
+	// Principal is the caller name, defaulting to ID
+	Principal string `yaml:"principal,omitempty" json:"principal,omitempty"`
+	// Salt and Hash are base64 encoded; Hash is sha256(salt || secret)
+	Salt string `yaml:"salt" json:"salt"`
+	Hash string `yaml:"hash" json:"hash"`
+	// Scopes lists the procedures the key may call, see Policy.Allows
+	Scopes []string `yaml:"scopes" json:"scopes"`
+	// ExpiresAt is when the key stops working; zero means never
+	ExpiresAt time.Time `yaml:"expires_at,omitempty" json:"expires_at,omitempty"`
+}
+
+// apiKeyFile is the layout of a key store file or environment variable
+type apiKeyFile struct {
+	Keys []APIKey `yaml:"keys"`
+}
+
+// APIKeyStore holds API keys indexed by ID
+type APIKeyStore struct {
+	keys map[string]storedKey
+}
+
+type storedKey struct {
+	APIKey
+	salt []byte
+	hash []byte
+}
+
+// LoadAPIKeyStore reads a key store from a YAML or JSON file
+func LoadAPIKeyStore(path string) (*APIKeyStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading API keys: %w", err)
+	}
+	return ParseAPIKeyStore(data)
+}
+
+// ParseAPIKeyStore parses a YAML or JSON document of the form
+// {"keys": [{"id": ..., "salt": ..., "hash": ..., "scopes": [...]}]}
+func ParseAPIKeyStore(data []byte) (*APIKeyStore, error) {
+	var file apiKeyFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing API keys: %w", err)
+	}
+	return NewAPIKeyStore(file.Keys)
+}
+
+// NewAPIKeyStore creates a store from the given keys
+func NewAPIKeyStore(keys []APIKey) (*APIKeyStore, error) {
+	store := &APIKeyStore{keys: make(map[string]storedKey, len(keys))}
+	for i, key := range keys {
+		if key.ID == "" || strings.Contains(key.ID, ".") {
+			return nil, fmt.Errorf("API key %d: id must be non-empty and contain no '.'", i)
+		}
+		if _, ok := store.keys[key.ID]; ok {
+			return nil, fmt.Errorf("API key %q: duplicate id", key.ID)
+		}
+		salt, err := base64.StdEncoding.DecodeString(key.Salt)
+		if err != nil || len(salt) == 0 {
+			return nil, fmt.Errorf("API key %q: salt must be non-empty base64", key.ID)
+		}
+		hash, err := base64.StdEncoding.DecodeString(key.Hash)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("API key %q: hash must be a base64 SHA-256 digest", key.ID)
+		}
+		if key.Principal == "" {
+			key.Principal = key.ID
+		}
+		store.keys[key.ID] = storedKey{APIKey: key, salt: salt, hash: hash}
+	}
+	return store, nil
+}
+
+// Len returns the number of keys in the store
+func (s *APIKeyStore) Len() int {
+	return len(s.keys)
+}
+
+// lookup returns the stored key matching a presented "<id>.<secret>" key
+func (s *APIKeyStore) lookup(presented string) (storedKey, bool) {
+	id, secret, ok := strings.Cut(presented, ".")
+	if !ok {
+		return storedKey{}, false
+	}
+	key, ok := s.keys[id]
+	if !ok {
+		return storedKey{}, false
+	}
+	if subtle.ConstantTimeCompare(hashSecret(key.salt, secret), key.hash) != 1 {
+		return storedKey{}, false
+	}
+	return key, true
+}
+
+// NewAPIKey generates a random key with the given ID. It returns the key to
+// hand to the caller and the entry to add to the store.
+func NewAPIKey(id string, scopes []string, expiresAt time.Time) (string, APIKey, error) {
+	secret := make([]byte, 32)
+	salt := make([]byte, 16)
+	if _, err := rand.Read(secret); err != nil {
+		return "", APIKey{}, err
+	}
+	if _, err := rand.Read(salt); err != nil {
+		return "", APIKey{}, err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+	entry := APIKey{
+		ID:        id,
+		Salt:      base64.StdEncoding.EncodeToString(salt),
+		Hash:      base64.StdEncoding.EncodeToString(hashSecret(salt, encoded)),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	return id + "." + encoded, entry, nil
+}
+
+func hashSecret(salt []byte, secret string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(secret))
+	return h.Sum(nil)
+}
+
+// APIKeyAuthenticator validates keys sent in the x-api-key header or as
+// "Authorization: ApiKey <key>"
+type APIKeyAuthenticator struct {
+	store *APIKeyStore
+	now   func() time.Time
+}
+
+// NewAPIKeyAuthenticator creates an authenticator backed by store
+func NewAPIKeyAuthenticator(store *APIKeyStore) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{store: store, now: time.Now}
+}
+
+// Authenticate implements Authenticator
+func (a *APIKeyAuthenticator) Authenticate(_ context.Context, header http.Header) (*Principal, error) {
+	presented, ok := apiKey(header)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	key, ok := a.store.lookup(presented)
+	if !ok {
+		return nil, apierror.New(connect.CodeUnauthenticated, ReasonAPIKeyInvalid, errors.New("invalid API key"))
+	}
+	if !key.ExpiresAt.IsZero() && !a.now().Before(key.ExpiresAt) {
+		return nil, apierror.New(connect.CodeUnauthenticated, ReasonAPIKeyExpired, errors.New("API key expired"),
+			apierror.WithMetadata("key_id", key.ID),
+			apierror.WithMetadata("expired_at", key.ExpiresAt.UTC().Format(time.RFC3339)))
+	}
+
+	scopes := key.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return &Principal{Name: key.Principal, Method: MethodAPIKey, Scopes: scopes}, nil
+}
+
+// apiKey extracts the key from the x-api-key or "Authorization: ApiKey" header
+func apiKey(header http.Header) (string, bool) {
+	if key := strings.TrimSpace(header.Get(APIKeyHeader)); key != "" {
+		return key, true
+	}
+	scheme, key, ok := strings.Cut(header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "ApiKey") {
+		return "", false
+	}
+	key = strings.TrimSpace(key)
+	return key, key != ""
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"gopkg.in/yaml.v3"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api/apiv1connect"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/server"
+)
+
+func newTestKey(t *testing.T, id string, scopes []string, expiresAt time.Time) (string, APIKey) {
+	key, entry, err := NewAPIKey(id, scopes, expiresAt)
+	require.NoError(t, err)
+	return key, entry
+}
+
+func apiKeyHeader(key string) http.Header {
+	header := http.Header{}
+	header.Set(APIKeyHeader, key)
+	return header
+}
+
+// errorInfo returns the ErrorInfo detail of a connect error
+func errorInfo(t *testing.T, err error) *errdetails.ErrorInfo {
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	for _, detail := range connectErr.Details() {
+		value, err := detail.Value()
+		require.NoError(t, err)
+		if info, ok := value.(*errdetails.ErrorInfo); ok {
+			return info
+		}
+	}
+	t.Fatalf("no ErrorInfo in %v", err)
+	return nil
+}
+
+func TestAPIKeyStore_LoadFile(t *testing.T) {
+	key, entry := newTestKey(t, "batch", []string{"ProcessData"}, time.Time{})
+	data, err := yaml.Marshal(apiKeyFile{Keys: []APIKey{entry}})
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "keys.yaml")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	store, err := LoadAPIKeyStore(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Len())
+
+	principal, err := NewAPIKeyAuthenticator(store).Authenticate(context.Background(), apiKeyHeader(key))
+	require.NoError(t, err)
+	assert.Equal(t, &Principal{Name: "batch", Method: MethodAPIKey, Scopes: []string{"ProcessData"}}, principal)
+}
+
+func TestAPIKeyStore_InvalidEntries(t *testing.T) {
+	_, entry := newTestKey(t, "batch", nil, time.Time{})
+
+	for name, mutate := range map[string]func(*APIKey){
+		"empty id":    func(k *APIKey) { k.ID = "" },
+		"dotted id":   func(k *APIKey) { k.ID = "a.b" },
+		"bad salt":    func(k *APIKey) { k.Salt = "!!" },
+		"short hash":  func(k *APIKey) { k.Hash = "YWJj" },
+		"missing key": func(k *APIKey) { k.Hash = "" },
+	} {
+		bad := entry
+		mutate(&bad)
+		_, err := NewAPIKeyStore([]APIKey{bad})
+		assert.Error(t, err, name)
+	}
+
+	_, err := NewAPIKeyStore([]APIKey{entry, entry})
+	assert.ErrorContains(t, err, "duplicate id")
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	validKey, valid := newTestKey(t, "valid", nil, now.Add(time.Hour))
+	expiredKey, expired := newTestKey(t, "expired", nil, now)
+	store, err := NewAPIKeyStore([]APIKey{valid, expired})
+	require.NoError(t, err)
+	authenticator := NewAPIKeyAuthenticator(store)
+	authenticator.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err = authenticator.Authenticate(ctx, http.Header{})
+	assert.ErrorIs(t, err, ErrNoCredentials)
+
+	header := http.Header{}
+	header.Set("Authorization", "ApiKey "+validKey)
+	principal, err := authenticator.Authenticate(ctx, header)
+	require.NoError(t, err)
+	assert.Equal(t, "valid", principal.Name)
+	assert.NotNil(t, principal.Scopes, "API keys without scopes may call nothing but public procedures")
+
+	_, err = authenticator.Authenticate(ctx, apiKeyHeader(expiredKey))
+	assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+	assert.Equal(t, ReasonAPIKeyExpired, errorInfo(t, err).Reason)
+
+	for _, bad := range []string{"valid", "valid.wrong", "unknown.secret", validKey + "x"} {
+		_, err = authenticator.Authenticate(ctx, apiKeyHeader(bad))
+		assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err), bad)
+		assert.Equal(t, ReasonAPIKeyInvalid, errorInfo(t, err).Reason, bad)
+	}
+}
+
+func TestPolicy_Allows(t *testing.T) {
+	policy := NewPolicy([]string{apiv1connect.GrpcServiceGetHealthProcedure})
+	scoped := &Principal{Name: "batch", Scopes: []string{"ProcessData", apiv1connect.GrpcServiceGetInfoProcedure}}
+
+	assert.True(t, policy.Allows(scoped, apiv1connect.GrpcServiceProcessDataProcedure))
+	assert.True(t, policy.Allows(scoped, apiv1connect.GrpcServiceGetInfoProcedure))
+	assert.True(t, policy.Allows(scoped, apiv1connect.GrpcServiceGetHealthProcedure), "public procedures need no scope")
+	assert.False(t, policy.Allows(scoped, apiv1connect.GrpcServiceStreamDataProcedure))
+	assert.True(t, policy.Allows(&Principal{Scopes: []string{"*"}}, apiv1connect.GrpcServiceStreamDataProcedure))
+	assert.True(t, policy.Allows(&Principal{}, apiv1connect.GrpcServiceStreamDataProcedure), "nil scopes are unrestricted")
+	assert.False(t, policy.Allows(&Principal{Scopes: []string{}}, apiv1connect.GrpcServiceStreamDataProcedure))
+}
+
+func TestInterceptor_APIKeyScopes(t *testing.T) {
+	key, entry := newTestKey(t, "batch", []string{"ProcessData"}, time.Time{})
+	store, err := NewAPIKeyStore([]APIKey{entry})
+	require.NoError(t, err)
+	interceptor := NewInterceptor(
+		NewPolicy([]string{apiv1connect.GrpcServiceGetHealthProcedure}),
+		NewAPIKeyAuthenticator(store),
+	)
+	mux := http.NewServeMux()
+	mux.Handle(apiv1connect.NewGrpcServiceHandler(
+		server.NewGrpcService(logrus.New()),
+		connect.WithInterceptors(interceptor),
+	))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	client := apiv1connect.NewGrpcServiceClient(srv.Client(), srv.URL)
+	ctx := context.Background()
+
+	req := connect.NewRequest(&apiv1.ProcessDataRequest{Data: "x"})
+	req.Header().Set(APIKeyHeader, key)
+	_, err = client.ProcessData(ctx, req)
+	require.NoError(t, err)
+
+	streamReq := connect.NewRequest(&apiv1.StreamDataRequest{Query: "x", Limit: 1})
+	streamReq.Header().Set(APIKeyHeader, key)
+	stream, err := client.StreamData(ctx, streamReq)
+	require.NoError(t, err)
+	assert.False(t, stream.Receive())
+	assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(stream.Err()))
+	info := errorInfo(t, stream.Err())
+	assert.Equal(t, ReasonScopeDenied, info.Reason)
+	assert.Equal(t, apiv1connect.GrpcServiceStreamDataProcedure, info.Metadata["procedure"])
+	require.NoError(t, stream.Close())
+
+	badReq := connect.NewRequest(&apiv1.ProcessDataRequest{Data: "x"})
+	badReq.Header().Set(APIKeyHeader, "batch.wrong")
+	_, err = client.ProcessData(ctx, badReq)
+	assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+}
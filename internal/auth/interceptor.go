@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bufbuild/connect-go"
+
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+)
+
+// ErrNoCredentials is returned by an Authenticator when the request carries
+// no credentials it understands, so the next one can be tried
+var ErrNoCredentials = errors.New("no credentials")
+
+// errUnauthenticated is returned for non-public procedures called anonymously
+var errUnauthenticated = errors.New("authentication required")
+
+// Authenticator validates the credentials carried in request headers
+type Authenticator interface {
+	// Authenticate returns the caller's principal, ErrNoCredentials when
+	// the headers hold none of its credentials, or another error when they
+	// are present but invalid
+	Authenticate(ctx context.Context, header http.Header) (*Principal, error)
+}
+
+// Policy decides which procedures may be called anonymously
+type Policy struct {
+	public map[string]bool
+}
+
+// NewPolicy creates a policy marking the given procedures (e.g.
+// "/api.v1.GrpcService/GetHealth") as public
+func NewPolicy(publicProcedures []string) *Policy {
+	public := make(map[string]bool, len(publicProcedures))
+	for _, procedure := range publicProcedures {
+		public[procedure] = true
+	}
+	return &Policy{public: public}
+}
+
+// IsPublic reports whether a procedure may be called without credentials
+func (p *Policy) IsPublic(procedure string) bool {
+	return p.public[procedure]
+}
+
+// Allows reports whether principal may call procedure. Public procedures
+// and principals without scopes are always allowed; otherwise a scope must
+// be "*", the full procedure name or its method name (e.g. "ProcessData").
+func (p *Policy) Allows(principal *Principal, procedure string) bool {
+	if principal.Scopes == nil || p.IsPublic(procedure) {
+		return true
+	}
+	method := procedure[strings.LastIndex(procedure, "/")+1:]
+	for _, scope := range principal.Scopes {
+		if scope == "*" || scope == procedure || scope == method {
+			return true
+		}
+	}
+	return false
+}
+
+// Interceptor authenticates every handler call. A principal already in the
+// context, e.g. from a client certificate, is kept. Otherwise the
+// authenticators are tried in order; invalid credentials are always
+// rejected and missing ones only for non-public procedures. Principals whose
+// scopes do not cover the procedure are rejected with PermissionDenied.
+type Interceptor struct {
+	policy         *Policy
+	authenticators []Authenticator
+}
+
+// NewInterceptor creates an authentication interceptor
+func NewInterceptor(policy *Policy, authenticators ...Authenticator) *Interceptor {
+	return &Interceptor{policy: policy, authenticators: authenticators}
+}
+
+// WrapUnary implements connect.Interceptor
+func (i *Interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		ctx, err := i.authenticate(ctx, req.Spec().Procedure, req.Header())
+		if err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor
+func (i *Interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor
+func (i *Interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx, err := i.authenticate(ctx, conn.Spec().Procedure, conn.RequestHeader())
+		if err != nil {
+			return err
+		}
+		return next(ctx, conn)
+	}
+}
+
+func (i *Interceptor) authenticate(ctx context.Context, procedure string, header http.Header) (context.Context, error) {
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		return ctx, i.authorize(principal, procedure)
+	}
+
+	for _, authenticator := range i.authenticators {
+		principal, err := authenticator.Authenticate(ctx, header)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		if err != nil {
+			return ctx, credentialsError(err)
+		}
+		if err := i.authorize(principal, procedure); err != nil {
+			return ctx, err
+		}
+		return WithPrincipal(ctx, principal), nil
+	}
+
+	if i.policy.IsPublic(procedure) {
+		return ctx, nil
+	}
+	return ctx, apierror.New(connect.CodeUnauthenticated, apierror.ReasonUnauthenticated, errUnauthenticated)
+}
+
+func (i *Interceptor) authorize(principal *Principal, procedure string) error {
+	if i.policy.Allows(principal, procedure) {
+		return nil
+	}
+	return apierror.New(connect.CodePermissionDenied, ReasonScopeDenied, errors.New("procedure not allowed for this caller"),
+		apierror.WithMetadata("principal", principal.Name),
+		apierror.WithMetadata("procedure", procedure))
+}
+
+// credentialsError keeps the connect errors of authenticators as they are and
+// turns others, e.g. an invalid JWT, into UNAUTHENTICATED errors
+func credentialsError(err error) error {
+	var connectErr *connect.Error
+	if errors.As(err, &connectErr) {
+		return connectErr
+	}
+	return apierror.New(connect.CodeUnauthenticated, apierror.ReasonCredentialsInvalid, err)
+}
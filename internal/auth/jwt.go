@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"golang.org/x/sync/singleflight"
+)
+
+// MethodJWT is the authentication method of bearer token principals
+const MethodJWT = "jwt"
+
+// minKeyRefetch limits how often an unknown key ID triggers a JWKS refetch
+const minKeyRefetch = 10 * time.Second
+
+// signatureAlgorithms are the asymmetric algorithms accepted in tokens
+var signatureAlgorithms = []jose.SignatureAlgorithm{
+	jose.RS256, jose.RS384, jose.RS512,
+	jose.PS256, jose.PS384, jose.PS512,
+	jose.ES256, jose.ES384, jose.ES512,
+	jose.EdDSA,
+}
+
+// JWKS is a cached JSON Web Key Set loaded from a URL or a file. It is
+// refreshed every refresh interval and when a token names an unknown key.
+type JWKS struct {
+	fetch   func(ctx context.Context) ([]byte, error)
+	refresh time.Duration
+	// loads shares one fetch among the callers that need a refresh at once
+	loads singleflight.Group
+
+	// mu guards keys and checkedAt; it is never held during a fetch
+	mu   sync.Mutex
+	keys jose.JSONWebKeySet
+	// checkedAt is the time of the last fetch attempt, successful or not,
+	// so an unavailable JWKS endpoint is not hit on every request
+	checkedAt time.Time
+}
+
+// NewJWKSFromURL creates a key set fetched over HTTP
+func NewJWKSFromURL(url string, client *http.Client, refresh time.Duration) *JWKS {
+	return &JWKS{
+		refresh: refresh,
+		fetch: func(ctx context.Context) ([]byte, error) {
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				return nil, err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return nil, fmt.Errorf("fetching %s: %s", url, resp.Status)
+			}
+			return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		},
+	}
+}
+
+// NewJWKSFromFile creates a key set read from a local file
+func NewJWKSFromFile(path string, refresh time.Duration) *JWKS {
+	return &JWKS{
+		refresh: refresh,
+		fetch: func(context.Context) ([]byte, error) {
+			return os.ReadFile(path)
+		},
+	}
+}
+
+// Refresh reloads the key set, keeping the previous keys on failure.
+// Concurrent calls share one fetch.
+func (k *JWKS) Refresh(ctx context.Context) error {
+	_, err, _ := k.loads.Do("", func() (any, error) {
+		return nil, k.load(ctx)
+	})
+	return err
+}
+
+// Key returns the key with the given ID
+func (k *JWKS) Key(ctx context.Context, kid string) (*jose.JSONWebKey, error) {
+	k.mu.Lock()
+	age := time.Since(k.checkedAt)
+	k.mu.Unlock()
+
+	refreshed := false
+	if age > k.refresh {
+		refreshed = true
+		if err := k.Refresh(ctx); err != nil && k.empty() {
+			return nil, err
+		}
+	}
+
+	keys := k.lookup(kid)
+	if len(keys) == 0 && !refreshed && age > minKeyRefetch {
+		if err := k.Refresh(ctx); err != nil {
+			return nil, err
+		}
+		keys = k.lookup(kid)
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return &keys[0], nil
+}
+
+// lookup returns the cached keys with the given ID
+func (k *JWKS) lookup(kid string) []jose.JSONWebKey {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.keys.Key(kid)
+}
+
+// empty reports whether no key set has been loaded yet
+func (k *JWKS) empty() bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return len(k.keys.Keys) == 0
+}
+
+// load fetches and parses the key set without holding mu, then swaps it in
+func (k *JWKS) load(ctx context.Context) error {
+	k.mu.Lock()
+	k.checkedAt = time.Now()
+	k.mu.Unlock()
+
+	data, err := k.fetch(ctx)
+	if err != nil {
+		return fmt.Errorf("loading JWKS: %w", err)
+	}
+	var keys jose.JSONWebKeySet
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("parsing JWKS: %w", err)
+	}
+	k.mu.Lock()
+	k.keys = keys
+	k.mu.Unlock()
+	return nil
+}
+
+// JWTAuthenticator validates "Authorization: Bearer" tokens
+type JWTAuthenticator struct {
+	keys      *JWKS
+	issuer    string
+	audience  []string
+	clockSkew time.Duration
+	now       func() time.Time
+}
+
+// NewJWTAuthenticator creates an authenticator accepting tokens signed by a
+// key in keys, issued by issuer for any of audience
+func NewJWTAuthenticator(keys *JWKS, issuer string, audience []string, clockSkew time.Duration) *JWTAuthenticator {
+	return &JWTAuthenticator{
+		keys:      keys,
+		issuer:    issuer,
+		audience:  audience,
+		clockSkew: clockSkew,
+		now:       time.Now,
+	}
+}
+
+// Authenticate implements Authenticator
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, header http.Header) (*Principal, error) {
+	raw, ok := bearerToken(header)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	token, err := jwt.ParseSigned(raw, signatureAlgorithms)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	if len(token.Headers) != 1 {
+		return nil, errors.New("invalid token: expected exactly one signature")
+	}
+	key, err := a.keys.Key(ctx, token.Headers[0].KeyID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	var claims jwt.Claims
+	if err := token.Claims(key, &claims); err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	if claims.Expiry == nil {
+		return nil, errors.New("invalid token: missing exp claim")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("invalid token: missing sub claim")
+	}
+	expected := jwt.Expected{
+		Issuer:      a.issuer,
+		AnyAudience: a.audience,
+		Time:        a.now(),
+	}
+	if err := claims.ValidateWithLeeway(expected, a.clockSkew); err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+
+	return &Principal{Name: claims.Subject, Method: MethodJWT}, nil
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer" header
+func bearerToken(header http.Header) (string, bool) {
+	scheme, token, ok := strings.Cut(header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api/apiv1connect"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/server"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com"
+	testAudience = "example-backend"
+)
+
+// testIssuerKeys signs tokens with a locally generated key and publishes the
+// matching JWKS to a file
+type testIssuerKeys struct {
+	signer   jose.Signer
+	jwksFile string
+}
+
+func newTestIssuer(t *testing.T) *testIssuerKeys {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: jose.JSONWebKey{Key: key, KeyID: "test-key"}},
+		(&jose.SignerOptions{}).WithType("JWT"),
+	)
+	require.NoError(t, err)
+
+	jwks := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "test-key", Algorithm: string(jose.ES256), Use: "sig"}}}
+	data, err := json.Marshal(jwks)
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+
+	return &testIssuerKeys{signer: signer, jwksFile: path}
+}
+
+func (k *testIssuerKeys) token(t *testing.T, claims jwt.Claims) string {
+	raw, err := jwt.Signed(k.signer).Claims(claims).Serialize()
+	require.NoError(t, err)
+	return raw
+}
+
+func validClaims() jwt.Claims {
+	now := time.Now()
+	return jwt.Claims{
+		Subject:  "user-123",
+		Issuer:   testIssuer,
+		Audience: jwt.Audience{testAudience},
+		IssuedAt: jwt.NewNumericDate(now),
+		Expiry:   jwt.NewNumericDate(now.Add(time.Hour)),
+	}
+}
+
+func bearer(token string) http.Header {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+token)
+	return header
+}
+
+func newTestAuthenticator(keys *testIssuerKeys) *JWTAuthenticator {
+	return NewJWTAuthenticator(NewJWKSFromFile(keys.jwksFile, time.Minute), testIssuer, []string{testAudience}, 30*time.Second)
+}
+
+func TestJWTAuthenticator_Valid(t *testing.T) {
+	keys := newTestIssuer(t)
+	authenticator := newTestAuthenticator(keys)
+
+	principal, err := authenticator.Authenticate(context.Background(), bearer(keys.token(t, validClaims())))
+
+	require.NoError(t, err)
+	assert.Equal(t, &Principal{Name: "user-123", Method: MethodJWT}, principal)
+}
+
+func TestJWTAuthenticator_NoCredentials(t *testing.T) {
+	authenticator := newTestAuthenticator(newTestIssuer(t))
+
+	_, err := authenticator.Authenticate(context.Background(), http.Header{})
+	assert.ErrorIs(t, err, ErrNoCredentials)
+
+	header := http.Header{}
+	header.Set("Authorization", "Basic dXNlcjpwYXNz")
+	_, err = authenticator.Authenticate(context.Background(), header)
+	assert.ErrorIs(t, err, ErrNoCredentials)
+}
+
+func TestJWTAuthenticator_Rejects(t *testing.T) {
+	keys := newTestIssuer(t)
+	otherKeys := newTestIssuer(t)
+	authenticator := newTestAuthenticator(keys)
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"malformed", "not-a-token"},
+		{"wrong issuer", keys.token(t, func() jwt.Claims { c := validClaims(); c.Issuer = "https://evil.example.com"; return c }())},
+		{"wrong audience", keys.token(t, func() jwt.Claims { c := validClaims(); c.Audience = jwt.Audience{"other"}; return c }())},
+		{"expired", keys.token(t, func() jwt.Claims {
+			c := validClaims()
+			c.Expiry = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+			return c
+		}())},
+		{"no expiry", keys.token(t, func() jwt.Claims { c := validClaims(); c.Expiry = nil; return c }())},
+		{"no subject", keys.token(t, func() jwt.Claims { c := validClaims(); c.Subject = ""; return c }())},
+		{"wrong key", otherKeys.token(t, validClaims())},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := authenticator.Authenticate(context.Background(), bearer(tt.token))
+			require.Error(t, err)
+			assert.NotErrorIs(t, err, ErrNoCredentials)
+		})
+	}
+}
+
+func TestJWTAuthenticator_ClockSkew(t *testing.T) {
+	keys := newTestIssuer(t)
+	authenticator := newTestAuthenticator(keys)
+	claims := validClaims()
+	claims.Expiry = jwt.NewNumericDate(time.Now().Add(-10 * time.Second))
+
+	_, err := authenticator.Authenticate(context.Background(), bearer(keys.token(t, claims)))
+
+	assert.NoError(t, err, "expiry within the allowed skew is accepted")
+}
+
+func TestJWKS_SlowFetch(t *testing.T) {
+	data, err := os.ReadFile(newTestIssuer(t).jwksFile)
+	require.NoError(t, err)
+	release := make(chan struct{})
+	defer close(release)
+	var fetches atomic.Int32
+	jwks := &JWKS{refresh: time.Hour, fetch: func(context.Context) ([]byte, error) {
+		if fetches.Add(1) > 1 {
+			<-release
+		}
+		return data, nil
+	}}
+	require.NoError(t, jwks.Refresh(context.Background()))
+
+	// Unknown key IDs wait for a refetch that never finishes
+	jwks.checkedAt = time.Now().Add(-time.Minute)
+	for i := 0; i < 3; i++ {
+		go func() { _, _ = jwks.Key(context.Background(), "rotated") }()
+	}
+	assert.Eventually(t, func() bool { return fetches.Load() == 2 }, time.Second, time.Millisecond)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := jwks.Key(context.Background(), "test-key")
+		assert.NoError(t, err)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("known keys wait for the fetch")
+	}
+	assert.Equal(t, int32(2), fetches.Load(), "concurrent refetches share one fetch")
+}
+
+func TestInterceptor_JWT(t *testing.T) {
+	keys := newTestIssuer(t)
+	interceptor := NewInterceptor(
+		NewPolicy([]string{apiv1connect.GrpcServiceGetHealthProcedure}),
+		newTestAuthenticator(keys),
+	)
+	mux := http.NewServeMux()
+	mux.Handle(apiv1connect.NewGrpcServiceHandler(
+		server.NewGrpcService(logrus.New()),
+		connect.WithInterceptors(interceptor),
+	))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	client := apiv1connect.NewGrpcServiceClient(srv.Client(), srv.URL)
+	ctx := context.Background()
+
+	// Public procedure works anonymously
+	_, err := client.GetHealth(ctx, connect.NewRequest(&apiv1.GetHealthRequest{}))
+	require.NoError(t, err)
+
+	// Protected procedure needs a token
+	_, err = client.GetInfo(ctx, connect.NewRequest(&apiv1.GetInfoRequest{}))
+	assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+	assert.Equal(t, apierror.ReasonUnauthenticated, apierror.Reason(err))
+
+	req := connect.NewRequest(&apiv1.GetInfoRequest{})
+	req.Header().Set("Authorization", "Bearer "+keys.token(t, validClaims()))
+	_, err = client.GetInfo(ctx, req)
+	require.NoError(t, err)
+
+	// Invalid tokens are rejected even on public procedures
+	healthReq := connect.NewRequest(&apiv1.GetHealthRequest{})
+	healthReq.Header().Set("Authorization", "Bearer garbage")
+	_, err = client.GetHealth(ctx, healthReq)
+	assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+	assert.Equal(t, apierror.ReasonCredentialsInvalid, apierror.Reason(err))
+	assert.NotNil(t, apierror.DetailsOf(err).Localized)
+}
+
+func TestInterceptor_KeepsExistingPrincipal(t *testing.T) {
+	interceptor := NewInterceptor(NewPolicy(nil))
+	ctx := WithPrincipal(context.Background(), &Principal{Name: "batch", Method: MethodMTLS})
+
+	got, err := interceptor.authenticate(ctx, apiv1connect.GrpcServiceGetInfoProcedure, http.Header{})
+
+	require.NoError(t, err)
+	principal, ok := PrincipalFromContext(got)
+	require.True(t, ok)
+	assert.Equal(t, "batch", principal.Name)
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/bufbuild/connect-go"
+
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+)
+
+// errCertNotAuthorized rejects client certificates matching no rule
+var errCertNotAuthorized = errors.New("client certificate is not authorized")
+
+// Certificate fields a CertRule can match
+const (
+	FieldCN    = "cn"
+	FieldDNS   = "dns"
+	FieldURI   = "uri"
+	FieldEmail = "email"
+)
+
+// CertRule maps client certificates whose field matches Pattern to a
+// principal. Pattern uses path.Match syntax; a Principal of "*" uses the
+// matched value itself.
+type CertRule struct {
+	Field     string
+	Pattern   string
+	Principal string
+}
+
+// ParseCertRule parses a rule written as field:pattern=principal, e.g.
+// "uri:spiffe://cluster.local/ns/batch/sa/*=batch" or "cn:*=*"
+func ParseCertRule(rule string) (CertRule, error) {
+	field, rest, ok := strings.Cut(rule, ":")
+	if !ok {
+		return CertRule{}, fmt.Errorf("rule %q: expected field:pattern=principal", rule)
+	}
+	i := strings.LastIndex(rest, "=")
+	if i <= 0 || i == len(rest)-1 {
+		return CertRule{}, fmt.Errorf("rule %q: expected field:pattern=principal", rule)
+	}
+
+	r := CertRule{Field: strings.ToLower(field), Pattern: rest[:i], Principal: rest[i+1:]}
+	switch r.Field {
+	case FieldCN, FieldDNS, FieldURI, FieldEmail:
+	default:
+		return CertRule{}, fmt.Errorf("rule %q: unknown field %q (want cn, dns, uri or email)", rule, field)
+	}
+	if _, err := path.Match(r.Pattern, ""); err != nil {
+		return CertRule{}, fmt.Errorf("rule %q: bad pattern: %w", rule, err)
+	}
+	return r, nil
+}
+
+// CertPolicy maps verified client certificates to principals. The first
+// matching rule wins. With no rules the certificate's common name is used.
+type CertPolicy struct {
+	Rules []CertRule
+}
+
+// NewCertPolicy parses rules written for ParseCertRule
+func NewCertPolicy(rules []string) (*CertPolicy, error) {
+	policy := &CertPolicy{}
+	for _, rule := range rules {
+		parsed, err := ParseCertRule(rule)
+		if err != nil {
+			return nil, err
+		}
+		policy.Rules = append(policy.Rules, parsed)
+	}
+	return policy, nil
+}
+
+// Principal returns the principal for a client certificate, or false when
+// no rule matches
+func (p *CertPolicy) Principal(cert *x509.Certificate) (*Principal, bool) {
+	if len(p.Rules) == 0 {
+		if cert.Subject.CommonName == "" {
+			return nil, false
+		}
+		return &Principal{Name: cert.Subject.CommonName, Method: MethodMTLS}, true
+	}
+
+	for _, rule := range p.Rules {
+		for _, value := range certValues(cert, rule.Field) {
+			if matched, _ := path.Match(rule.Pattern, value); !matched {
+				continue
+			}
+			name := rule.Principal
+			if name == "*" {
+				name = value
+			}
+			return &Principal{Name: name, Method: MethodMTLS}, true
+		}
+	}
+	return nil, false
+}
+
+// Middleware attaches the principal of a verified client certificate to the
+// request context. Requests without a certificate pass through anonymously;
+// requests whose certificate matches no rule are rejected with
+// CodePermissionDenied in the caller's protocol.
+func (p *CertPolicy) Middleware(next http.Handler) http.Handler {
+	errorWriter := connect.NewErrorWriter()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, ok := p.Principal(r.TLS.VerifiedChains[0][0])
+		if !ok {
+			if errorWriter.IsSupported(r) {
+				_ = errorWriter.Write(w, r, apierror.New(connect.CodePermissionDenied, apierror.ReasonCertNotAuthorized, errCertNotAuthorized))
+			} else {
+				http.Error(w, errCertNotAuthorized.Error(), http.StatusForbidden)
+			}
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+	})
+}
+
+func certValues(cert *x509.Certificate, field string) []string {
+	switch field {
+	case FieldCN:
+		return []string{cert.Subject.CommonName}
+	case FieldDNS:
+		return cert.DNSNames
+	case FieldURI:
+		values := make([]string, len(cert.URIs))
+		for i, uri := range cert.URIs {
+			values[i] = uri.String()
+		}
+		return values
+	case FieldEmail:
+		return cert.EmailAddresses
+	default:
+		return nil
+	}
+}
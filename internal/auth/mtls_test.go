@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testCert() *x509.Certificate {
+	spiffe, _ := url.Parse("spiffe://cluster.local/ns/batch/sa/uploader")
+	return &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "uploader"},
+		DNSNames: []string{"uploader.batch.svc"},
+		URIs:     []*url.URL{spiffe},
+	}
+}
+
+func TestParseCertRule(t *testing.T) {
+	rule, err := ParseCertRule("uri:spiffe://cluster.local/ns/batch/sa/*=batch")
+	require.NoError(t, err)
+	assert.Equal(t, CertRule{Field: FieldURI, Pattern: "spiffe://cluster.local/ns/batch/sa/*", Principal: "batch"}, rule)
+
+	for _, bad := range []string{"cn", "cn:x", "cn:=p", "ip:1.2.3.4=p", "cn:[=p"} {
+		_, err := ParseCertRule(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestCertPolicy_DefaultsToCommonName(t *testing.T) {
+	policy, err := NewCertPolicy(nil)
+	require.NoError(t, err)
+
+	principal, ok := policy.Principal(testCert())
+
+	require.True(t, ok)
+	assert.Equal(t, &Principal{Name: "uploader", Method: MethodMTLS}, principal)
+}
+
+func TestCertPolicy_Rules(t *testing.T) {
+	policy, err := NewCertPolicy([]string{
+		"cn:admin=admin",
+		"uri:spiffe://cluster.local/ns/batch/sa/*=batch",
+		"dns:*.svc=*",
+	})
+	require.NoError(t, err)
+
+	principal, ok := policy.Principal(testCert())
+	require.True(t, ok)
+	assert.Equal(t, "batch", principal.Name)
+
+	cert := testCert()
+	cert.URIs = nil
+	principal, ok = policy.Principal(cert)
+	require.True(t, ok)
+	assert.Equal(t, "uploader.batch.svc", principal.Name, "* uses the matched value")
+
+	cert.DNSNames = nil
+	_, ok = policy.Principal(cert)
+	assert.False(t, ok)
+}
+
+func TestCertPolicy_Middleware(t *testing.T) {
+	policy, err := NewCertPolicy([]string{"cn:uploader=batch"})
+	require.NoError(t, err)
+
+	var got *Principal
+	handler := policy.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = PrincipalFromContext(r.Context())
+	}))
+
+	// Anonymous plaintext request
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/", nil))
+	assert.Nil(t, got)
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{testCert()}}}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	require.NotNil(t, got)
+	assert.Equal(t, "batch", got.Name)
+
+	cert := testCert()
+	cert.Subject.CommonName = "intruder"
+	req = httptest.NewRequest(http.MethodPost, "/api.v1.GrpcService/GetInfo", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "permission_denied")
+	assert.Contains(t, recorder.Body.String(), "google.rpc.ErrorInfo")
+}
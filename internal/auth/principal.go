@@ -0,0 +1,48 @@
+// Package auth identifies callers and carries the authenticated principal
+// through the request context.
+package auth
+
+import "context"
+
+// Authentication methods recorded on a Principal
+const (
+	MethodMTLS = "mtls"
+)
+
+// Principal is an authenticated caller
+type Principal struct {
+	// Name identifies the caller, e.g. a service account or user
+	Name string
+	// Method is how the caller authenticated
+	Method string
+	// Scopes limits the procedures the caller may call; nil means no limit
+	Scopes []string
+}
+
+// ID identifies the principal across authentication methods, e.g.
+// "jwt:alice"
+func (p *Principal) ID() string {
+	return p.Method + ":" + p.Name
+}
+
+// CallerID returns the ID of the authenticated principal in ctx, or "" when
+// the caller is not authenticated
+func CallerID(ctx context.Context) string {
+	if principal, ok := PrincipalFromContext(ctx); ok {
+		return principal.ID()
+	}
+	return ""
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the authenticated principal, if any
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
@@ -0,0 +1,131 @@
+// Package clock abstracts the current time and timers so time-dependent code
+// can be tested with a fake clock.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock tells the time and waits for durations to pass
+type Clock interface {
+	Now() time.Time
+	// After waits for d to elapse and then sends the current time on the
+	// returned channel
+	After(d time.Duration) <-chan time.Time
+}
+
+// Real returns the wall clock
+func Real() Clock {
+	return realClock{}
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// Since returns the time elapsed on c since t
+func Since(c Clock, t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Fake is a Clock that only moves when told to. It is safe for concurrent use.
+type Fake struct {
+	mu          sync.Mutex
+	cond        *sync.Cond
+	now         time.Time
+	waiters     []waiter
+	autoAdvance bool
+}
+
+type waiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewFake creates a fake clock reading now
+func NewFake(now time.Time) *Fake {
+	f := &Fake{now: now}
+	f.cond = sync.NewCond(&f.mu)
+	return f
+}
+
+// SetAutoAdvance makes After move the clock forward by the requested
+// duration and fire at once, so code that sleeps runs without waiting
+func (f *Fake) SetAutoAdvance(enabled bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.autoAdvance = enabled
+}
+
+// Now implements Clock
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After implements Clock. The channel fires once the clock has been advanced
+// by d.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if f.autoAdvance && d > 0 {
+		f.advance(d)
+	}
+	if d <= 0 || f.autoAdvance {
+		ch <- f.now
+		return ch
+	}
+	f.waiters = append(f.waiters, waiter{deadline: f.now.Add(d), ch: ch})
+	f.cond.Broadcast()
+	return ch
+}
+
+// Advance moves the clock forward by d, firing every timer that falls due
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.advance(d)
+}
+
+func (f *Fake) advance(d time.Duration) {
+	f.now = f.now.Add(d)
+	sort.SliceStable(f.waiters, func(i, j int) bool {
+		return f.waiters[i].deadline.Before(f.waiters[j].deadline)
+	})
+	pending := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.deadline.After(f.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- f.now
+	}
+	f.waiters = pending
+}
+
+// Waiters returns the number of After calls that have not fired yet
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+// BlockUntil waits until at least n After calls are pending. Tests use it to
+// advance the clock only once the code under test is waiting.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.cond.Wait()
+	}
+}
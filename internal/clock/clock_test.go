@@ -0,0 +1,71 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func fired(ch <-chan time.Time) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestFake_Advance(t *testing.T) {
+	fake := NewFake(start)
+	short := fake.After(time.Second)
+	long := fake.After(time.Minute)
+	assert.Equal(t, 2, fake.Waiters())
+
+	fake.Advance(500 * time.Millisecond)
+	assert.False(t, fired(short))
+
+	fake.Advance(500 * time.Millisecond)
+	assert.True(t, fired(short))
+	assert.False(t, fired(long))
+	assert.Equal(t, 1, fake.Waiters())
+	assert.Equal(t, start.Add(time.Second), fake.Now())
+	assert.Equal(t, time.Second, Since(fake, start))
+}
+
+func TestFake_AfterZero(t *testing.T) {
+	fake := NewFake(start)
+
+	assert.True(t, fired(fake.After(0)))
+	assert.Equal(t, 0, fake.Waiters())
+}
+
+func TestFake_AutoAdvance(t *testing.T) {
+	fake := NewFake(start)
+	fake.SetAutoAdvance(true)
+
+	at := <-fake.After(time.Minute)
+
+	assert.Equal(t, start.Add(time.Minute), at)
+	assert.Equal(t, start.Add(time.Minute), fake.Now())
+}
+
+func TestFake_BlockUntil(t *testing.T) {
+	fake := NewFake(start)
+	done := make(chan struct{})
+	go func() {
+		<-fake.After(time.Second)
+		close(done)
+	}()
+
+	fake.BlockUntil(1)
+	fake.Advance(time.Second)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("waiter was not released")
+	}
+}
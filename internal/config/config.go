@@ -0,0 +1,539 @@
+// Package config loads the typed server configuration.
+//
+// Values are resolved in the following order, each source overriding the
+// previous one:
+//
+//  1. built-in defaults (see Default)
+//  2. an optional YAML file passed with -config or CONFIG_FILE
+//  3. environment variables
+//  4. command line flags
+//
+// The mapping from a field to its YAML key, environment variable and flag is
+// declared with struct tags, so adding a setting only means adding a field.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileEnv is the environment variable naming the optional YAML file
+const ConfigFileEnv = "CONFIG_FILE"
+
+// Config is the complete server configuration
+type Config struct {
+	Environment string           `yaml:"environment" env:"ENVIRONMENT" flag:"environment" usage:"deployment environment name"`
+	Debug       bool             `yaml:"debug" env:"DEBUG" flag:"debug" usage:"enable debug behavior"`
+	Server      ServerConfig     `yaml:"server"`
+	TLS         TLSConfig        `yaml:"tls"`
+	Auth        AuthConfig       `yaml:"auth"`
+	RateLimit   RateLimitConfig  `yaml:"rate_limit"`
+	Stream      StreamConfig     `yaml:"stream"`
+	Upload      UploadConfig     `yaml:"upload"`
+	Process     ProcessConfig    `yaml:"process"`
+	Jobs        JobsConfig       `yaml:"jobs"`
+	Storage     StorageConfig    `yaml:"storage"`
+	Pagination  PaginationConfig `yaml:"pagination"`
+	Tracing     TracingConfig    `yaml:"tracing"`
+	Log         LogConfig        `yaml:"log"`
+}
+
+// ServerConfig holds listener settings
+type ServerConfig struct {
+	GRPCPort   int  `yaml:"grpc_port" env:"GRPC_PORT" flag:"grpc-port" usage:"port for the gRPC/Connect listener"`
+	HealthPort int  `yaml:"health_port" env:"HEALTH_PORT,HTTP_PORT" flag:"health-port" usage:"port for the health check listener"`
+	Reflection bool `yaml:"reflection" env:"GRPC_REFLECTION" flag:"reflection" usage:"serve gRPC server reflection"`
+
+	// PreStopDelay is how long the server keeps serving after reporting
+	// NOT_SERVING, giving load balancers time to stop routing to it
+	PreStopDelay time.Duration `yaml:"pre_stop_delay" env:"PRE_STOP_DELAY" flag:"pre-stop-delay" usage:"delay between failing readiness and draining"`
+	// ShutdownTimeout bounds how long in-flight RPCs may run while draining
+	// before they are canceled
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout" usage:"maximum time to drain in-flight RPCs"`
+	HTTP2           HTTP2Config   `yaml:"http2"`
+}
+
+// HTTP2Config holds HTTP/2 flow control settings for the main listener.
+// Zero values keep the golang.org/x/net/http2 defaults.
+type HTTP2Config struct {
+	MaxConcurrentStreams int `yaml:"max_concurrent_streams" env:"HTTP2_MAX_CONCURRENT_STREAMS" flag:"http2-max-concurrent-streams" usage:"concurrent HTTP/2 streams per connection"`
+	// MaxUploadBufferPerConnection is the connection flow control window,
+	// bounding unread request data across all streams of a connection
+	MaxUploadBufferPerConnection int `yaml:"max_upload_buffer_per_connection" env:"HTTP2_MAX_UPLOAD_BUFFER_PER_CONNECTION" usage:"HTTP/2 connection flow control window in bytes"`
+	// MaxUploadBufferPerStream is the stream flow control window, bounding
+	// how far a client can send ahead of the handler reading its requests
+	MaxUploadBufferPerStream int `yaml:"max_upload_buffer_per_stream" env:"HTTP2_MAX_UPLOAD_BUFFER_PER_STREAM" flag:"http2-max-upload-buffer-per-stream" usage:"HTTP/2 stream flow control window in bytes"`
+}
+
+// TLSConfig holds settings for TLS and mutual TLS on the main listener
+type TLSConfig struct {
+	Enabled  bool   `yaml:"enabled" env:"TLS_ENABLED" flag:"tls" usage:"serve TLS on the gRPC listener"`
+	CertFile string `yaml:"cert_file" env:"TLS_CERT_FILE" flag:"tls-cert" usage:"server certificate PEM file"`
+	KeyFile  string `yaml:"key_file" env:"TLS_KEY_FILE" flag:"tls-key" usage:"server private key PEM file"`
+	// ClientCAFile enables client certificate verification against its CAs
+	ClientCAFile string `yaml:"client_ca_file" env:"TLS_CLIENT_CA_FILE" flag:"tls-client-ca" usage:"CA bundle for verifying client certificates"`
+	// ClientAuth is none, request (verify if presented) or require
+	ClientAuth string `yaml:"client_auth" env:"TLS_CLIENT_AUTH" flag:"tls-client-auth" usage:"client certificate mode (none, request, require)"`
+	// PrincipalRules map client certificates to principals, written as
+	// field:pattern=principal (see auth.ParseCertRule)
+	PrincipalRules []string      `yaml:"principal_rules" env:"TLS_PRINCIPAL_RULES" usage:"client certificate to principal rules"`
+	ReloadInterval time.Duration `yaml:"reload_interval" env:"TLS_RELOAD_INTERVAL" flag:"tls-reload-interval" usage:"how often to check certificate files for changes"`
+}
+
+// AuthConfig holds caller authentication settings
+type AuthConfig struct {
+	// PublicProcedures may be called without credentials when any
+	// authentication method is enabled
+	PublicProcedures []string     `yaml:"public_procedures" env:"AUTH_PUBLIC_PROCEDURES" usage:"procedures callable without credentials"`
+	JWT              JWTConfig    `yaml:"jwt"`
+	APIKeys          APIKeyConfig `yaml:"api_keys"`
+}
+
+// JWTConfig holds settings for bearer token authentication
+type JWTConfig struct {
+	Enabled  bool   `yaml:"enabled" env:"AUTH_JWT_ENABLED" flag:"auth-jwt" usage:"require JWT bearer tokens"`
+	JWKSURL  string `yaml:"jwks_url" env:"AUTH_JWKS_URL" flag:"auth-jwks-url" usage:"URL of the JSON Web Key Set"`
+	JWKSFile string `yaml:"jwks_file" env:"AUTH_JWKS_FILE" flag:"auth-jwks-file" usage:"local JSON Web Key Set file"`
+	// JWKSRefreshInterval is how often the key set is reloaded
+	JWKSRefreshInterval time.Duration `yaml:"jwks_refresh_interval" env:"AUTH_JWKS_REFRESH_INTERVAL" usage:"how often to reload the key set"`
+	Issuer              string        `yaml:"issuer" env:"AUTH_JWT_ISSUER" flag:"auth-jwt-issuer" usage:"required token issuer"`
+	Audience            []string      `yaml:"audience" env:"AUTH_JWT_AUDIENCE" flag:"auth-jwt-audience" usage:"accepted token audiences, comma separated"`
+	ClockSkew           time.Duration `yaml:"clock_skew" env:"AUTH_JWT_CLOCK_SKEW" usage:"allowed clock skew for exp and nbf"`
+}
+
+// APIKeyConfig holds settings for API key authentication. The key store is
+// a YAML or JSON document read from File or, inline, from Keys.
+type APIKeyConfig struct {
+	Enabled bool   `yaml:"enabled" env:"AUTH_API_KEYS_ENABLED" flag:"auth-api-keys" usage:"accept API keys"`
+	File    string `yaml:"file" env:"AUTH_API_KEYS_FILE" flag:"auth-api-keys-file" usage:"API key store file"`
+	Keys    string `yaml:"keys" env:"AUTH_API_KEYS" usage:"inline API key store" secret:"true"`
+}
+
+// RateLimitConfig holds token bucket settings applied per caller and
+// procedure
+type RateLimitConfig struct {
+	Enabled bool    `yaml:"enabled" env:"RATE_LIMIT_ENABLED" flag:"rate-limit" usage:"enable rate limiting"`
+	Rate    float64 `yaml:"rate" env:"RATE_LIMIT_RATE" flag:"rate-limit-rate" usage:"default requests per second per caller"`
+	Burst   int     `yaml:"burst" env:"RATE_LIMIT_BURST" flag:"rate-limit-burst" usage:"default burst size per caller"`
+	// Procedures overrides the default as "procedure=rate:burst", e.g.
+	// "StreamData=1:2"; a rate of 0 disables limiting for the procedure
+	Procedures []string `yaml:"procedures" env:"RATE_LIMIT_PROCEDURES" usage:"per-procedure limits as procedure=rate:burst"`
+	// TrustForwardedFor identifies anonymous callers by X-Forwarded-For
+	TrustForwardedFor bool `yaml:"trust_forwarded_for" env:"RATE_LIMIT_TRUST_FORWARDED_FOR" usage:"key anonymous callers by X-Forwarded-For"`
+	// TrustedProxies is how many proxies in front of the server append to
+	// X-Forwarded-For; the caller is the entry that many from the right
+	TrustedProxies int `yaml:"trusted_proxies" env:"RATE_LIMIT_TRUSTED_PROXIES" usage:"proxies appending to X-Forwarded-For"`
+}
+
+// StreamConfig bounds server-streaming RPCs such as StreamData
+type StreamConfig struct {
+	// MaxLimit is the largest number of messages a stream may ask for
+	MaxLimit int `yaml:"max_limit" env:"STREAM_MAX_LIMIT" flag:"stream-max-limit" usage:"largest StreamData limit accepted"`
+	// MaxDuration ends streams that run longer with DEADLINE_EXCEEDED
+	MaxDuration time.Duration `yaml:"max_duration" env:"STREAM_MAX_DURATION" flag:"stream-max-duration" usage:"longest time a stream may run"`
+	// Interval is the delay between StreamData messages
+	Interval time.Duration `yaml:"interval" env:"STREAM_INTERVAL" flag:"stream-interval" usage:"delay between StreamData messages"`
+}
+
+// UploadConfig bounds client-streaming uploads such as UploadData
+type UploadConfig struct {
+	// MaxRecords is the largest number of records one upload may send
+	MaxRecords int `yaml:"max_records" env:"UPLOAD_MAX_RECORDS" flag:"upload-max-records" usage:"most records accepted per UploadData call"`
+	// MaxBytes bounds the total encoded size of the records of one upload
+	MaxBytes int `yaml:"max_bytes" env:"UPLOAD_MAX_BYTES" flag:"upload-max-bytes" usage:"most bytes accepted per UploadData call"`
+	// MaxFailures is how many failed records the summary lists
+	MaxFailures int `yaml:"max_failures" env:"UPLOAD_MAX_FAILURES" usage:"failed records listed in the UploadData summary"`
+}
+
+// ProcessConfig bounds concurrent processing in BatchProcessData and
+// ProcessStream
+type ProcessConfig struct {
+	// MaxBatchSize is the largest number of items a BatchProcessData call
+	// may send
+	MaxBatchSize int `yaml:"max_batch_size" env:"PROCESS_MAX_BATCH_SIZE" flag:"process-max-batch-size" usage:"most items accepted per BatchProcessData call"`
+	// BatchWorkers is how many items of one batch are processed at once
+	BatchWorkers int `yaml:"batch_workers" env:"PROCESS_BATCH_WORKERS" flag:"process-batch-workers" usage:"items processed concurrently per BatchProcessData call"`
+	// MaxInFlight is how many requests of one stream are processed at once.
+	// Further requests are not read until a result has been sent, so HTTP/2
+	// flow control slows down clients that send faster than that.
+	MaxInFlight int `yaml:"max_in_flight" env:"PROCESS_MAX_IN_FLIGHT" flag:"process-max-in-flight" usage:"requests processed concurrently per ProcessStream call"`
+}
+
+// JobsConfig holds settings for asynchronous jobs started with SubmitJob
+type JobsConfig struct {
+	Workers int `yaml:"workers" env:"JOBS_WORKERS" flag:"jobs-workers" usage:"jobs run concurrently"`
+	// QueueSize is how many jobs may wait for a worker before SubmitJob
+	// fails with RESOURCE_EXHAUSTED
+	QueueSize int `yaml:"queue_size" env:"JOBS_QUEUE_SIZE" flag:"jobs-queue-size" usage:"jobs waiting for a worker before submissions are rejected"`
+	// Retention is how long finished jobs can still be fetched
+	Retention time.Duration `yaml:"retention" env:"JOBS_RETENTION" flag:"jobs-retention" usage:"how long finished jobs are kept"`
+	// MaxWait is the longest a WaitJob call blocks
+	MaxWait time.Duration `yaml:"max_wait" env:"JOBS_MAX_WAIT" usage:"longest WaitJob timeout"`
+}
+
+// StorageConfig selects where results and jobs are kept
+type StorageConfig struct {
+	// Driver is memory, which keeps recent results and all jobs until the
+	// process exits, or sqlite, which persists both in Path
+	Driver string `yaml:"driver" env:"STORAGE_DRIVER" flag:"storage-driver" usage:"where results and jobs are kept (memory or sqlite)"`
+	Path   string `yaml:"path" env:"STORAGE_PATH" flag:"storage-path" usage:"SQLite database file"`
+	// MaxResults is how many results either driver keeps, dropping the
+	// oldest first
+	MaxResults int `yaml:"max_results" env:"STORAGE_MAX_RESULTS" flag:"storage-max-results" usage:"most results kept; the oldest are deleted first"`
+}
+
+// PaginationConfig holds the page size and page token settings of the List
+// RPCs
+type PaginationConfig struct {
+	DefaultPageSize int `yaml:"default_page_size" env:"PAGE_SIZE_DEFAULT" usage:"page size used when a request asks for 0"`
+	// MaxPageSize caps larger page sizes
+	MaxPageSize int `yaml:"max_page_size" env:"PAGE_SIZE_MAX" usage:"largest page size returned"`
+	// TokenKey signs page tokens. Replicas must share it for tokens to work
+	// across them; when empty, each process uses a random key.
+	TokenKey string `yaml:"token_key" env:"PAGE_TOKEN_KEY" usage:"key signing page tokens" secret:"true"`
+}
+
+// TracingConfig holds OpenTelemetry trace export settings
+type TracingConfig struct {
+	// Exporter is none, stdout, otlp-grpc or otlp-http
+	Exporter string `yaml:"exporter" env:"TRACING_EXPORTER" flag:"tracing-exporter" usage:"trace exporter (none, stdout, otlp-grpc, otlp-http)"`
+	// Endpoint is the collector host:port; empty uses the OTEL_EXPORTER_OTLP_* defaults
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT" flag:"tracing-endpoint" usage:"OTLP collector host:port"`
+	Insecure    bool    `yaml:"insecure" env:"TRACING_INSECURE" usage:"send OTLP without TLS"`
+	SampleRatio float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" usage:"fraction of new traces to sample"`
+}
+
+// LogConfig holds logging settings
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL" flag:"log-level" usage:"log level (trace, debug, info, warn, error)"`
+	Format string `yaml:"format" env:"LOG_FORMAT" flag:"log-format" usage:"log format (json, text or cloud)"`
+	// ProjectID qualifies trace IDs in the cloud format so Cloud Logging
+	// links log lines to Cloud Trace
+	ProjectID string `yaml:"project_id" env:"LOG_PROJECT_ID,GOOGLE_CLOUD_PROJECT" usage:"Google Cloud project for trace links"`
+}
+
+// Default returns the built-in configuration
+func Default() *Config {
+	return &Config{
+		Environment: "development",
+		Server: ServerConfig{
+			GRPCPort:        9090,
+			HealthPort:      8080,
+			Reflection:      true,
+			PreStopDelay:    5 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		TLS: TLSConfig{
+			ClientAuth:     "none",
+			ReloadInterval: 30 * time.Second,
+		},
+		Auth: AuthConfig{
+			PublicProcedures: []string{"/api.v1.GrpcService/GetHealth"},
+			JWT: JWTConfig{
+				JWKSRefreshInterval: 5 * time.Minute,
+				ClockSkew:           30 * time.Second,
+			},
+		},
+		RateLimit: RateLimitConfig{
+			Rate:           10,
+			Burst:          20,
+			TrustedProxies: 1,
+		},
+		Stream: StreamConfig{
+			MaxLimit:    1000,
+			MaxDuration: 5 * time.Minute,
+			Interval:    100 * time.Millisecond,
+		},
+		Upload: UploadConfig{
+			MaxRecords:  10000,
+			MaxBytes:    16 << 20,
+			MaxFailures: 100,
+		},
+		Process: ProcessConfig{
+			MaxBatchSize: 100,
+			BatchWorkers: 8,
+			MaxInFlight:  16,
+		},
+		Jobs: JobsConfig{
+			Workers:   4,
+			QueueSize: 100,
+			Retention: time.Hour,
+			MaxWait:   time.Minute,
+		},
+		Storage: StorageConfig{
+			Driver:     "memory",
+			Path:       "example-backend.db",
+			MaxResults: 10000,
+		},
+		Pagination: PaginationConfig{
+			DefaultPageSize: 50,
+			MaxPageSize:     1000,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			SampleRatio: 1,
+		},
+		Log: LogConfig{
+			Level:  "info",
+			Format: "json",
+		},
+	}
+}
+
+// Load builds the configuration from defaults, the optional YAML file, the
+// process environment and the given command line arguments, then validates it
+func Load(args []string) (*Config, error) {
+	return load(args, os.LookupEnv)
+}
+
+func load(args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+
+	path := configPath(args)
+	if path == "" {
+		path, _ = lookupEnv(ConfigFileEnv)
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := applyEnv(reflect.ValueOf(cfg).Elem(), "", lookupEnv); err != nil {
+		return nil, err
+	}
+
+	fs := flag.NewFlagSet("server", flag.ContinueOnError)
+	fs.String("config", path, "path to a YAML configuration file")
+	bindFlags(fs, reflect.ValueOf(cfg).Elem())
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("parsing flags: %w", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile merges the YAML file at path into the configuration
+func (c *Config) loadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening config file: %w", err)
+	}
+	defer f.Close()
+
+	decoder := yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate checks the configuration and reports every invalid field
+func (c *Config) Validate() error {
+	var errs []error
+
+	if c.Environment == "" {
+		errs = append(errs, errors.New("environment: must not be empty"))
+	}
+	errs = append(errs, validatePort("server.grpc_port", c.Server.GRPCPort))
+	errs = append(errs, validatePort("server.health_port", c.Server.HealthPort))
+	if c.Server.GRPCPort == c.Server.HealthPort {
+		errs = append(errs, fmt.Errorf("server.health_port: must differ from server.grpc_port (%d)", c.Server.GRPCPort))
+	}
+	if c.Server.PreStopDelay < 0 {
+		errs = append(errs, fmt.Errorf("server.pre_stop_delay: must not be negative, got %s", c.Server.PreStopDelay))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("server.shutdown_timeout: must be positive, got %s", c.Server.ShutdownTimeout))
+	}
+	errs = append(errs, c.Server.HTTP2.validate())
+	errs = append(errs, c.TLS.validate())
+	errs = append(errs, c.Auth.JWT.validate())
+	if c.RateLimit.Rate < 0 {
+		errs = append(errs, fmt.Errorf("rate_limit.rate: must not be negative, got %g", c.RateLimit.Rate))
+	}
+	if c.RateLimit.Rate > 0 && c.RateLimit.Burst < 1 {
+		errs = append(errs, fmt.Errorf("rate_limit.burst: must be at least 1, got %d", c.RateLimit.Burst))
+	}
+	if c.RateLimit.TrustForwardedFor && c.RateLimit.TrustedProxies < 1 {
+		errs = append(errs, fmt.Errorf("rate_limit.trusted_proxies: must be at least 1, got %d", c.RateLimit.TrustedProxies))
+	}
+	if c.Stream.MaxLimit < 1 {
+		errs = append(errs, fmt.Errorf("stream.max_limit: must be at least 1, got %d", c.Stream.MaxLimit))
+	}
+	if c.Stream.MaxDuration <= 0 {
+		errs = append(errs, fmt.Errorf("stream.max_duration: must be positive, got %s", c.Stream.MaxDuration))
+	}
+	if c.Stream.Interval < 0 {
+		errs = append(errs, fmt.Errorf("stream.interval: must not be negative, got %s", c.Stream.Interval))
+	}
+	if c.Upload.MaxRecords < 1 {
+		errs = append(errs, fmt.Errorf("upload.max_records: must be at least 1, got %d", c.Upload.MaxRecords))
+	}
+	if c.Upload.MaxBytes < 1 {
+		errs = append(errs, fmt.Errorf("upload.max_bytes: must be at least 1, got %d", c.Upload.MaxBytes))
+	}
+	if c.Upload.MaxFailures < 0 {
+		errs = append(errs, fmt.Errorf("upload.max_failures: must not be negative, got %d", c.Upload.MaxFailures))
+	}
+	if c.Process.MaxBatchSize < 1 {
+		errs = append(errs, fmt.Errorf("process.max_batch_size: must be at least 1, got %d", c.Process.MaxBatchSize))
+	}
+	if c.Process.BatchWorkers < 1 {
+		errs = append(errs, fmt.Errorf("process.batch_workers: must be at least 1, got %d", c.Process.BatchWorkers))
+	}
+	if c.Process.MaxInFlight < 1 {
+		errs = append(errs, fmt.Errorf("process.max_in_flight: must be at least 1, got %d", c.Process.MaxInFlight))
+	}
+	if c.Jobs.Workers < 1 {
+		errs = append(errs, fmt.Errorf("jobs.workers: must be at least 1, got %d", c.Jobs.Workers))
+	}
+	if c.Jobs.QueueSize < 1 {
+		errs = append(errs, fmt.Errorf("jobs.queue_size: must be at least 1, got %d", c.Jobs.QueueSize))
+	}
+	if c.Jobs.Retention <= 0 {
+		errs = append(errs, fmt.Errorf("jobs.retention: must be positive, got %s", c.Jobs.Retention))
+	}
+	if c.Jobs.MaxWait <= 0 {
+		errs = append(errs, fmt.Errorf("jobs.max_wait: must be positive, got %s", c.Jobs.MaxWait))
+	}
+	switch c.Storage.Driver {
+	case "memory":
+	case "sqlite":
+		if c.Storage.Path == "" {
+			errs = append(errs, errors.New("storage.path: must not be empty with the sqlite driver"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("storage.driver: must be memory or sqlite, got %q", c.Storage.Driver))
+	}
+	if c.Storage.MaxResults < 1 {
+		errs = append(errs, fmt.Errorf("storage.max_results: must be at least 1, got %d", c.Storage.MaxResults))
+	}
+	if c.Pagination.DefaultPageSize < 1 {
+		errs = append(errs, fmt.Errorf("pagination.default_page_size: must be at least 1, got %d", c.Pagination.DefaultPageSize))
+	}
+	if c.Pagination.MaxPageSize < c.Pagination.DefaultPageSize {
+		errs = append(errs, fmt.Errorf("pagination.max_page_size: must be at least the default page size %d, got %d", c.Pagination.DefaultPageSize, c.Pagination.MaxPageSize))
+	}
+	switch c.Tracing.Exporter {
+	case "none", "stdout", "otlp-grpc", "otlp-http":
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter: must be none, stdout, otlp-grpc or otlp-http, got %q", c.Tracing.Exporter))
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing.sample_ratio: must be between 0 and 1, got %g", c.Tracing.SampleRatio))
+	}
+	if c.Auth.APIKeys.Enabled && (c.Auth.APIKeys.File == "") == (c.Auth.APIKeys.Keys == "") {
+		errs = append(errs, errors.New("auth.api_keys: exactly one of file and keys is required"))
+	}
+	if _, err := logrus.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: unknown level %q", c.Log.Level))
+	}
+	if c.Log.Format != "json" && c.Log.Format != "text" && c.Log.Format != "cloud" {
+		errs = append(errs, fmt.Errorf("log.format: must be json, text or cloud, got %q", c.Log.Format))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (h *HTTP2Config) validate() error {
+	var errs []error
+	if h.MaxConcurrentStreams < 0 || h.MaxConcurrentStreams > math.MaxUint32 {
+		errs = append(errs, fmt.Errorf("server.http2.max_concurrent_streams: must be between 0 and %d, got %d", uint32(math.MaxUint32), h.MaxConcurrentStreams))
+	}
+	// Flow control windows cannot be below the initial window of 64KiB - 1
+	// or above the largest window HTTP/2 allows
+	const minWindow, maxWindow = 1<<16 - 1, 1<<31 - 1
+	if h.MaxUploadBufferPerConnection != 0 && (h.MaxUploadBufferPerConnection < minWindow || h.MaxUploadBufferPerConnection > maxWindow) {
+		errs = append(errs, fmt.Errorf("server.http2.max_upload_buffer_per_connection: must be 0 or between %d and %d, got %d", minWindow, maxWindow, h.MaxUploadBufferPerConnection))
+	}
+	if h.MaxUploadBufferPerStream < 0 || h.MaxUploadBufferPerStream > maxWindow {
+		errs = append(errs, fmt.Errorf("server.http2.max_upload_buffer_per_stream: must be between 0 and %d, got %d", maxWindow, h.MaxUploadBufferPerStream))
+	}
+	return errors.Join(errs...)
+}
+
+func (t *TLSConfig) validate() error {
+	var errs []error
+
+	switch t.ClientAuth {
+	case "none", "request", "require":
+	default:
+		errs = append(errs, fmt.Errorf("tls.client_auth: must be none, request or require, got %q", t.ClientAuth))
+	}
+	if !t.Enabled {
+		return errors.Join(errs...)
+	}
+
+	if t.CertFile == "" || t.KeyFile == "" {
+		errs = append(errs, errors.New("tls.cert_file and tls.key_file: required when tls.enabled is true"))
+	}
+	if t.ClientAuth != "none" && t.ClientCAFile == "" {
+		errs = append(errs, fmt.Errorf("tls.client_ca_file: required when tls.client_auth is %s", t.ClientAuth))
+	}
+	if t.ReloadInterval <= 0 {
+		errs = append(errs, fmt.Errorf("tls.reload_interval: must be positive, got %s", t.ReloadInterval))
+	}
+	return errors.Join(errs...)
+}
+
+func (j *JWTConfig) validate() error {
+	if !j.Enabled {
+		return nil
+	}
+
+	var errs []error
+	if (j.JWKSURL == "") == (j.JWKSFile == "") {
+		errs = append(errs, errors.New("auth.jwt: exactly one of jwks_url and jwks_file is required"))
+	}
+	if j.Issuer == "" {
+		errs = append(errs, errors.New("auth.jwt.issuer: required when auth.jwt.enabled is true"))
+	}
+	if len(j.Audience) == 0 {
+		errs = append(errs, errors.New("auth.jwt.audience: required when auth.jwt.enabled is true"))
+	}
+	if j.JWKSRefreshInterval <= 0 {
+		errs = append(errs, fmt.Errorf("auth.jwt.jwks_refresh_interval: must be positive, got %s", j.JWKSRefreshInterval))
+	}
+	if j.ClockSkew < 0 {
+		errs = append(errs, fmt.Errorf("auth.jwt.clock_skew: must not be negative, got %s", j.ClockSkew))
+	}
+	return errors.Join(errs...)
+}
+
+func validatePort(name string, port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("%s: must be between 1 and 65535, got %d", name, port)
+	}
+	return nil
+}
+
+// configPath finds the -config flag in args without parsing the rest
+func configPath(args []string) string {
+	for i, arg := range args {
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			continue
+		}
+		if value, ok := strings.CutPrefix(name, "config="); ok {
+			return value
+		}
+		if name == "config" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
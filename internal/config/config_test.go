@@ -0,0 +1,343 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func envMap(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := values[key]
+		return value, ok
+	}
+}
+
+func writeFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, err := load(nil, envMap(nil))
+
+	require.NoError(t, err)
+	assert.Equal(t, Default(), cfg)
+}
+
+func TestLoad_Env(t *testing.T) {
+	cfg, err := load(nil, envMap(map[string]string{
+		"GRPC_PORT":   "9191",
+		"HEALTH_PORT": "8181",
+		"LOG_LEVEL":   "debug",
+		"ENVIRONMENT": "staging",
+	}))
+
+	require.NoError(t, err)
+	assert.Equal(t, 9191, cfg.Server.GRPCPort)
+	assert.Equal(t, 8181, cfg.Server.HealthPort)
+	assert.Equal(t, "debug", cfg.Log.Level)
+	assert.Equal(t, "staging", cfg.Environment)
+}
+
+func TestLoad_EnvAlias(t *testing.T) {
+	cfg, err := load(nil, envMap(map[string]string{"HTTP_PORT": "8181"}))
+	require.NoError(t, err)
+	assert.Equal(t, 8181, cfg.Server.HealthPort)
+
+	cfg, err = load(nil, envMap(map[string]string{"HEALTH_PORT": "8282", "HTTP_PORT": "8181"}))
+	require.NoError(t, err)
+	assert.Equal(t, 8282, cfg.Server.HealthPort)
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	path := writeFile(t, `
+environment: file
+server:
+  grpc_port: 7000
+  health_port: 7001
+log:
+  level: warn
+`)
+
+	cfg, err := load(
+		[]string{"-config", path, "-log-level=error"},
+		envMap(map[string]string{"GRPC_PORT": "7100"}),
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, "file", cfg.Environment)
+	assert.Equal(t, 7100, cfg.Server.GRPCPort, "env overrides file")
+	assert.Equal(t, 7001, cfg.Server.HealthPort, "file overrides default")
+	assert.Equal(t, "error", cfg.Log.Level, "flag overrides file")
+}
+
+func TestLoad_ConfigFileEnv(t *testing.T) {
+	path := writeFile(t, "environment: from-env-file\n")
+
+	cfg, err := load(nil, envMap(map[string]string{ConfigFileEnv: path}))
+
+	require.NoError(t, err)
+	assert.Equal(t, "from-env-file", cfg.Environment)
+}
+
+func TestLoad_UnknownFileKey(t *testing.T) {
+	path := writeFile(t, "server:\n  grpc_prot: 9090\n")
+
+	_, err := load([]string{"--config=" + path}, envMap(nil))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "grpc_prot")
+}
+
+func TestLoad_InvalidEnv(t *testing.T) {
+	_, err := load(nil, envMap(map[string]string{"GRPC_PORT": "ninety"}))
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server.grpc_port: invalid value for GRPC_PORT")
+}
+
+func TestLoad_BoolFlag(t *testing.T) {
+	cfg, err := load([]string{"-reflection=false", "-debug"}, envMap(nil))
+
+	require.NoError(t, err)
+	assert.False(t, cfg.Server.Reflection)
+	assert.True(t, cfg.Debug)
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	cfg.Server.GRPCPort = 0
+	cfg.Log.Level = "loud"
+	cfg.Log.Format = "xml"
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server.grpc_port: must be between 1 and 65535, got 0")
+	assert.Contains(t, err.Error(), `log.level: unknown level "loud"`)
+	assert.Contains(t, err.Error(), `log.format: must be json, text or cloud, got "xml"`)
+}
+
+func TestValidate_SamePorts(t *testing.T) {
+	cfg := Default()
+	cfg.Server.HealthPort = cfg.Server.GRPCPort
+
+	assert.ErrorContains(t, cfg.Validate(), "must differ from server.grpc_port")
+}
+
+func TestValidate_JWT(t *testing.T) {
+	cfg := Default()
+	cfg.Auth.JWT.Enabled = true
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "auth.jwt: exactly one of jwks_url and jwks_file is required")
+	assert.Contains(t, err.Error(), "auth.jwt.issuer: required")
+	assert.Contains(t, err.Error(), "auth.jwt.audience: required")
+
+	cfg.Auth.JWT.JWKSFile = "jwks.json"
+	cfg.Auth.JWT.Issuer = "https://issuer.example.com"
+	cfg.Auth.JWT.Audience = []string{"example-backend"}
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidate_APIKeys(t *testing.T) {
+	cfg := Default()
+	cfg.Auth.APIKeys.Enabled = true
+
+	assert.ErrorContains(t, cfg.Validate(), "auth.api_keys: exactly one of file and keys is required")
+
+	cfg.Auth.APIKeys.File = "keys.yaml"
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidate_RateLimit(t *testing.T) {
+	cfg := Default()
+	cfg.RateLimit.Rate = -1
+	assert.ErrorContains(t, cfg.Validate(), "rate_limit.rate: must not be negative")
+
+	cfg.RateLimit.Rate = 5
+	cfg.RateLimit.Burst = 0
+	assert.ErrorContains(t, cfg.Validate(), "rate_limit.burst: must be at least 1")
+
+	cfg = Default()
+	cfg.RateLimit.TrustForwardedFor = true
+	cfg.RateLimit.TrustedProxies = 0
+	assert.ErrorContains(t, cfg.Validate(), "rate_limit.trusted_proxies: must be at least 1")
+}
+
+func TestValidate_Tracing(t *testing.T) {
+	cfg := Default()
+	cfg.Tracing.Exporter = "zipkin"
+	cfg.Tracing.SampleRatio = 2
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `tracing.exporter: must be none, stdout, otlp-grpc or otlp-http, got "zipkin"`)
+	assert.Contains(t, err.Error(), "tracing.sample_ratio: must be between 0 and 1, got 2")
+}
+
+func TestValidate_Stream(t *testing.T) {
+	cfg := Default()
+	cfg.Stream.MaxLimit = 0
+	cfg.Stream.MaxDuration = 0
+	cfg.Stream.Interval = -time.Second
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "stream.max_limit: must be at least 1, got 0")
+	assert.Contains(t, err.Error(), "stream.max_duration: must be positive, got 0s")
+	assert.Contains(t, err.Error(), "stream.interval: must not be negative, got -1s")
+}
+
+func TestValidate_Upload(t *testing.T) {
+	cfg := Default()
+	cfg.Upload.MaxRecords = 0
+	cfg.Upload.MaxBytes = -1
+	cfg.Upload.MaxFailures = -1
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "upload.max_records: must be at least 1, got 0")
+	assert.Contains(t, err.Error(), "upload.max_bytes: must be at least 1, got -1")
+	assert.Contains(t, err.Error(), "upload.max_failures: must not be negative, got -1")
+}
+
+func TestValidate_Process(t *testing.T) {
+	cfg := Default()
+	cfg.Process.MaxInFlight = 0
+	cfg.Process.MaxBatchSize = 0
+	cfg.Process.BatchWorkers = -1
+	cfg.Server.HTTP2.MaxConcurrentStreams = -1
+	cfg.Server.HTTP2.MaxUploadBufferPerConnection = 1024
+	cfg.Server.HTTP2.MaxUploadBufferPerStream = 1 << 31
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "process.max_in_flight: must be at least 1, got 0")
+	assert.Contains(t, err.Error(), "process.max_batch_size: must be at least 1, got 0")
+	assert.Contains(t, err.Error(), "process.batch_workers: must be at least 1, got -1")
+	assert.Contains(t, err.Error(), "server.http2.max_concurrent_streams: must be between 0 and 4294967295, got -1")
+	assert.Contains(t, err.Error(), "server.http2.max_upload_buffer_per_connection: must be 0 or between 65535 and 2147483647, got 1024")
+	assert.Contains(t, err.Error(), "server.http2.max_upload_buffer_per_stream: must be between 0 and 2147483647, got 2147483648")
+}
+
+func TestValidate_Jobs(t *testing.T) {
+	cfg := Default()
+	cfg.Jobs.Workers = 0
+	cfg.Jobs.QueueSize = -1
+	cfg.Jobs.Retention = 0
+	cfg.Jobs.MaxWait = -time.Second
+
+	err := cfg.Validate()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "jobs.workers: must be at least 1, got 0")
+	assert.Contains(t, err.Error(), "jobs.queue_size: must be at least 1, got -1")
+	assert.Contains(t, err.Error(), "jobs.retention: must be positive, got 0s")
+	assert.Contains(t, err.Error(), "jobs.max_wait: must be positive, got -1s")
+}
+
+func TestLoad_HTTP2(t *testing.T) {
+	cfg, err := load([]string{"-http2-max-concurrent-streams", "100"}, envMap(map[string]string{
+		"HTTP2_MAX_UPLOAD_BUFFER_PER_STREAM": "262144",
+		"PROCESS_MAX_IN_FLIGHT":              "4",
+	}))
+
+	require.NoError(t, err)
+	assert.Equal(t, 100, cfg.Server.HTTP2.MaxConcurrentStreams)
+	assert.Equal(t, 262144, cfg.Server.HTTP2.MaxUploadBufferPerStream)
+	assert.Zero(t, cfg.Server.HTTP2.MaxUploadBufferPerConnection)
+	assert.Equal(t, 4, cfg.Process.MaxInFlight)
+}
+
+func TestValidate_Storage(t *testing.T) {
+	cfg := Default()
+	cfg.Storage.Driver = "postgres"
+	assert.ErrorContains(t, cfg.Validate(), `storage.driver: must be memory or sqlite, got "postgres"`)
+
+	cfg.Storage.Driver = "sqlite"
+	cfg.Storage.Path = ""
+	assert.ErrorContains(t, cfg.Validate(), "storage.path: must not be empty with the sqlite driver")
+
+	cfg = Default()
+	cfg.Storage.MaxResults = 0
+	assert.ErrorContains(t, cfg.Validate(), "storage.max_results: must be at least 1, got 0")
+}
+
+func TestLoad_Storage(t *testing.T) {
+	cfg, err := load([]string{"-storage-driver", "sqlite"}, envMap(map[string]string{"STORAGE_PATH": "/data/results.db"}))
+
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite", cfg.Storage.Driver)
+	assert.Equal(t, "/data/results.db", cfg.Storage.Path)
+}
+
+func TestValidate_Pagination(t *testing.T) {
+	cfg := Default()
+	cfg.Pagination.DefaultPageSize = 0
+	assert.ErrorContains(t, cfg.Validate(), "pagination.default_page_size: must be at least 1, got 0")
+
+	cfg.Pagination.DefaultPageSize = 100
+	cfg.Pagination.MaxPageSize = 10
+	assert.ErrorContains(t, cfg.Validate(), "pagination.max_page_size: must be at least the default page size 100, got 10")
+}
+
+func TestLoad_Pagination(t *testing.T) {
+	cfg, err := load(nil, envMap(map[string]string{"PAGE_TOKEN_KEY": "shared-key", "PAGE_SIZE_MAX": "200"}))
+
+	require.NoError(t, err)
+	assert.Equal(t, "shared-key", cfg.Pagination.TokenKey)
+	assert.Equal(t, 200, cfg.Pagination.MaxPageSize)
+	assert.Equal(t, redactedValue, cfg.Redacted()["pagination.token_key"])
+}
+
+func TestLoad_Durations(t *testing.T) {
+	cfg, err := load([]string{"-shutdown-timeout", "45s"}, envMap(map[string]string{"PRE_STOP_DELAY": "10s"}))
+
+	require.NoError(t, err)
+	assert.Equal(t, 10*time.Second, cfg.Server.PreStopDelay)
+	assert.Equal(t, 45*time.Second, cfg.Server.ShutdownTimeout)
+
+	_, err = load([]string{"-shutdown-timeout", "0s"}, envMap(nil))
+	assert.ErrorContains(t, err, "server.shutdown_timeout: must be positive")
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+	redacted := cfg.Redacted()
+
+	assert.Equal(t, "9090", redacted["server.grpc_port"])
+	assert.Equal(t, "info", redacted["log.level"])
+	assert.Equal(t, "development", redacted["environment"])
+}
+
+func TestFlatten_Secrets(t *testing.T) {
+	type secrets struct {
+		Token   string        `yaml:"token" secret:"true"`
+		Empty   string        `yaml:"empty" secret:"true"`
+		Timeout time.Duration `yaml:"timeout"`
+		Hosts   []string      `yaml:"hosts"`
+	}
+	out := make(map[string]string)
+	flatten(reflect.ValueOf(secrets{Token: "hunter2", Timeout: time.Second, Hosts: []string{"a", "b"}}), "", out)
+
+	assert.Equal(t, map[string]string{
+		"token":   redactedValue,
+		"empty":   "",
+		"timeout": "1s",
+		"hosts":   "a,b",
+	}, out)
+}
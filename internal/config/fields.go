@@ -0,0 +1,186 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redactedValue replaces secret values in Redacted output
+const redactedValue = "[REDACTED]"
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// applyEnv overrides fields tagged with env from the environment. A tag may
+// list several comma separated names; the first one that is set wins.
+func applyEnv(v reflect.Value, prefix string, lookupEnv func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+		key := joinKey(prefix, field)
+
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			if err := applyEnv(value, key, lookupEnv); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tag := field.Tag.Get("env")
+		if tag == "" {
+			continue
+		}
+		for _, name := range strings.Split(tag, ",") {
+			raw, ok := lookupEnv(name)
+			if !ok {
+				continue
+			}
+			if err := setValue(value, raw); err != nil {
+				return fmt.Errorf("%s: invalid value for %s: %w", key, name, err)
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// bindFlags registers a flag for every field tagged with flag. The current
+// field values become the flag defaults, so unset flags leave them untouched.
+func bindFlags(fs *flag.FlagSet, v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			bindFlags(fs, value)
+			continue
+		}
+
+		name := field.Tag.Get("flag")
+		if name == "" {
+			continue
+		}
+		fs.Var(fieldValue{value}, name, field.Tag.Get("usage"))
+	}
+}
+
+// Redacted returns the effective configuration flattened to dotted YAML keys,
+// with fields tagged secret replaced by a placeholder
+func (c *Config) Redacted() map[string]string {
+	out := make(map[string]string)
+	flatten(reflect.ValueOf(c).Elem(), "", out)
+	return out
+}
+
+func flatten(v reflect.Value, prefix string, out map[string]string) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+		key := joinKey(prefix, field)
+
+		if field.Type.Kind() == reflect.Struct && field.Type != durationType {
+			flatten(value, key, out)
+			continue
+		}
+
+		formatted := formatValue(value)
+		if field.Tag.Get("secret") == "true" && formatted != "" {
+			formatted = redactedValue
+		}
+		out[key] = formatted
+	}
+}
+
+func joinKey(prefix string, field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func setValue(v reflect.Value, raw string) error {
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case v.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+func formatValue(v reflect.Value) string {
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// fieldValue adapts a struct field to flag.Value
+type fieldValue struct {
+	v reflect.Value
+}
+
+func (f fieldValue) String() string {
+	if !f.v.IsValid() {
+		return ""
+	}
+	return formatValue(f.v)
+}
+
+func (f fieldValue) Set(raw string) error {
+	return setValue(f.v, raw)
+}
+
+func (f fieldValue) IsBoolFlag() bool {
+	return f.v.IsValid() && f.v.Kind() == reflect.Bool
+}
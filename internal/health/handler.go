@@ -0,0 +1,107 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/bufbuild/connect-go"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// ServiceName is the fully-qualified name of the gRPC health service
+const ServiceName = "grpc.health.v1.Health"
+
+const (
+	checkProcedure = "/" + ServiceName + "/Check"
+	watchProcedure = "/" + ServiceName + "/Watch"
+)
+
+// NewHandler builds an HTTP handler serving the grpc.health.v1.Health Check
+// and Watch RPCs from the registry. It returns the path on which to mount it.
+func NewHandler(registry *Registry, opts ...connect.HandlerOption) (string, http.Handler) {
+	mux := http.NewServeMux()
+	mux.Handle(checkProcedure, connect.NewUnaryHandler(
+		checkProcedure,
+		func(ctx context.Context, req *connect.Request[healthpb.HealthCheckRequest]) (*connect.Response[healthpb.HealthCheckResponse], error) {
+			status, ok := registry.Status(req.Msg.Service)
+			if !ok {
+				return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("unknown service %q", req.Msg.Service))
+			}
+			return connect.NewResponse(&healthpb.HealthCheckResponse{Status: status}), nil
+		},
+		opts...,
+	))
+	mux.Handle(watchProcedure, connect.NewServerStreamHandler(
+		watchProcedure,
+		func(ctx context.Context, req *connect.Request[healthpb.HealthCheckRequest], stream *connect.ServerStream[healthpb.HealthCheckResponse]) error {
+			updates, cancel := registry.Watch(req.Msg.Service)
+			defer cancel()
+
+			last := Status(-1)
+			for {
+				select {
+				case <-ctx.Done():
+					return connect.NewError(connect.CodeCanceled, ctx.Err())
+				case status := <-updates:
+					if status == last {
+						continue
+					}
+					last = status
+					if err := stream.Send(&healthpb.HealthCheckResponse{Status: status}); err != nil {
+						return err
+					}
+				}
+			}
+		},
+		opts...,
+	))
+	return "/" + ServiceName + "/", mux
+}
+
+// probeResponse is the JSON body of the HTTP probe endpoints
+type probeResponse struct {
+	Status   string            `json:"status"`
+	Services map[string]string `json:"services,omitempty"`
+}
+
+// LivenessHandler serves the /health endpoint. It answers 200 whenever the
+// process can serve HTTP: a failing dependency or a shutdown drain is
+// reported by readiness, so the kubelet does not restart pods for them.
+func LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeProbe(w, http.StatusOK, probeResponse{Status: "healthy"})
+	})
+}
+
+// ReadinessHandler serves the /ready endpoint. It answers 200 when the
+// overall status is SERVING and 503 otherwise, listing the status of every
+// component in the body.
+func ReadinessHandler(registry *Registry) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := probeResponse{Status: "ready", Services: make(map[string]string)}
+		code := http.StatusOK
+
+		if status, _ := registry.Status(OverallService); status != StatusServing {
+			body.Status = "not_ready"
+			code = http.StatusServiceUnavailable
+		}
+		for service, status := range registry.Snapshot() {
+			body.Services[service] = StatusText(status)
+		}
+		writeProbe(w, code, body)
+	})
+}
+
+func writeProbe(w http.ResponseWriter, code int, body probeResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// StatusText returns the lower-case name of a status, e.g. "serving"
+func StatusText(status Status) string {
+	return strings.ToLower(status.String())
+}
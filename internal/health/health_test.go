@@ -0,0 +1,161 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestRegistry_OverallStatus(t *testing.T) {
+	registry := NewRegistry()
+
+	status, ok := registry.Status(OverallService)
+	assert.True(t, ok)
+	assert.Equal(t, StatusServing, status, "empty registry is serving")
+
+	registry.SetServingStatus("db", StatusServing)
+	registry.SetServingStatus("cache", StatusNotServing)
+	status, _ = registry.Status(OverallService)
+	assert.Equal(t, StatusNotServing, status)
+
+	registry.SetServingStatus("cache", StatusServing)
+	status, _ = registry.Status(OverallService)
+	assert.Equal(t, StatusServing, status)
+}
+
+func TestRegistry_UnknownService(t *testing.T) {
+	registry := NewRegistry()
+
+	status, ok := registry.Status("missing")
+
+	assert.False(t, ok)
+	assert.Equal(t, StatusServiceUnknown, status)
+}
+
+func TestRegistry_ShutdownAndResume(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetServingStatus("db", StatusServing)
+
+	registry.Shutdown()
+	status, _ := registry.Status("db")
+	assert.Equal(t, StatusNotServing, status)
+	overall, _ := registry.Status(OverallService)
+	assert.Equal(t, StatusNotServing, overall)
+
+	registry.Resume()
+	status, _ = registry.Status("db")
+	assert.Equal(t, StatusServing, status)
+}
+
+func TestRegistry_Watch(t *testing.T) {
+	registry := NewRegistry()
+	updates, cancel := registry.Watch("db")
+	defer cancel()
+
+	assert.Equal(t, StatusServiceUnknown, <-updates)
+
+	registry.SetServingStatus("db", StatusNotServing)
+	registry.SetServingStatus("db", StatusServing)
+	assert.Equal(t, StatusServing, <-updates, "only the latest status is kept")
+}
+
+func newHealthServer(t *testing.T, registry *Registry) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle(NewHandler(registry))
+	mux.Handle("/health", LivenessHandler())
+	mux.Handle("/ready", ReadinessHandler(registry))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestHandler_Check(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetServingStatus("api.v1.GrpcService", StatusServing)
+	server := newHealthServer(t, registry)
+	client := connect.NewClient[healthpb.HealthCheckRequest, healthpb.HealthCheckResponse](
+		server.Client(), server.URL+checkProcedure,
+	)
+
+	resp, err := client.CallUnary(context.Background(), connect.NewRequest(&healthpb.HealthCheckRequest{}))
+	require.NoError(t, err)
+	assert.Equal(t, StatusServing, resp.Msg.Status)
+
+	resp, err = client.CallUnary(context.Background(), connect.NewRequest(&healthpb.HealthCheckRequest{Service: "api.v1.GrpcService"}))
+	require.NoError(t, err)
+	assert.Equal(t, StatusServing, resp.Msg.Status)
+
+	_, err = client.CallUnary(context.Background(), connect.NewRequest(&healthpb.HealthCheckRequest{Service: "missing"}))
+	require.Error(t, err)
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+}
+
+func TestHandler_Watch(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetServingStatus("db", StatusServing)
+	server := newHealthServer(t, registry)
+	client := connect.NewClient[healthpb.HealthCheckRequest, healthpb.HealthCheckResponse](
+		server.Client(), server.URL+watchProcedure,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.CallServerStream(ctx, connect.NewRequest(&healthpb.HealthCheckRequest{Service: "db"}))
+	require.NoError(t, err)
+	defer func() {
+		// Cancel first: Close drains the stream, which never ends on its own
+		cancel()
+		_ = stream.Close()
+	}()
+
+	require.True(t, stream.Receive())
+	assert.Equal(t, StatusServing, stream.Msg().Status)
+
+	registry.SetServingStatus("db", StatusNotServing)
+	require.True(t, stream.Receive())
+	assert.Equal(t, StatusNotServing, stream.Msg().Status)
+}
+
+func TestProbeHandlers(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetServingStatus("db", StatusServing)
+	server := newHealthServer(t, registry)
+
+	get := func(path string) (int, probeResponse) {
+		resp, err := server.Client().Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		var body probeResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, body
+	}
+
+	code, body := get("/health")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "healthy", body.Status)
+
+	code, body = get("/ready")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", body.Status)
+	assert.Equal(t, "serving", body.Services["db"])
+
+	registry.SetServingStatus("db", StatusNotServing)
+	code, body = get("/ready")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not_ready", body.Status)
+	assert.Equal(t, "not_serving", body.Services["db"])
+
+	// A failing dependency or a drain must not get the pod restarted
+	registry.Shutdown()
+	code, body = get("/health")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "healthy", body.Status)
+}
@@ -0,0 +1,175 @@
+// Package health tracks the serving status of the service and its
+// dependencies, and exposes it through the standard grpc.health.v1 Health
+// service and the HTTP probe endpoints.
+package health
+
+import (
+	"sort"
+	"sync"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// Status is the serving status of a component
+type Status = healthpb.HealthCheckResponse_ServingStatus
+
+// Serving statuses, re-exported for callers that do not import healthpb
+const (
+	StatusUnknown        = healthpb.HealthCheckResponse_UNKNOWN
+	StatusServing        = healthpb.HealthCheckResponse_SERVING
+	StatusNotServing     = healthpb.HealthCheckResponse_NOT_SERVING
+	StatusServiceUnknown = healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+)
+
+// OverallService is the service name that reports the status of the whole
+// server, as used by grpc-health-probe when no -service is given
+const OverallService = ""
+
+// Registry holds the serving status of every registered component. The
+// overall status is SERVING only when every component is SERVING and the
+// registry has not been shut down.
+type Registry struct {
+	mu       sync.RWMutex
+	statuses map[string]Status
+	shutdown bool
+	watchers map[string]map[chan Status]struct{}
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		statuses: make(map[string]Status),
+		watchers: make(map[string]map[chan Status]struct{}),
+	}
+}
+
+// SetServingStatus records the status of a component and notifies watchers.
+// While the registry is shut down the update is stored but every component
+// keeps reporting NOT_SERVING.
+func (r *Registry) SetServingStatus(service string, status Status) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.statuses[service] = status
+	r.notifyLocked()
+}
+
+// Shutdown marks every component NOT_SERVING, e.g. when the server begins
+// draining. Later SetServingStatus calls do not undo it; Resume does.
+func (r *Registry) Shutdown() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.shutdown = true
+	r.notifyLocked()
+}
+
+// Resume reverses Shutdown and restores the last recorded statuses
+func (r *Registry) Resume() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.shutdown = false
+	r.notifyLocked()
+}
+
+// Status returns the status of a component, or the overall status for
+// OverallService. The boolean is false for unregistered components.
+func (r *Registry) Status(service string) (Status, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.statusLocked(service)
+}
+
+// Snapshot returns the status of every registered component
+func (r *Registry) Snapshot() map[string]Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshot := make(map[string]Status, len(r.statuses))
+	for service := range r.statuses {
+		snapshot[service], _ = r.statusLocked(service)
+	}
+	return snapshot
+}
+
+// Services returns the registered component names in sorted order
+func (r *Registry) Services() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	services := make([]string, 0, len(r.statuses))
+	for service := range r.statuses {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services
+}
+
+// Watch subscribes to status changes of a service. The returned channel
+// immediately receives the current status and then every change; only the
+// latest status is kept if the reader falls behind. Call the returned
+// function to unsubscribe.
+func (r *Registry) Watch(service string) (<-chan Status, func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ch := make(chan Status, 1)
+	if r.watchers[service] == nil {
+		r.watchers[service] = make(map[chan Status]struct{})
+	}
+	r.watchers[service][ch] = struct{}{}
+	ch <- r.watchStatusLocked(service)
+
+	return ch, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.watchers[service], ch)
+		if len(r.watchers[service]) == 0 {
+			delete(r.watchers, service)
+		}
+	}
+}
+
+func (r *Registry) statusLocked(service string) (Status, bool) {
+	if service == OverallService {
+		if r.shutdown {
+			return StatusNotServing, true
+		}
+		for _, status := range r.statuses {
+			if status != StatusServing {
+				return StatusNotServing, true
+			}
+		}
+		return StatusServing, true
+	}
+
+	status, ok := r.statuses[service]
+	if !ok {
+		return StatusServiceUnknown, false
+	}
+	if r.shutdown {
+		return StatusNotServing, true
+	}
+	return status, true
+}
+
+func (r *Registry) watchStatusLocked(service string) Status {
+	status, _ := r.statusLocked(service)
+	return status
+}
+
+func (r *Registry) notifyLocked() {
+	for service, watchers := range r.watchers {
+		status := r.watchStatusLocked(service)
+		for ch := range watchers {
+			// Replace any unread status with the latest one
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- status
+		}
+	}
+}
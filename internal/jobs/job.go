@@ -0,0 +1,154 @@
+// Package jobs runs ProcessData work asynchronously. A Manager queues
+// submitted jobs, runs them on a worker pool, records their progress and
+// outcome in a Store and drops finished jobs once their retention expires.
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned for jobs that do not exist or have expired
+	ErrNotFound = errors.New("job not found")
+	// ErrQueueFull is returned by Submit when the queue has no room left
+	ErrQueueFull = errors.New("job queue is full")
+	// ErrClosed is returned by Submit once the manager is closed
+	ErrClosed = errors.New("job manager is closed")
+
+	// errInvalidTransition reports a state change the state machine forbids
+	errInvalidTransition = errors.New("invalid job state transition")
+)
+
+// State is the lifecycle state of a job. Jobs start Pending, move to
+// Running when a worker picks them up and end Succeeded, Failed or
+// Cancelled. Pending jobs can also be cancelled directly, or fail when the
+// manager closes before they run.
+type State int
+
+// Job states
+const (
+	StatePending State = iota + 1
+	StateRunning
+	StateSucceeded
+	StateFailed
+	StateCancelled
+)
+
+// String returns the lower case state name, as used in metric labels
+func (s State) String() string {
+	switch s {
+	case StatePending:
+		return "pending"
+	case StateRunning:
+		return "running"
+	case StateSucceeded:
+		return "succeeded"
+	case StateFailed:
+		return "failed"
+	case StateCancelled:
+		return "cancelled"
+	default:
+		return "unknown"
+	}
+}
+
+// Done reports whether s is a final state
+func (s State) Done() bool {
+	return s == StateSucceeded || s == StateFailed || s == StateCancelled
+}
+
+// canTransition reports whether a job may move from one state to another
+func canTransition(from, to State) bool {
+	switch from {
+	case StatePending:
+		return to == StateRunning || to == StateCancelled || to == StateFailed
+	case StateRunning:
+		return to.Done()
+	default:
+		return false
+	}
+}
+
+// Job is a unit of asynchronous work and its outcome
+type Job struct {
+	ID string
+	// Owner identifies the caller that submitted the job; it is empty when
+	// callers are not authenticated
+	Owner   string
+	State   State
+	Data    string
+	Options map[string]string
+	// Progress is the completed percentage, from 0 to 100
+	Progress int32
+
+	// Output is set when the job succeeded
+	Output *Output
+	// Error is set when the job failed or was cancelled
+	Error *Error
+
+	CreateTime time.Time
+	StartTime  time.Time
+	EndTime    time.Time
+}
+
+// Output is the result of a successful job, as ProcessData would return it
+type Output struct {
+	Result       string
+	Success      bool
+	ErrorMessage string
+	// ErrorReason is the ErrorInfo reason of a rejected input
+	ErrorReason string
+	Seed        string
+}
+
+// Error describes why a job failed
+type Error struct {
+	// Code is the connect code name ProcessData would have failed with
+	Code string
+	// Reason is the ErrorInfo reason of the failure
+	Reason  string
+	Message string
+}
+
+// Clone returns a deep copy of j
+func (j *Job) Clone() *Job {
+	c := *j
+	if j.Options != nil {
+		c.Options = make(map[string]string, len(j.Options))
+		for k, v := range j.Options {
+			c.Options[k] = v
+		}
+	}
+	if j.Output != nil {
+		output := *j.Output
+		c.Output = &output
+	}
+	if j.Error != nil {
+		jobErr := *j.Error
+		c.Error = &jobErr
+	}
+	return &c
+}
+
+// Store keeps jobs. Implementations must be safe for concurrent use and
+// must not retain or hand out the *Job values passed to and returned from
+// them; the Manager serializes updates to a job.
+type Store interface {
+	// Create adds a new job
+	Create(ctx context.Context, job *Job) error
+	// Get returns the job with id, or ErrNotFound
+	Get(ctx context.Context, id string) (*Job, error)
+	// Update replaces a stored job, or returns ErrNotFound
+	Update(ctx context.Context, job *Job) error
+	// List returns up to limit jobs of owner with IDs greater than afterID,
+	// ordered by ID
+	List(ctx context.Context, owner, afterID string, limit int) ([]*Job, error)
+	// ListUnfinished returns up to limit pending or running jobs of any
+	// owner with IDs greater than afterID, ordered by ID
+	ListUnfinished(ctx context.Context, afterID string, limit int) ([]*Job, error)
+	// DeleteFinishedBefore removes finished jobs that ended before t and
+	// returns how many were removed
+	DeleteFinishedBefore(ctx context.Context, t time.Time) (int, error)
+}
@@ -0,0 +1,398 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/clock"
+)
+
+var start = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// echo succeeds with the job's data after reporting progress twice
+func echo(_ context.Context, job *Job, progress func(int32)) (*Output, error) {
+	progress(50)
+	progress(100)
+	return &Output{Result: job.Data, Success: true}, nil
+}
+
+// blocking runs until release is closed or the job is canceled
+func blocking(release <-chan struct{}) RunFunc {
+	return func(ctx context.Context, job *Job, _ func(int32)) (*Output, error) {
+		select {
+		case <-release:
+			return &Output{Result: job.Data, Success: true}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func newManager(t *testing.T, run RunFunc, opts ...Option) *Manager {
+	m := NewManager(NewMemoryStore(), run, append([]Option{WithClock(clock.NewFake(start))}, opts...)...)
+	t.Cleanup(func() { closeNow(m) })
+	return m
+}
+
+// closeNow closes m, canceling jobs that are still running
+func closeNow(m *Manager) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = m.Close(ctx)
+}
+
+func wait(t *testing.T, m *Manager, id string) *Job {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	job, err := m.Wait(ctx, id)
+	require.NoError(t, err)
+	return job
+}
+
+// waitRunning waits until the job with id is running
+func waitRunning(t *testing.T, m *Manager, id string) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		job, err := m.Get(context.Background(), id)
+		return err == nil && job.State == StateRunning
+	}, 5*time.Second, time.Millisecond)
+}
+
+func TestCanTransition(t *testing.T) {
+	assert.True(t, canTransition(StatePending, StateRunning))
+	assert.True(t, canTransition(StatePending, StateCancelled))
+	assert.True(t, canTransition(StateRunning, StateSucceeded))
+	assert.True(t, canTransition(StateRunning, StateFailed))
+	assert.False(t, canTransition(StatePending, StateSucceeded))
+	assert.False(t, canTransition(StateRunning, StatePending))
+	assert.False(t, canTransition(StateSucceeded, StateCancelled))
+	assert.False(t, canTransition(StateCancelled, StateRunning))
+}
+
+func TestManager_Submit(t *testing.T) {
+	m := newManager(t, echo)
+
+	job, err := m.Submit(context.Background(), "jwt:alice", "hello", map[string]string{"processor": "uppercase"})
+	require.NoError(t, err)
+	assert.Equal(t, StatePending, job.State)
+	assert.Equal(t, "jwt:alice", job.Owner)
+	assert.Equal(t, start, job.CreateTime)
+
+	done := wait(t, m, job.ID)
+	assert.Equal(t, StateSucceeded, done.State)
+	assert.Equal(t, &Output{Result: "hello", Success: true}, done.Output)
+	assert.Nil(t, done.Error)
+	assert.Equal(t, int32(100), done.Progress)
+	assert.Equal(t, map[string]string{"processor": "uppercase"}, done.Options)
+	assert.Equal(t, start, done.StartTime)
+	assert.Equal(t, start, done.EndTime)
+}
+
+func TestManager_Failed(t *testing.T) {
+	m := newManager(t, func(context.Context, *Job, func(int32)) (*Output, error) {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("unknown processor"))
+	})
+
+	job, err := m.Submit(context.Background(), "", "x", nil)
+	require.NoError(t, err)
+
+	done := wait(t, m, job.ID)
+	assert.Equal(t, StateFailed, done.State)
+	assert.Equal(t, &Error{Code: "invalid_argument", Message: "unknown processor"}, done.Error)
+	assert.Nil(t, done.Output)
+}
+
+func TestManager_Panic(t *testing.T) {
+	m := newManager(t, func(ctx context.Context, job *Job, progress func(int32)) (*Output, error) {
+		if job.Data == "panic" {
+			panic("boom")
+		}
+		return echo(ctx, job, progress)
+	}, WithWorkers(1))
+
+	job, err := m.Submit(context.Background(), "", "panic", nil)
+	require.NoError(t, err)
+
+	done := wait(t, m, job.ID)
+	assert.Equal(t, StateFailed, done.State)
+	assert.Equal(t, &Error{Code: "internal", Reason: "INTERNAL", Message: "internal error"}, done.Error)
+
+	// The worker survives to run the next job
+	next, err := m.Submit(context.Background(), "", "x", nil)
+	require.NoError(t, err)
+	assert.Equal(t, StateSucceeded, wait(t, m, next.ID).State)
+}
+
+// slowStore blocks updates of jobs with data "slow" until release is closed
+type slowStore struct {
+	*MemoryStore
+	release chan struct{}
+}
+
+func (s *slowStore) Update(ctx context.Context, job *Job) error {
+	if job.Data == "slow" {
+		<-s.release
+	}
+	return s.MemoryStore.Update(ctx, job)
+}
+
+func TestManager_SlowStore(t *testing.T) {
+	store := &slowStore{MemoryStore: NewMemoryStore(), release: make(chan struct{})}
+	m := NewManager(store, echo, WithClock(clock.NewFake(start)))
+	t.Cleanup(func() { closeNow(m) })
+
+	slow, err := m.Submit(context.Background(), "", "slow", nil)
+	require.NoError(t, err)
+
+	// Other jobs are submitted and run while the slow job's update blocks
+	fast, err := m.Submit(context.Background(), "", "fast", nil)
+	require.NoError(t, err)
+	assert.Equal(t, StateSucceeded, wait(t, m, fast.ID).State)
+
+	close(store.release)
+	assert.Equal(t, StateSucceeded, wait(t, m, slow.ID).State)
+}
+
+// failStartStore fails every update that starts a job
+type failStartStore struct {
+	*MemoryStore
+	failed chan string
+}
+
+func (s *failStartStore) Update(ctx context.Context, job *Job) error {
+	if job.State == StateRunning {
+		s.failed <- job.ID
+		return errors.New("disk full")
+	}
+	return s.MemoryStore.Update(ctx, job)
+}
+
+func TestManager_StartUpdateFails(t *testing.T) {
+	store := &failStartStore{MemoryStore: NewMemoryStore(), failed: make(chan string, 1)}
+	m := NewManager(store, echo, WithClock(clock.NewFake(start)))
+	t.Cleanup(func() { closeNow(m) })
+
+	job, err := m.Submit(context.Background(), "", "x", nil)
+	require.NoError(t, err)
+	assert.Equal(t, job.ID, <-store.failed)
+
+	assert.Eventually(t, func() bool {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return len(m.running) == 0
+	}, time.Second, time.Millisecond, "a job that failed to start is not tracked as running")
+	stored, err := m.Get(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatePending, stored.State)
+}
+
+func TestManager_InvalidTransition(t *testing.T) {
+	m := newManager(t, echo)
+	job := &Job{ID: "a", State: StateSucceeded, Output: &Output{Result: "x", Success: true}}
+
+	err := m.endLocked(job, StateCancelled, nil, cancelledError())
+
+	assert.ErrorIs(t, err, errInvalidTransition)
+	assert.Equal(t, StateSucceeded, job.State, "the job is left alone")
+	assert.Nil(t, job.Error)
+}
+
+func TestManager_CancelPending(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	m := newManager(t, blocking(release), WithWorkers(1))
+
+	first, err := m.Submit(context.Background(), "", "first", nil)
+	require.NoError(t, err)
+	waitRunning(t, m, first.ID)
+	second, err := m.Submit(context.Background(), "", "second", nil)
+	require.NoError(t, err)
+
+	cancelled, err := m.Cancel(context.Background(), second.ID)
+
+	require.NoError(t, err)
+	assert.Equal(t, StateCancelled, cancelled.State)
+	assert.Equal(t, "canceled", cancelled.Error.Code)
+	assert.True(t, cancelled.StartTime.IsZero(), "a cancelled pending job never starts")
+}
+
+func TestManager_CancelRunning(t *testing.T) {
+	m := newManager(t, blocking(make(chan struct{})))
+	job, err := m.Submit(context.Background(), "", "x", nil)
+	require.NoError(t, err)
+	waitRunning(t, m, job.ID)
+
+	_, err = m.Cancel(context.Background(), job.ID)
+	require.NoError(t, err)
+
+	done := wait(t, m, job.ID)
+	assert.Equal(t, StateCancelled, done.State)
+	assert.Equal(t, "canceled", done.Error.Code)
+
+	// Cancelling a finished job changes nothing
+	again, err := m.Cancel(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, done, again)
+}
+
+func TestManager_QueueFull(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	m := newManager(t, blocking(release), WithWorkers(1), WithQueueSize(1))
+
+	first, err := m.Submit(context.Background(), "", "running", nil)
+	require.NoError(t, err)
+	waitRunning(t, m, first.ID)
+	_, err = m.Submit(context.Background(), "", "queued", nil)
+	require.NoError(t, err)
+
+	_, err = m.Submit(context.Background(), "", "rejected", nil)
+
+	assert.ErrorIs(t, err, ErrQueueFull)
+}
+
+func TestManager_WaitTimeout(t *testing.T) {
+	m := newManager(t, blocking(make(chan struct{})))
+	job, err := m.Submit(context.Background(), "", "x", nil)
+	require.NoError(t, err)
+	waitRunning(t, m, job.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	current, err := m.Wait(ctx, job.ID)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, StateRunning, current.State)
+}
+
+func TestManager_NotFound(t *testing.T) {
+	m := newManager(t, echo)
+
+	_, err := m.Get(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = m.Wait(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = m.Cancel(context.Background(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestManager_List(t *testing.T) {
+	m := newManager(t, echo)
+	var ids []string
+	for _, data := range []string{"a", "b", "c"} {
+		job, err := m.Submit(context.Background(), "", data, nil)
+		require.NoError(t, err)
+		ids = append(ids, job.ID)
+	}
+
+	_, err := m.Submit(context.Background(), "jwt:bob", "other", nil)
+	require.NoError(t, err)
+
+	page, err := m.List(context.Background(), "", "", 2)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, ids[:2], []string{page[0].ID, page[1].ID})
+
+	page, err = m.List(context.Background(), "", page[1].ID, 2)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, ids[2], page[0].ID)
+}
+
+func TestManager_Retention(t *testing.T) {
+	fake := clock.NewFake(start)
+	store := NewMemoryStore()
+	m := NewManager(store, echo, WithClock(fake), WithRetention(time.Hour))
+	t.Cleanup(func() { closeNow(m) })
+
+	job, err := m.Submit(context.Background(), "", "x", nil)
+	require.NoError(t, err)
+	wait(t, m, job.ID)
+
+	// The first sweep after a minute keeps the job
+	fake.BlockUntil(1)
+	fake.Advance(time.Minute)
+	fake.BlockUntil(1)
+	_, err = m.Get(context.Background(), job.ID)
+	require.NoError(t, err)
+
+	fake.Advance(time.Hour)
+	require.Eventually(t, func() bool { return store.Len() == 0 }, 5*time.Second, time.Millisecond)
+	_, err = m.Get(context.Background(), job.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestManager_Close(t *testing.T) {
+	m := NewManager(NewMemoryStore(), blocking(make(chan struct{})), WithWorkers(1))
+	running, err := m.Submit(context.Background(), "", "running", nil)
+	require.NoError(t, err)
+	waitRunning(t, m, running.ID)
+	queued, err := m.Submit(context.Background(), "", "queued", nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = m.Close(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	for _, id := range []string{running.ID, queued.ID} {
+		job, err := m.Get(context.Background(), id)
+		require.NoError(t, err)
+		assert.Equal(t, StateFailed, job.State)
+		assert.Equal(t, "unavailable", job.Error.Code)
+	}
+	_, err = m.Submit(context.Background(), "", "late", nil)
+	assert.ErrorIs(t, err, ErrClosed)
+}
+
+func TestManager_Recover(t *testing.T) {
+	store := NewMemoryStore()
+	for _, job := range []*Job{
+		{ID: "a", State: StatePending, CreateTime: start},
+		{ID: "b", State: StateRunning, CreateTime: start, StartTime: start},
+		{ID: "c", State: StateSucceeded, Output: &Output{Result: "done"}, CreateTime: start, EndTime: start},
+	} {
+		require.NoError(t, store.Create(context.Background(), job))
+	}
+	m := NewManager(store, echo, WithClock(clock.NewFake(start.Add(time.Hour))))
+	t.Cleanup(func() { closeNow(m) })
+
+	recovered, err := m.Recover(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, recovered)
+	for _, id := range []string{"a", "b"} {
+		job, err := m.Get(context.Background(), id)
+		require.NoError(t, err)
+		assert.Equal(t, StateFailed, job.State)
+		assert.Equal(t, "unavailable", job.Error.Code)
+		assert.Equal(t, start.Add(time.Hour), job.EndTime)
+	}
+	job, err := m.Get(context.Background(), "c")
+	require.NoError(t, err)
+	assert.Equal(t, StateSucceeded, job.State)
+}
+
+func TestManager_Metrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m := newManager(t, echo, WithRegisterer(reg))
+
+	job, err := m.Submit(context.Background(), "", "x", nil)
+	require.NoError(t, err)
+	wait(t, m, job.ID)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.submittedTotal))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.finishedTotal.WithLabelValues("succeeded")))
+	count, err := testutil.GatherAndCount(reg, "jobs_queued", "jobs_running", "jobs_stored", "jobs_run_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 4, count)
+}
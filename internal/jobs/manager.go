@@ -0,0 +1,593 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/clock"
+)
+
+// maxSweepInterval is the longest time between deletions of expired jobs
+const maxSweepInterval = time.Minute
+
+// recoverPageSize is how many jobs Recover reads at a time
+const recoverPageSize = 100
+
+// RunFunc does the work of a job. It should return promptly once ctx is
+// canceled and may call progress with the completed percentage. A RunFunc
+// that panics fails its job as INTERNAL.
+type RunFunc func(ctx context.Context, job *Job, progress func(percent int32)) (*Output, error)
+
+// Manager queues jobs and runs them on a pool of workers. Workers start
+// with the first submitted job.
+type Manager struct {
+	store     Store
+	run       RunFunc
+	clock     clock.Clock
+	logger    *logrus.Logger
+	workers   int
+	retention time.Duration
+	registry  prometheus.Registerer
+	metrics   *metrics
+
+	queue chan string
+	// ctx is the parent of every job's context; Close cancels it when the
+	// jobs do not finish in time
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   chan struct{}
+	wg     sync.WaitGroup
+
+	// mu guards the fields below. It is never held during store IO, so a
+	// slow store only holds up calls for the job being read or written.
+	mu       sync.Mutex
+	started  bool
+	closed   bool
+	sequence uint32
+	// reserved counts queue slots held by Submit calls storing their job
+	reserved int
+	running  map[string]*runningJob
+	watchers map[string]*watcher
+	locks    map[string]*jobLock
+}
+
+type runningJob struct {
+	cancel    context.CancelFunc
+	cancelled bool
+}
+
+// watcher wakes up the Wait calls of a job on its next change
+type watcher struct {
+	changed chan struct{}
+	waiters int
+}
+
+// jobLock serializes the updates of one job
+type jobLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// Option configures a Manager
+type Option func(*Manager)
+
+// WithWorkers sets how many jobs run at once; the default is 4
+func WithWorkers(n int) Option {
+	return func(m *Manager) {
+		m.workers = n
+	}
+}
+
+// WithQueueSize sets how many jobs may wait for a worker before Submit
+// returns ErrQueueFull; the default is 100
+func WithQueueSize(n int) Option {
+	return func(m *Manager) {
+		m.queue = make(chan string, n)
+	}
+}
+
+// WithRetention sets how long finished jobs are kept; the default is an hour
+func WithRetention(d time.Duration) Option {
+	return func(m *Manager) {
+		m.retention = d
+	}
+}
+
+// WithClock sets the clock used for timestamps and expiry
+func WithClock(c clock.Clock) Option {
+	return func(m *Manager) {
+		m.clock = c
+	}
+}
+
+// WithLogger sets the logger for store failures and expiry
+func WithLogger(logger *logrus.Logger) Option {
+	return func(m *Manager) {
+		m.logger = logger
+	}
+}
+
+// WithRegisterer registers the job metrics with reg
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(m *Manager) {
+		m.registry = reg
+	}
+}
+
+// NewManager creates a manager running jobs with run and keeping them in
+// store
+func NewManager(store Store, run RunFunc, opts ...Option) *Manager {
+	m := &Manager{
+		store:     store,
+		run:       run,
+		clock:     clock.Real(),
+		logger:    logrus.StandardLogger(),
+		workers:   4,
+		retention: time.Hour,
+		queue:     make(chan string, 100),
+		stop:      make(chan struct{}),
+		running:   make(map[string]*runningJob),
+		watchers:  make(map[string]*watcher),
+		locks:     make(map[string]*jobLock),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	if m.registry != nil {
+		m.metrics = newMetrics(m.registry, m)
+	}
+	return m
+}
+
+// Submit queues a job for owner processing data with options and returns it
+// in the pending state
+func (m *Manager) Submit(ctx context.Context, owner, data string, options map[string]string) (*Job, error) {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil, ErrClosed
+	}
+	// Hold a queue slot while the job is stored so the send below never
+	// blocks
+	if len(m.queue)+m.reserved >= cap(m.queue) {
+		m.mu.Unlock()
+		return nil, ErrQueueFull
+	}
+	m.reserved++
+	m.startLocked()
+
+	now := m.clock.Now()
+	m.sequence++
+	job := &Job{
+		// IDs sort in submission order
+		ID:         fmt.Sprintf("%016x%08x", now.UnixNano(), m.sequence),
+		Owner:      owner,
+		State:      StatePending,
+		Data:       data,
+		Options:    options,
+		CreateTime: now,
+	}
+	m.mu.Unlock()
+
+	err := m.store.Create(ctx, job)
+
+	m.mu.Lock()
+	m.reserved--
+	if err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	if m.closed {
+		// Close ran while the job was stored, so it will never run
+		m.mu.Unlock()
+		m.abandon(job.ID)
+		return nil, ErrClosed
+	}
+	m.queue <- job.ID
+	m.mu.Unlock()
+	m.metrics.submitted()
+	return job.Clone(), nil
+}
+
+// Get returns the job with id
+func (m *Manager) Get(ctx context.Context, id string) (*Job, error) {
+	return m.store.Get(ctx, id)
+}
+
+// List returns up to limit jobs of owner submitted after the job with
+// afterID
+func (m *Manager) List(ctx context.Context, owner, afterID string, limit int) ([]*Job, error) {
+	return m.store.List(ctx, owner, afterID, limit)
+}
+
+// Cancel cancels a job. Pending jobs are cancelled at once; running jobs
+// have their context canceled and are cancelled once their RunFunc returns,
+// so the returned job may still be running. Finished jobs are returned
+// unchanged.
+func (m *Manager) Cancel(ctx context.Context, id string) (*Job, error) {
+	job, _, err := m.update(ctx, id, func(job *Job) (bool, error) {
+		switch job.State {
+		case StatePending:
+			return true, m.endLocked(job, StateCancelled, nil, cancelledError())
+		case StateRunning:
+			if r, ok := m.running[id]; ok {
+				r.cancelled = true
+				r.cancel()
+			}
+		}
+		return false, nil
+	})
+	return job, err
+}
+
+// Wait returns the job once it has finished. If ctx ends first it returns
+// the job as it is then, with the context's error.
+func (m *Manager) Wait(ctx context.Context, id string) (*Job, error) {
+	for {
+		changed, unwatch := m.watch(id)
+		// Read the job after watching it, so a change in between still
+		// wakes this call up
+		job, err := m.store.Get(ctx, id)
+		if err != nil || job.State.Done() {
+			unwatch()
+			return job, err
+		}
+
+		select {
+		case <-changed:
+			unwatch()
+		case <-ctx.Done():
+			unwatch()
+			return job, ctx.Err()
+		}
+	}
+}
+
+// watch returns a channel closed on the next change to the job with id and
+// a function to call once done with it
+func (m *Manager) watch(id string) (<-chan struct{}, func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	w, ok := m.watchers[id]
+	if !ok {
+		w = &watcher{changed: make(chan struct{})}
+		m.watchers[id] = w
+	}
+	w.waiters++
+	return w.changed, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if w.waiters--; w.waiters == 0 && m.watchers[id] == w {
+			delete(m.watchers, id)
+		}
+	}
+}
+
+// Recover fails the jobs a previous process left pending or running in a
+// persistent store, as they will never finish. Call it before the first
+// Submit; it returns how many jobs were failed.
+func (m *Manager) Recover(ctx context.Context) (int, error) {
+	recovered := 0
+	afterID := ""
+	for {
+		page, err := m.store.ListUnfinished(ctx, afterID, recoverPageSize)
+		if err != nil {
+			return recovered, err
+		}
+		for _, job := range page {
+			if job.State.Done() {
+				continue
+			}
+			_, failed, err := m.update(ctx, job.ID, func(job *Job) (bool, error) {
+				if job.State.Done() {
+					return false, nil
+				}
+				return true, m.endLocked(job, StateFailed, nil, restartError())
+			})
+			if err != nil {
+				return recovered, err
+			}
+			if failed {
+				recovered++
+			}
+		}
+		if len(page) < recoverPageSize {
+			return recovered, nil
+		}
+		afterID = page[len(page)-1].ID
+	}
+}
+
+// Close stops taking jobs and waits for running jobs to finish. When ctx
+// ends first, running jobs are canceled and fail as UNAVAILABLE. Jobs still
+// waiting in the queue fail the same way without running.
+func (m *Manager) Close(ctx context.Context) error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	close(m.stop)
+	close(m.queue)
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		m.cancel()
+		return nil
+	case <-ctx.Done():
+		m.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// startLocked starts the workers and the expiry loop on first use
+func (m *Manager) startLocked() {
+	if m.started {
+		return
+	}
+	m.started = true
+	for i := 0; i < m.workers; i++ {
+		m.wg.Add(1)
+		go m.work()
+	}
+	m.wg.Add(1)
+	go m.expire()
+}
+
+func (m *Manager) work() {
+	defer m.wg.Done()
+	for id := range m.queue {
+		select {
+		case <-m.stop:
+			m.abandon(id)
+		default:
+			m.runJob(id)
+		}
+	}
+}
+
+// runJob runs a pending job and records its outcome
+func (m *Manager) runJob(id string) {
+	ctx, cancel := context.WithCancel(m.ctx)
+	defer cancel()
+
+	job, started, err := m.update(context.Background(), id, func(job *Job) (bool, error) {
+		if !canTransition(job.State, StateRunning) {
+			return false, nil
+		}
+		job.State = StateRunning
+		job.StartTime = m.clock.Now()
+		m.running[id] = &runningJob{cancel: cancel}
+		return true, nil
+	})
+	if err != nil {
+		// The job never started, so it must not look cancellable as running
+		m.mu.Lock()
+		delete(m.running, id)
+		m.mu.Unlock()
+		m.logger.WithError(err).WithField("job", id).Error("Failed to start job")
+		return
+	}
+	if !started {
+		// Cancelled while it was queued
+		return
+	}
+
+	progress := func(percent int32) {
+		_, _, err := m.update(context.Background(), id, func(job *Job) (bool, error) {
+			if job.State != StateRunning || percent <= job.Progress {
+				return false, nil
+			}
+			job.Progress = min(percent, 100)
+			return true, nil
+		})
+		if err != nil {
+			m.logger.WithError(err).WithField("job", id).Warn("Failed to record job progress")
+		}
+	}
+	output, runErr := m.call(ctx, job, progress)
+
+	_, _, err = m.update(context.Background(), id, func(job *Job) (bool, error) {
+		r := m.running[id]
+		delete(m.running, id)
+		switch {
+		case runErr == nil:
+			job.Progress = 100
+			return true, m.endLocked(job, StateSucceeded, output, nil)
+		case r != nil && r.cancelled:
+			return true, m.endLocked(job, StateCancelled, nil, cancelledError())
+		case m.ctx.Err() != nil:
+			return true, m.endLocked(job, StateFailed, nil, shutdownError())
+		default:
+			return true, m.endLocked(job, StateFailed, nil, runError(runErr))
+		}
+	})
+	if err != nil {
+		m.logger.WithError(err).WithField("job", id).Error("Failed to record job result")
+	}
+}
+
+// call runs a job's RunFunc, recovering a panic as an INTERNAL error: jobs
+// run on the manager's workers, outside any handler's recovery
+func (m *Manager) call(ctx context.Context, job *Job, progress func(int32)) (output *Output, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			m.logger.WithFields(logrus.Fields{
+				"job":   job.ID,
+				"panic": fmt.Sprint(r),
+				"stack": string(debug.Stack()),
+			}).Error("Recovered panic in job")
+			output, err = nil, connect.NewError(connect.CodeInternal, errors.New("internal error"))
+		}
+	}()
+	return m.run(ctx, job, progress)
+}
+
+// abandon fails a job that was still queued when the manager closed
+func (m *Manager) abandon(id string) {
+	_, _, err := m.update(context.Background(), id, func(job *Job) (bool, error) {
+		if job.State != StatePending {
+			return false, nil
+		}
+		return true, m.endLocked(job, StateFailed, nil, shutdownError())
+	})
+	if err != nil {
+		m.logger.WithError(err).WithField("job", id).Error("Failed to record abandoned job")
+	}
+}
+
+// expire deletes finished jobs older than the retention period
+func (m *Manager) expire() {
+	defer m.wg.Done()
+	interval := min(m.retention, maxSweepInterval)
+	for {
+		select {
+		case <-m.clock.After(interval):
+		case <-m.stop:
+			return
+		}
+		deleted, err := m.store.DeleteFinishedBefore(context.Background(), m.clock.Now().Add(-m.retention))
+		if err != nil {
+			m.logger.WithError(err).Error("Failed to delete expired jobs")
+			continue
+		}
+		if deleted > 0 {
+			m.logger.WithField("deleted", deleted).Debug("Deleted expired jobs")
+		}
+	}
+}
+
+// update applies fn to the stored job with id and saves it if fn reports a
+// change, waking up Wait calls for the job. When fn fails the job is left
+// as stored. Updates of one job run one at a time; fn runs with m.mu held,
+// the store IO around it without.
+func (m *Manager) update(ctx context.Context, id string, fn func(*Job) (bool, error)) (*Job, bool, error) {
+	unlock := m.lockJob(id)
+	defer unlock()
+
+	job, err := m.store.Get(ctx, id)
+	if err != nil {
+		return nil, false, err
+	}
+	m.mu.Lock()
+	changed, err := fn(job)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, false, err
+	}
+	if !changed {
+		return job, false, nil
+	}
+	if err := m.store.Update(ctx, job); err != nil {
+		return nil, false, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if w, ok := m.watchers[id]; ok {
+		close(w.changed)
+		delete(m.watchers, id)
+	}
+	return job, true, nil
+}
+
+// lockJob locks the job with id for an update and returns the function
+// that unlocks it
+func (m *Manager) lockJob(id string) func() {
+	m.mu.Lock()
+	l, ok := m.locks[id]
+	if !ok {
+		l = &jobLock{}
+		m.locks[id] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if l.refs--; l.refs == 0 {
+			delete(m.locks, id)
+		}
+	}
+}
+
+// endLocked moves a job to a final state. An invalid transition is a bug in
+// the manager; it is returned as an error rather than a panic, since this
+// runs on worker goroutines where a panic would stop the whole process.
+func (m *Manager) endLocked(job *Job, state State, output *Output, jobErr *Error) error {
+	if !canTransition(job.State, state) {
+		return fmt.Errorf("%w from %s to %s", errInvalidTransition, job.State, state)
+	}
+	job.State = state
+	job.Output = output
+	job.Error = jobErr
+	job.EndTime = m.clock.Now()
+	m.metrics.finished(job)
+	return nil
+}
+
+// ErrorInfo reasons of the failures the manager reports itself. They are the
+// ones apierror publishes, which cannot be imported here as apierror imports
+// this package.
+const (
+	reasonCanceled     = "CANCELED"
+	reasonShuttingDown = "SHUTTING_DOWN"
+	reasonInternal     = "INTERNAL"
+)
+
+func cancelledError() *Error {
+	return &Error{Code: connect.CodeCanceled.String(), Reason: reasonCanceled, Message: "job was cancelled"}
+}
+
+func shutdownError() *Error {
+	return &Error{Code: connect.CodeUnavailable.String(), Reason: reasonShuttingDown, Message: "server shut down before the job finished"}
+}
+
+func restartError() *Error {
+	return &Error{Code: connect.CodeUnavailable.String(), Reason: reasonShuttingDown, Message: "server restarted before the job finished"}
+}
+
+// runError describes the error a RunFunc failed with, keeping the reason of
+// its ErrorInfo detail. Internal errors without one, such as panics, are
+// INTERNAL.
+func runError(err error) *Error {
+	code := connect.CodeOf(err)
+	jobErr := &Error{Code: code.String(), Message: err.Error()}
+	if code == connect.CodeInternal || code == connect.CodeUnknown {
+		jobErr.Reason = reasonInternal
+	}
+	var connectErr *connect.Error
+	if !errors.As(err, &connectErr) {
+		return jobErr
+	}
+	jobErr.Message = connectErr.Message()
+	for _, detail := range connectErr.Details() {
+		if value, valueErr := detail.Value(); valueErr == nil {
+			if info, ok := value.(*errdetails.ErrorInfo); ok && info.Reason != "" {
+				jobErr.Reason = info.Reason
+			}
+		}
+	}
+	return jobErr
+}
@@ -0,0 +1,106 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps jobs in process memory, so they are lost on restart
+type MemoryStore struct {
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[string]*Job)}
+}
+
+// Create implements Store
+func (s *MemoryStore) Create(_ context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[job.ID]; ok {
+		return fmt.Errorf("job %q already exists", job.ID)
+	}
+	s.jobs[job.ID] = job.Clone()
+	return nil
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(_ context.Context, id string) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return job.Clone(), nil
+}
+
+// Update implements Store
+func (s *MemoryStore) Update(_ context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.jobs[job.ID]; !ok {
+		return ErrNotFound
+	}
+	s.jobs[job.ID] = job.Clone()
+	return nil
+}
+
+// List implements Store
+func (s *MemoryStore) List(_ context.Context, owner, afterID string, limit int) ([]*Job, error) {
+	return s.list(afterID, limit, func(job *Job) bool { return job.Owner == owner }), nil
+}
+
+// ListUnfinished implements Store
+func (s *MemoryStore) ListUnfinished(_ context.Context, afterID string, limit int) ([]*Job, error) {
+	return s.list(afterID, limit, func(job *Job) bool { return !job.State.Done() }), nil
+}
+
+// list returns up to limit jobs selected by keep with IDs greater than
+// afterID, ordered by ID
+func (s *MemoryStore) list(afterID string, limit int, keep func(*Job) bool) []*Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ids := make([]string, 0, len(s.jobs))
+	for id, job := range s.jobs {
+		if id > afterID && keep(job) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	jobs := make([]*Job, len(ids))
+	for i, id := range ids {
+		jobs[i] = s.jobs[id].Clone()
+	}
+	return jobs
+}
+
+// DeleteFinishedBefore implements Store
+func (s *MemoryStore) DeleteFinishedBefore(_ context.Context, t time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deleted := 0
+	for id, job := range s.jobs {
+		if job.State.Done() && job.EndTime.Before(t) {
+			delete(s.jobs, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// Len returns the number of stored jobs
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.jobs)
+}
@@ -0,0 +1,67 @@
+package jobs
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// metrics are the job metrics; a nil *metrics records nothing
+type metrics struct {
+	submittedTotal prometheus.Counter
+	finishedTotal  *prometheus.CounterVec
+	runDuration    *prometheus.HistogramVec
+}
+
+func newMetrics(reg prometheus.Registerer, m *Manager) *metrics {
+	jm := &metrics{
+		submittedTotal: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "jobs_submitted_total",
+			Help: "Jobs submitted.",
+		}),
+		finishedTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "jobs_finished_total",
+			Help: "Jobs that reached a final state, by state.",
+		}, []string{"state"}),
+		runDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "jobs_run_duration_seconds",
+			Help:    "Time from a job starting to run until it finished, by final state.",
+			Buckets: []float64{0.01, 0.1, 1, 10, 60, 300, 900, 3600},
+		}, []string{"state"}),
+	}
+	reg.MustRegister(jm.submittedTotal, jm.finishedTotal, jm.runDuration)
+	reg.MustRegister(
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "jobs_queued",
+			Help: "Jobs waiting for a worker.",
+		}, func() float64 { return float64(len(m.queue)) }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "jobs_running",
+			Help: "Jobs currently running.",
+		}, func() float64 {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			return float64(len(m.running))
+		}),
+	)
+	if sizer, ok := m.store.(interface{ Len() int }); ok {
+		reg.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "jobs_stored",
+			Help: "Jobs kept in the job store, including finished jobs not yet expired.",
+		}, func() float64 { return float64(sizer.Len()) }))
+	}
+	return jm
+}
+
+func (jm *metrics) submitted() {
+	if jm != nil {
+		jm.submittedTotal.Inc()
+	}
+}
+
+func (jm *metrics) finished(job *Job) {
+	if jm == nil {
+		return
+	}
+	state := job.State.String()
+	jm.finishedTotal.WithLabelValues(state).Inc()
+	if !job.StartTime.IsZero() {
+		jm.runDuration.WithLabelValues(state).Observe(job.EndTime.Sub(job.StartTime).Seconds())
+	}
+}
@@ -0,0 +1,114 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Special fields recognized by Cloud Logging in structured JSON logs
+const (
+	cloudTraceKey        = "logging.googleapis.com/trace"
+	cloudSpanIDKey       = "logging.googleapis.com/spanId"
+	cloudTraceSampledKey = "logging.googleapis.com/trace_sampled"
+)
+
+// HTTPRequest describes a request in the shape of Cloud Logging's
+// httpRequest field. Formatters other than CloudFormatter write it as JSON
+// with the same keys.
+type HTTPRequest struct {
+	RequestMethod string
+	RequestURL    string
+	Status        int
+	RequestSize   int64
+	ResponseSize  int64
+	RemoteIP      string
+	UserAgent     string
+	Latency       time.Duration
+}
+
+// MarshalJSON encodes sizes as strings and latency as seconds, e.g. "0.25s",
+// as the LogEntry API expects
+func (r *HTTPRequest) MarshalJSON() ([]byte, error) {
+	out := map[string]any{
+		"requestMethod": r.RequestMethod,
+		"requestUrl":    r.RequestURL,
+		"status":        r.Status,
+		"remoteIp":      r.RemoteIP,
+		"latency":       strconv.FormatFloat(r.Latency.Seconds(), 'f', -1, 64) + "s",
+	}
+	if r.UserAgent != "" {
+		out["userAgent"] = r.UserAgent
+	}
+	if r.RequestSize > 0 {
+		out["requestSize"] = strconv.FormatInt(r.RequestSize, 10)
+	}
+	if r.ResponseSize > 0 {
+		out["responseSize"] = strconv.FormatInt(r.ResponseSize, 10)
+	}
+	return json.Marshal(out)
+}
+
+// CloudFormatter writes one JSON object per entry using the field names of
+// Google Cloud Logging: severity, message, time, httpRequest and the trace
+// fields that link the entry to Cloud Trace. Other fields are written as
+// they are and show up in jsonPayload.
+type CloudFormatter struct {
+	// ProjectID qualifies trace IDs as projects/<id>/traces/<trace>; without
+	// it the trace link fields are omitted
+	ProjectID string
+}
+
+// Format implements logrus.Formatter
+func (f *CloudFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	data := make(logrus.Fields, len(entry.Data)+6)
+	for key, value := range entry.Data {
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+		data[key] = value
+	}
+
+	data["severity"] = severity(entry.Level)
+	data["message"] = entry.Message
+	data["time"] = entry.Time.Format(time.RFC3339Nano)
+
+	if entry.Context != nil {
+		if spanContext := trace.SpanContextFromContext(entry.Context); spanContext.IsValid() && f.ProjectID != "" {
+			data[cloudTraceKey] = fmt.Sprintf("projects/%s/traces/%s", f.ProjectID, spanContext.TraceID())
+			data[cloudSpanIDKey] = spanContext.SpanID().String()
+			data[cloudTraceSampledKey] = spanContext.IsSampled()
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(data); err != nil {
+		return nil, fmt.Errorf("failed to marshal log entry: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// severity maps logrus levels to Cloud Logging severities
+func severity(level logrus.Level) string {
+	switch level {
+	case logrus.TraceLevel, logrus.DebugLevel:
+		return "DEBUG"
+	case logrus.InfoLevel:
+		return "INFO"
+	case logrus.WarnLevel:
+		return "WARNING"
+	case logrus.ErrorLevel:
+		return "ERROR"
+	case logrus.FatalLevel:
+		return "CRITICAL"
+	default:
+		return "ALERT"
+	}
+}
@@ -0,0 +1,25 @@
+// Package logging provides request-scoped logrus entries, access logging
+// for Connect calls and a Google Cloud Logging formatter.
+package logging
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type entryKey struct{}
+
+// WithEntry returns a copy of ctx carrying a request-scoped log entry
+func WithEntry(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, entryKey{}, entry)
+}
+
+// FromContext returns the request-scoped entry, or an entry of fallback
+// bound to ctx outside of a request
+func FromContext(ctx context.Context, fallback *logrus.Logger) *logrus.Entry {
+	if entry, ok := ctx.Value(entryKey{}).(*logrus.Entry); ok && entry != nil {
+		return entry
+	}
+	return fallback.WithContext(ctx)
+}
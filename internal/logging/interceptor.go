@@ -0,0 +1,226 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+)
+
+// RequestIDHeader carries the request ID in both directions
+const RequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength bounds request IDs accepted from callers
+const maxRequestIDLength = 128
+
+// Field names used on request-scoped entries and access log lines
+const (
+	FieldRequestID   = "request_id"
+	FieldProcedure   = "procedure"
+	FieldProtocol    = "protocol"
+	FieldPeer        = "peer"
+	FieldCode        = "code"
+	FieldDuration    = "duration"
+	FieldHTTPRequest = "httpRequest"
+)
+
+// Interceptor assigns each handler call a request ID, taken from the
+// x-request-id header when present, echoes it in the response, stores a
+// request-scoped entry in the context and writes one access log line when
+// the call completes.
+type Interceptor struct {
+	logger *logrus.Logger
+}
+
+// NewInterceptor creates a logging interceptor writing to logger
+func NewInterceptor(logger *logrus.Logger) *Interceptor {
+	return &Interceptor{logger: logger}
+}
+
+// WrapUnary implements connect.Interceptor
+func (i *Interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		ctx, call := i.begin(ctx, req.Spec(), req.Peer(), req.Header())
+		call.httpRequest.RequestSize = messageSize(req.Any())
+
+		resp, err := next(ctx, req)
+		// Handlers return a typed nil response with their error
+		if err == nil && resp != nil {
+			resp.Header().Set(RequestIDHeader, call.requestID)
+			call.httpRequest.ResponseSize = messageSize(resp.Any())
+		}
+		var connectErr *connect.Error
+		if errors.As(err, &connectErr) {
+			connectErr.Meta().Set(RequestIDHeader, call.requestID)
+		}
+		call.end(err)
+		return resp, err
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor
+func (i *Interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor
+func (i *Interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx, call := i.begin(ctx, conn.Spec(), conn.Peer(), conn.RequestHeader())
+		conn.ResponseHeader().Set(RequestIDHeader, call.requestID)
+		err := next(ctx, conn)
+		call.end(err)
+		return err
+	}
+}
+
+// call tracks a single request for its access log line
+type call struct {
+	entry       *logrus.Entry
+	requestID   string
+	protocol    string
+	start       time.Time
+	httpRequest *HTTPRequest
+}
+
+func (i *Interceptor) begin(ctx context.Context, spec connect.Spec, peer connect.Peer, header http.Header) (context.Context, *call) {
+	requestID := header.Get(RequestIDHeader)
+	if !validRequestID(requestID) {
+		requestID = newRequestID()
+	}
+	remoteIP, _, err := net.SplitHostPort(peer.Addr)
+	if err != nil {
+		remoteIP = peer.Addr
+	}
+
+	entry := i.logger.WithContext(ctx).WithFields(logrus.Fields{
+		FieldRequestID: requestID,
+		FieldProcedure: spec.Procedure,
+		FieldProtocol:  peer.Protocol,
+		FieldPeer:      peer.Addr,
+	})
+	c := &call{
+		entry:     entry,
+		requestID: requestID,
+		protocol:  peer.Protocol,
+		start:     time.Now(),
+		httpRequest: &HTTPRequest{
+			RequestMethod: http.MethodPost,
+			RequestURL:    spec.Procedure,
+			RemoteIP:      remoteIP,
+			UserAgent:     header.Get("User-Agent"),
+		},
+	}
+	return WithEntry(ctx, entry), c
+}
+
+func (c *call) end(err error) {
+	duration := time.Since(c.start)
+	code := connect.Code(0)
+	if err != nil {
+		code = connect.CodeOf(err)
+	}
+	c.httpRequest.Status = httpStatus(c.protocol, code)
+	c.httpRequest.Latency = duration
+
+	entry := c.entry.WithFields(logrus.Fields{
+		FieldCode:        codeName(code),
+		FieldDuration:    duration.String(),
+		FieldHTTPRequest: c.httpRequest,
+	})
+	switch {
+	case err == nil:
+		entry.Info("RPC completed")
+	case isServerError(code):
+		entry.WithError(err).Error("RPC failed")
+	default:
+		entry.WithError(err).Warn("RPC failed")
+	}
+}
+
+// validRequestID accepts short IDs made of URL-safe characters
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func codeName(code connect.Code) string {
+	if code == 0 {
+		return "ok"
+	}
+	return code.String()
+}
+
+// isServerError reports codes that indicate a problem on our side
+func isServerError(code connect.Code) bool {
+	switch code {
+	case connect.CodeUnknown, connect.CodeInternal, connect.CodeDataLoss, connect.CodeUnimplemented:
+		return true
+	}
+	return false
+}
+
+// httpStatus returns the HTTP status a call completed with. gRPC and
+// gRPC-Web report errors in trailers, so their status is always 200.
+func httpStatus(protocol string, code connect.Code) int {
+	if code == 0 || protocol == connect.ProtocolGRPC || protocol == connect.ProtocolGRPCWeb {
+		return http.StatusOK
+	}
+	switch code {
+	case connect.CodeCanceled, connect.CodeDeadlineExceeded:
+		return http.StatusRequestTimeout
+	case connect.CodeInvalidArgument, connect.CodeOutOfRange:
+		return http.StatusBadRequest
+	case connect.CodeNotFound:
+		return http.StatusNotFound
+	case connect.CodeAlreadyExists, connect.CodeAborted:
+		return http.StatusConflict
+	case connect.CodePermissionDenied:
+		return http.StatusForbidden
+	case connect.CodeResourceExhausted:
+		return http.StatusTooManyRequests
+	case connect.CodeFailedPrecondition:
+		return http.StatusPreconditionFailed
+	case connect.CodeUnimplemented:
+		return http.StatusNotFound
+	case connect.CodeUnavailable:
+		return http.StatusServiceUnavailable
+	case connect.CodeUnauthenticated:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func messageSize(msg any) int64 {
+	if m, ok := msg.(proto.Message); ok {
+		return int64(proto.Size(m))
+	}
+	return 0
+}
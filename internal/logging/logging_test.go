@@ -0,0 +1,233 @@
+package logging_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api/apiv1connect"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/logging"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/server"
+)
+
+// logLines decodes JSON log output, one object per line
+func logLines(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	var lines []map[string]any
+	scanner := bufio.NewScanner(buf)
+	for scanner.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func newTestClient(t *testing.T, logger *logrus.Logger, interceptors ...connect.Interceptor) apiv1connect.GrpcServiceClient {
+	mux := http.NewServeMux()
+	mux.Handle(apiv1connect.NewGrpcServiceHandler(
+		server.NewGrpcService(logger),
+		connect.WithInterceptors(append([]connect.Interceptor{logging.NewInterceptor(logger)}, interceptors...)...),
+	))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return apiv1connect.NewGrpcServiceClient(srv.Client(), srv.URL)
+}
+
+func newTestLogger() (*logrus.Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	return logger, &buf
+}
+
+func TestInterceptor_PropagatesRequestID(t *testing.T) {
+	logger, buf := newTestLogger()
+	client := newTestClient(t, logger)
+
+	req := connect.NewRequest(&apiv1.ProcessDataRequest{Data: "x"})
+	req.Header().Set(logging.RequestIDHeader, "req-123")
+	resp, err := client.ProcessData(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "req-123", resp.Header().Get(logging.RequestIDHeader))
+
+	lines := logLines(t, buf)
+	require.Len(t, lines, 2)
+
+	// The handler logs through the request-scoped entry
+	assert.Equal(t, "ProcessData called", lines[0]["msg"])
+	assert.Equal(t, "req-123", lines[0][logging.FieldRequestID])
+	assert.Equal(t, apiv1connect.GrpcServiceProcessDataProcedure, lines[0][logging.FieldProcedure])
+
+	access := lines[1]
+	assert.Equal(t, "RPC completed", access["msg"])
+	assert.Equal(t, "info", access["level"])
+	assert.Equal(t, "req-123", access[logging.FieldRequestID])
+	assert.Equal(t, "ok", access[logging.FieldCode])
+	assert.Equal(t, connect.ProtocolConnect, access[logging.FieldProtocol])
+	httpRequest := access[logging.FieldHTTPRequest].(map[string]any)
+	assert.Equal(t, float64(200), httpRequest["status"])
+	assert.Equal(t, apiv1connect.GrpcServiceProcessDataProcedure, httpRequest["requestUrl"])
+	assert.Equal(t, "127.0.0.1", httpRequest["remoteIp"])
+}
+
+func TestInterceptor_GeneratesRequestID(t *testing.T) {
+	logger, buf := newTestLogger()
+	client := newTestClient(t, logger)
+
+	req := connect.NewRequest(&apiv1.GetInfoRequest{})
+	req.Header().Set(logging.RequestIDHeader, "bad id")
+	resp, err := client.GetInfo(context.Background(), req)
+	require.NoError(t, err)
+
+	requestID := resp.Header().Get(logging.RequestIDHeader)
+	assert.Len(t, requestID, 32)
+	lines := logLines(t, buf)
+	assert.Equal(t, requestID, lines[len(lines)-1][logging.FieldRequestID])
+}
+
+// denyAll rejects every call
+type denyAll struct{ code connect.Code }
+
+func (d denyAll) WrapUnary(connect.UnaryFunc) connect.UnaryFunc {
+	return func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, connect.NewError(d.code, errors.New("denied"))
+	}
+}
+
+func (d denyAll) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (d denyAll) WrapStreamingHandler(connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(context.Context, connect.StreamingHandlerConn) error {
+		return connect.NewError(d.code, errors.New("denied"))
+	}
+}
+
+func TestInterceptor_LogsFailures(t *testing.T) {
+	logger, buf := newTestLogger()
+	client := newTestClient(t, logger, denyAll{code: connect.CodePermissionDenied})
+
+	_, err := client.GetInfo(context.Background(), connect.NewRequest(&apiv1.GetInfoRequest{}))
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	assert.NotEmpty(t, connectErr.Meta().Get(logging.RequestIDHeader), "errors carry the request ID")
+
+	stream, err := client.StreamData(context.Background(), connect.NewRequest(&apiv1.StreamDataRequest{Query: "q"}))
+	require.NoError(t, err)
+	assert.False(t, stream.Receive())
+	assert.NotEmpty(t, stream.ResponseHeader().Get(logging.RequestIDHeader))
+	require.NoError(t, stream.Close())
+
+	lines := logLines(t, buf)
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		assert.Equal(t, "warning", line["level"])
+		assert.Equal(t, "permission_denied", line[logging.FieldCode])
+		assert.Equal(t, float64(http.StatusForbidden), line[logging.FieldHTTPRequest].(map[string]any)["status"])
+	}
+}
+
+func TestInterceptor_HandlerError(t *testing.T) {
+	logger, buf := newTestLogger()
+	client := newTestClient(t, logger)
+
+	// The handler returns a typed nil response with its error
+	req := connect.NewRequest(&apiv1.ProcessDataRequest{Data: "x", Options: map[string]string{"processor": "missing"}})
+	req.Header().Set(logging.RequestIDHeader, "req-456")
+	_, err := client.ProcessData(context.Background(), req)
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	assert.Equal(t, connect.CodeInvalidArgument, connectErr.Code())
+	assert.Equal(t, "req-456", connectErr.Meta().Get(logging.RequestIDHeader))
+
+	lines := logLines(t, buf)
+	access := lines[len(lines)-1]
+	assert.Equal(t, "RPC failed", access["msg"])
+	assert.Equal(t, "warning", access["level"])
+	assert.Equal(t, "invalid_argument", access[logging.FieldCode])
+	httpRequest := access[logging.FieldHTTPRequest].(map[string]any)
+	assert.Equal(t, float64(http.StatusBadRequest), httpRequest["status"])
+	assert.NotContains(t, httpRequest, "responseSize")
+}
+
+func TestFromContext_Fallback(t *testing.T) {
+	logger := logrus.New()
+	ctx := context.Background()
+
+	assert.Equal(t, ctx, logging.FromContext(ctx, logger).Context)
+
+	entry := logger.WithField("k", "v")
+	assert.Same(t, entry, logging.FromContext(logging.WithEntry(ctx, entry), logger))
+}
+
+func TestCloudFormatter(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+
+	var buf bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&buf)
+	logger.SetFormatter(&logging.CloudFormatter{ProjectID: "my-project"})
+	logger.WithContext(ctx).WithFields(logrus.Fields{
+		logging.FieldRequestID: "req-1",
+		logging.FieldHTTPRequest: &logging.HTTPRequest{
+			RequestMethod: http.MethodPost,
+			RequestURL:    "/api.v1.GrpcService/GetInfo",
+			Status:        200,
+			ResponseSize:  42,
+			Latency:       1500 * time.Millisecond,
+		},
+	}).WithError(errors.New("boom")).Warn("RPC failed")
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "WARNING", line["severity"])
+	assert.Equal(t, "RPC failed", line["message"])
+	assert.Equal(t, "boom", line["error"])
+	assert.Equal(t, "req-1", line[logging.FieldRequestID])
+	assert.Equal(t, "projects/my-project/traces/4bf92f3577b34da6a3ce929d0e0e4736", line["logging.googleapis.com/trace"])
+	assert.Equal(t, "00f067aa0ba902b7", line["logging.googleapis.com/spanId"])
+	assert.Equal(t, true, line["logging.googleapis.com/trace_sampled"])
+	assert.Equal(t, map[string]any{
+		"requestMethod": "POST",
+		"requestUrl":    "/api.v1.GrpcService/GetInfo",
+		"status":        float64(200),
+		"responseSize":  "42",
+		"remoteIp":      "",
+		"latency":       "1.5s",
+	}, line["httpRequest"])
+}
+
+func TestCloudFormatter_WithoutProject(t *testing.T) {
+	entry := logrus.NewEntry(logrus.New())
+	entry.Level = logrus.ErrorLevel
+	entry.Message = "failed"
+
+	out, err := (&logging.CloudFormatter{}).Format(entry)
+	require.NoError(t, err)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(out, &line))
+	assert.Equal(t, "ERROR", line["severity"])
+	assert.NotContains(t, line, "logging.googleapis.com/trace")
+}
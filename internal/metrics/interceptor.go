@@ -0,0 +1,248 @@
+// Package metrics records Prometheus metrics for Connect procedures using
+// the metric names of the grpc-prometheus ecosystem.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+// Label names shared with grpc-prometheus, plus the wire protocol
+const (
+	labelType     = "grpc_type"
+	labelService  = "grpc_service"
+	labelMethod   = "grpc_method"
+	labelCode     = "grpc_code"
+	labelProtocol = "protocol"
+)
+
+// Values of the grpc_type label
+const (
+	typeUnary        = "unary"
+	typeClientStream = "client_stream"
+	typeServerStream = "server_stream"
+	typeBidiStream   = "bidi_stream"
+)
+
+// sizeBuckets are message size buckets in bytes, from 64B to 4MiB
+var sizeBuckets = prometheus.ExponentialBuckets(64, 4, 9)
+
+// Interceptor records server-side RPC metrics:
+//
+//	grpc_server_started_total         RPCs started
+//	grpc_server_handled_total         RPCs completed, by code
+//	grpc_server_handling_seconds      latency histogram
+//	grpc_server_in_flight_requests    RPCs currently running
+//	grpc_server_msg_received_total    messages received
+//	grpc_server_msg_sent_total        messages sent
+//	grpc_server_request_size_bytes    received message sizes
+//	grpc_server_response_size_bytes   sent message sizes
+type Interceptor struct {
+	started      *prometheus.CounterVec
+	handled      *prometheus.CounterVec
+	handling     *prometheus.HistogramVec
+	inFlight     *prometheus.GaugeVec
+	received     *prometheus.CounterVec
+	sent         *prometheus.CounterVec
+	requestSize  *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+}
+
+// NewInterceptor creates a metrics interceptor registered with reg
+func NewInterceptor(reg prometheus.Registerer) *Interceptor {
+	labels := []string{labelType, labelService, labelMethod, labelProtocol}
+	i := &Interceptor{
+		started: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_started_total",
+			Help: "Total number of RPCs started on the server.",
+		}, labels),
+		handled: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_handled_total",
+			Help: "Total number of RPCs completed on the server, regardless of success or failure.",
+		}, append(labels, labelCode)),
+		handling: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_handling_seconds",
+			Help:    "Histogram of response latency (seconds) of RPCs that had been application-level handled by the server.",
+			Buckets: prometheus.DefBuckets,
+		}, labels),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "grpc_server_in_flight_requests",
+			Help: "Number of RPCs currently being handled by the server.",
+		}, labels),
+		received: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_msg_received_total",
+			Help: "Total number of RPC stream messages received on the server.",
+		}, labels),
+		sent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_msg_sent_total",
+			Help: "Total number of gRPC stream messages sent by the server.",
+		}, labels),
+		requestSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_request_size_bytes",
+			Help:    "Histogram of sizes (bytes) of messages received by the server.",
+			Buckets: sizeBuckets,
+		}, labels),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_response_size_bytes",
+			Help:    "Histogram of sizes (bytes) of messages sent by the server.",
+			Buckets: sizeBuckets,
+		}, labels),
+	}
+	reg.MustRegister(i.started, i.handled, i.handling, i.inFlight, i.received, i.sent, i.requestSize, i.responseSize)
+	return i
+}
+
+// WrapUnary implements connect.Interceptor
+func (i *Interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		rpc := i.begin(req.Spec(), req.Peer())
+		rpc.received(req.Any())
+
+		resp, err := next(ctx, req)
+		if err == nil && resp != nil {
+			rpc.sent(resp.Any())
+		}
+		rpc.end(err)
+		return resp, err
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor
+func (i *Interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor
+func (i *Interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		rpc := i.begin(conn.Spec(), conn.Peer())
+		err := next(ctx, &streamingConn{StreamingHandlerConn: conn, rpc: rpc})
+		rpc.end(err)
+		return err
+	}
+}
+
+// rpc tracks a single call
+type rpc struct {
+	interceptor *Interceptor
+	labels      prometheus.Labels
+	start       time.Time
+}
+
+func (i *Interceptor) begin(spec connect.Spec, peer connect.Peer) *rpc {
+	service, method := splitProcedure(spec.Procedure)
+	labels := prometheus.Labels{
+		labelType:     streamType(spec.StreamType),
+		labelService:  service,
+		labelMethod:   method,
+		labelProtocol: protocol(peer.Protocol),
+	}
+	i.started.With(labels).Inc()
+	i.inFlight.With(labels).Inc()
+	return &rpc{interceptor: i, labels: labels, start: time.Now()}
+}
+
+func (r *rpc) received(msg any) {
+	r.interceptor.received.With(r.labels).Inc()
+	r.interceptor.requestSize.With(r.labels).Observe(messageSize(msg))
+}
+
+func (r *rpc) sent(msg any) {
+	r.interceptor.sent.With(r.labels).Inc()
+	r.interceptor.responseSize.With(r.labels).Observe(messageSize(msg))
+}
+
+func (r *rpc) end(err error) {
+	r.interceptor.inFlight.With(r.labels).Dec()
+	r.interceptor.handling.With(r.labels).Observe(time.Since(r.start).Seconds())
+
+	handled := prometheus.Labels{labelCode: Code(err)}
+	for k, v := range r.labels {
+		handled[k] = v
+	}
+	r.interceptor.handled.With(handled).Inc()
+}
+
+// streamingConn counts the messages of a streaming call
+type streamingConn struct {
+	connect.StreamingHandlerConn
+	rpc *rpc
+}
+
+func (c *streamingConn) Receive(msg any) error {
+	if err := c.StreamingHandlerConn.Receive(msg); err != nil {
+		return err
+	}
+	c.rpc.received(msg)
+	return nil
+}
+
+func (c *streamingConn) Send(msg any) error {
+	if err := c.StreamingHandlerConn.Send(msg); err != nil {
+		return err
+	}
+	c.rpc.sent(msg)
+	return nil
+}
+
+// Code returns the gRPC name of an error's code, e.g. "OK" or
+// "ResourceExhausted", as used in the grpc_code label
+func Code(err error) string {
+	if err == nil {
+		return codes.OK.String()
+	}
+	if errors.Is(err, context.Canceled) {
+		return codes.Canceled.String()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return codes.DeadlineExceeded.String()
+	}
+	return codes.Code(connect.CodeOf(err)).String()
+}
+
+// splitProcedure splits "/api.v1.GrpcService/GetInfo" into service and method
+func splitProcedure(procedure string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(procedure, "/"), "/")
+	if !ok {
+		return "unknown", procedure
+	}
+	return service, method
+}
+
+func streamType(t connect.StreamType) string {
+	switch t {
+	case connect.StreamTypeClient:
+		return typeClientStream
+	case connect.StreamTypeServer:
+		return typeServerStream
+	case connect.StreamTypeBidi:
+		return typeBidiStream
+	default:
+		return typeUnary
+	}
+}
+
+// protocol names the wire protocol as connect, grpc or grpc-web
+func protocol(name string) string {
+	if name == connect.ProtocolGRPCWeb {
+		return "grpc-web"
+	}
+	return name
+}
+
+func messageSize(msg any) float64 {
+	if m, ok := msg.(proto.Message); ok {
+		return float64(proto.Size(m))
+	}
+	return 0
+}
@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api/apiv1connect"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/server"
+)
+
+// denyInfo rejects GetInfo to exercise error codes
+type denyInfo struct{}
+
+func (denyInfo) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().Procedure == apiv1connect.GrpcServiceGetInfoProcedure {
+			return nil, connect.NewError(connect.CodePermissionDenied, errors.New("denied"))
+		}
+		return next(ctx, req)
+	}
+}
+
+func (denyInfo) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (denyInfo) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
+
+func newTestServer(t *testing.T) (*prometheus.Registry, *httptest.Server) {
+	reg := prometheus.NewRegistry()
+	mux := http.NewServeMux()
+	mux.Handle(apiv1connect.NewGrpcServiceHandler(
+		server.NewGrpcService(logrus.New()),
+		connect.WithInterceptors(NewInterceptor(reg), denyInfo{}),
+	))
+	srv := httptest.NewUnstartedServer(mux)
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return reg, srv
+}
+
+func TestInterceptor_Unary(t *testing.T) {
+	reg, srv := newTestServer(t)
+	ctx := context.Background()
+
+	connectClient := apiv1connect.NewGrpcServiceClient(srv.Client(), srv.URL)
+	grpcClient := apiv1connect.NewGrpcServiceClient(srv.Client(), srv.URL, connect.WithGRPC())
+	grpcWebClient := apiv1connect.NewGrpcServiceClient(srv.Client(), srv.URL, connect.WithGRPCWeb())
+
+	for _, client := range []apiv1connect.GrpcServiceClient{connectClient, grpcClient, grpcWebClient} {
+		_, err := client.ProcessData(ctx, connect.NewRequest(&apiv1.ProcessDataRequest{Data: "hello"}))
+		require.NoError(t, err)
+	}
+	_, err := connectClient.GetInfo(ctx, connect.NewRequest(&apiv1.GetInfoRequest{}))
+	require.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+
+	expected := `
+# HELP grpc_server_handled_total Total number of RPCs completed on the server, regardless of success or failure.
+# TYPE grpc_server_handled_total counter
+grpc_server_handled_total{grpc_code="OK",grpc_method="ProcessData",grpc_service="api.v1.GrpcService",grpc_type="unary",protocol="connect"} 1
+grpc_server_handled_total{grpc_code="OK",grpc_method="ProcessData",grpc_service="api.v1.GrpcService",grpc_type="unary",protocol="grpc"} 1
+grpc_server_handled_total{grpc_code="OK",grpc_method="ProcessData",grpc_service="api.v1.GrpcService",grpc_type="unary",protocol="grpc-web"} 1
+grpc_server_handled_total{grpc_code="PermissionDenied",grpc_method="GetInfo",grpc_service="api.v1.GrpcService",grpc_type="unary",protocol="connect"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "grpc_server_handled_total"))
+
+	expected = `
+# HELP grpc_server_in_flight_requests Number of RPCs currently being handled by the server.
+# TYPE grpc_server_in_flight_requests gauge
+grpc_server_in_flight_requests{grpc_method="GetInfo",grpc_service="api.v1.GrpcService",grpc_type="unary",protocol="connect"} 0
+grpc_server_in_flight_requests{grpc_method="ProcessData",grpc_service="api.v1.GrpcService",grpc_type="unary",protocol="connect"} 0
+grpc_server_in_flight_requests{grpc_method="ProcessData",grpc_service="api.v1.GrpcService",grpc_type="unary",protocol="grpc"} 0
+grpc_server_in_flight_requests{grpc_method="ProcessData",grpc_service="api.v1.GrpcService",grpc_type="unary",protocol="grpc-web"} 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "grpc_server_in_flight_requests"))
+
+	// Rejected calls received a request but sent no response
+	assert.Equal(t, 4, testutil.CollectAndCount(reg, "grpc_server_msg_received_total"))
+	assert.Equal(t, 3, testutil.CollectAndCount(reg, "grpc_server_msg_sent_total"))
+	assert.Equal(t, 4, testutil.CollectAndCount(reg, "grpc_server_handling_seconds"))
+	assert.Equal(t, 4, testutil.CollectAndCount(reg, "grpc_server_request_size_bytes"))
+}
+
+func TestInterceptor_ServerStream(t *testing.T) {
+	reg, srv := newTestServer(t)
+	client := apiv1connect.NewGrpcServiceClient(srv.Client(), srv.URL, connect.WithGRPC())
+
+	stream, err := client.StreamData(context.Background(), connect.NewRequest(&apiv1.StreamDataRequest{Query: "q", Limit: 3}))
+	require.NoError(t, err)
+	received := 0
+	for stream.Receive() {
+		received++
+	}
+	require.NoError(t, stream.Err())
+	require.NoError(t, stream.Close())
+	require.Equal(t, 3, received)
+
+	expected := `
+# HELP grpc_server_msg_sent_total Total number of gRPC stream messages sent by the server.
+# TYPE grpc_server_msg_sent_total counter
+grpc_server_msg_sent_total{grpc_method="StreamData",grpc_service="api.v1.GrpcService",grpc_type="server_stream",protocol="grpc"} 3
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "grpc_server_msg_sent_total"))
+
+	expected = `
+# HELP grpc_server_msg_received_total Total number of RPC stream messages received on the server.
+# TYPE grpc_server_msg_received_total counter
+grpc_server_msg_received_total{grpc_method="StreamData",grpc_service="api.v1.GrpcService",grpc_type="server_stream",protocol="grpc"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "grpc_server_msg_received_total"))
+}
+
+func TestCode(t *testing.T) {
+	assert.Equal(t, "OK", Code(nil))
+	assert.Equal(t, "Canceled", Code(context.Canceled))
+	assert.Equal(t, "DeadlineExceeded", Code(context.DeadlineExceeded))
+	assert.Equal(t, "ResourceExhausted", Code(connect.NewError(connect.CodeResourceExhausted, errors.New("x"))))
+	assert.Equal(t, "Unknown", Code(errors.New("x")))
+}
@@ -0,0 +1,389 @@
+package pagination
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxFilterLength is the longest filter expression accepted, in bytes
+const MaxFilterLength = 1024
+
+// maxFilterDepth bounds the nesting of parentheses and NOT
+const maxFilterDepth = 32
+
+// Kind is the type of a filterable field
+type Kind int
+
+// Field kinds
+const (
+	String Kind = iota + 1
+	Int
+	Bool
+	// Time values are RFC 3339 timestamps or dates like 2025-01-01, which
+	// mean midnight UTC
+	Time
+)
+
+// Fields declares the fields a filter may compare and their kinds
+type Fields map[string]Kind
+
+// Filter is a parsed AIP-160 style filter. It supports comparisons of a
+// field with a value (=, !=, <, <=, >, >=), AND, OR, NOT and parentheses.
+// As in AIP-160, OR binds tighter than AND:
+//
+//	status = "ok" AND processed_at > "2025-01-01"
+//	NOT (method = "StreamData" OR success = false)
+//
+// A nil *Filter matches everything.
+type Filter struct {
+	root node
+}
+
+// ParseFilter parses expr, checking its fields and values against fields.
+// An empty expression gives a nil Filter. Syntax errors are an *Error for
+// the filter field.
+func ParseFilter(expr string, fields Fields) (*Filter, error) {
+	if len(expr) > MaxFilterLength {
+		return nil, &Error{Field: "filter", Message: fmt.Sprintf("must be at most %d bytes, got %d", MaxFilterLength, len(expr))}
+	}
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+	p := &parser{lexer: lexer{input: expr}, fields: fields}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	root, err := p.expression(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.token.kind != tokenEOF {
+		return nil, p.errorf("unexpected %s", p.token)
+	}
+	return &Filter{root: root}, nil
+}
+
+// Match reports whether the filter selects a record. value returns the
+// record's value of a field: a string, int64, bool or time.Time according
+// to the field's kind.
+func (f *Filter) Match(value func(field string) any) bool {
+	if f == nil {
+		return true
+	}
+	return f.root.match(value)
+}
+
+// SQL returns the filter as an SQL condition with ? placeholders and their
+// arguments, or "" when there is no filter. column maps a field to the SQL
+// expression holding it. Times are passed as Unix nanoseconds.
+func (f *Filter) SQL(column func(field string) string) (string, []any) {
+	if f == nil {
+		return "", nil
+	}
+	var b strings.Builder
+	var args []any
+	f.root.sql(&b, &args, column)
+	return b.String(), args
+}
+
+type node interface {
+	match(value func(string) any) bool
+	sql(b *strings.Builder, args *[]any, column func(string) string)
+}
+
+// andNode and orNode hold at least two operands
+type andNode []node
+type orNode []node
+
+type notNode struct {
+	operand node
+}
+
+type comparison struct {
+	field string
+	op    string
+	value any
+}
+
+func (n andNode) match(value func(string) any) bool {
+	for _, operand := range n {
+		if !operand.match(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (n andNode) sql(b *strings.Builder, args *[]any, column func(string) string) {
+	joinSQL(b, args, column, n, " AND ")
+}
+
+func (n orNode) match(value func(string) any) bool {
+	for _, operand := range n {
+		if operand.match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n orNode) sql(b *strings.Builder, args *[]any, column func(string) string) {
+	joinSQL(b, args, column, n, " OR ")
+}
+
+func joinSQL(b *strings.Builder, args *[]any, column func(string) string, operands []node, separator string) {
+	b.WriteString("(")
+	for i, operand := range operands {
+		if i > 0 {
+			b.WriteString(separator)
+		}
+		operand.sql(b, args, column)
+	}
+	b.WriteString(")")
+}
+
+func (n notNode) match(value func(string) any) bool {
+	return !n.operand.match(value)
+}
+
+func (n notNode) sql(b *strings.Builder, args *[]any, column func(string) string) {
+	b.WriteString("NOT (")
+	n.operand.sql(b, args, column)
+	b.WriteString(")")
+}
+
+func (c comparison) match(value func(string) any) bool {
+	var order int
+	switch want := c.value.(type) {
+	case string:
+		got, ok := value(c.field).(string)
+		if !ok {
+			return false
+		}
+		order = strings.Compare(got, want)
+	case int64:
+		got, ok := value(c.field).(int64)
+		if !ok {
+			return false
+		}
+		order = compareInts(got, want)
+	case bool:
+		got, ok := value(c.field).(bool)
+		if !ok {
+			return false
+		}
+		if got == want {
+			order = 0
+		} else {
+			order = 1
+		}
+	case time.Time:
+		got, ok := value(c.field).(time.Time)
+		if !ok {
+			return false
+		}
+		order = got.Compare(want)
+	}
+	switch c.op {
+	case "=":
+		return order == 0
+	case "!=":
+		return order != 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	default:
+		return order >= 0
+	}
+}
+
+func (c comparison) sql(b *strings.Builder, args *[]any, column func(string) string) {
+	op := c.op
+	if op == "!=" {
+		op = "<>"
+	}
+	fmt.Fprintf(b, "%s %s ?", column(c.field), op)
+	if t, ok := c.value.(time.Time); ok {
+		*args = append(*args, t.UnixNano())
+	} else {
+		*args = append(*args, c.value)
+	}
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+type parser struct {
+	lexer  lexer
+	fields Fields
+	token  token
+}
+
+func (p *parser) advance() error {
+	t, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.token = t
+	return nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &Error{Field: "filter", Message: fmt.Sprintf(format, args...) + fmt.Sprintf(" at position %d", p.token.pos+1)}
+}
+
+func (p *parser) keyword(word string) bool {
+	return p.token.kind == tokenWord && p.token.text == word
+}
+
+// expression is a sequence of disjunctions joined by AND
+func (p *parser) expression(depth int) (node, error) {
+	return p.join(depth, "AND", p.disjunction, func(operands []node) node { return andNode(operands) })
+}
+
+// disjunction is a sequence of factors joined by OR
+func (p *parser) disjunction(depth int) (node, error) {
+	return p.join(depth, "OR", p.factor, func(operands []node) node { return orNode(operands) })
+}
+
+func (p *parser) join(depth int, keyword string, operand func(int) (node, error), combine func([]node) node) (node, error) {
+	first, err := operand(depth)
+	if err != nil {
+		return nil, err
+	}
+	operands := []node{first}
+	for p.keyword(keyword) {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		next, err := operand(depth)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, next)
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return combine(operands), nil
+}
+
+// factor is an optionally negated comparison or parenthesized expression
+func (p *parser) factor(depth int) (node, error) {
+	if depth >= maxFilterDepth {
+		return nil, p.errorf("nesting deeper than %d", maxFilterDepth)
+	}
+	switch {
+	case p.keyword("NOT"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		operand, err := p.factor(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return notNode{operand: operand}, nil
+	case p.token.kind == tokenLeftParen:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		inner, err := p.expression(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if p.token.kind != tokenRightParen {
+			return nil, p.errorf("expected ) but found %s", p.token)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	default:
+		return p.comparison()
+	}
+}
+
+func (p *parser) comparison() (node, error) {
+	if p.token.kind != tokenWord || p.keyword("AND") || p.keyword("OR") {
+		return nil, p.errorf("expected a field name but found %s", p.token)
+	}
+	field := p.token.text
+	kind, ok := p.fields[field]
+	if !ok {
+		return nil, p.errorf("unknown field %q (available: %s)", field, strings.Join(p.fieldNames(), ", "))
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.token.kind != tokenOperator {
+		return nil, p.errorf("expected a comparison operator after %s but found %s", field, p.token)
+	}
+	op := p.token.text
+	if kind == Bool && op != "=" && op != "!=" {
+		return nil, p.errorf("%s is a boolean and only supports = and !=", field)
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.token.kind != tokenWord && p.token.kind != tokenString {
+		return nil, p.errorf("expected a value after %s %s but found %s", field, op, p.token)
+	}
+	value, err := parseValue(kind, p.token.text)
+	if err != nil {
+		return nil, p.errorf("%s: %v", field, err)
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	return comparison{field: field, op: op, value: value}, nil
+}
+
+func (p *parser) fieldNames() []string {
+	names := make([]string, 0, len(p.fields))
+	for name := range p.fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func parseValue(kind Kind, text string) (any, error) {
+	switch kind {
+	case Int:
+		n, err := strconv.ParseInt(text, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("expected an integer, got %q", text)
+		}
+		return n, nil
+	case Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil || (text != "true" && text != "false") {
+			return nil, fmt.Errorf("expected true or false, got %q", text)
+		}
+		return b, nil
+	case Time:
+		if t, err := time.Parse(time.RFC3339Nano, text); err == nil {
+			return t, nil
+		}
+		if t, err := time.Parse(time.DateOnly, text); err == nil {
+			return t, nil
+		}
+		return nil, fmt.Errorf("expected an RFC 3339 timestamp or a date like 2025-01-01, got %q", text)
+	default:
+		return text, nil
+	}
+}
@@ -0,0 +1,151 @@
+package pagination
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testFields = Fields{
+	"id":           Int,
+	"status":       String,
+	"success":      Bool,
+	"processed_at": Time,
+}
+
+type record struct {
+	id          int64
+	status      string
+	success     bool
+	processedAt time.Time
+}
+
+func (r record) value(field string) any {
+	switch field {
+	case "id":
+		return r.id
+	case "status":
+		return r.status
+	case "success":
+		return r.success
+	case "processed_at":
+		return r.processedAt
+	}
+	return nil
+}
+
+var records = []record{
+	{id: 1, status: "ok", success: true, processedAt: time.Date(2024, 12, 31, 23, 0, 0, 0, time.UTC)},
+	{id: 2, status: "ok", success: true, processedAt: time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)},
+	{id: 3, status: "invalid_argument", processedAt: time.Date(2025, 1, 3, 0, 0, 0, 0, time.UTC)},
+	{id: 4, status: "internal", processedAt: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+}
+
+// matching returns the IDs of the records the filter selects
+func matching(t *testing.T, expr string) []int64 {
+	t.Helper()
+	filter, err := ParseFilter(expr, testFields)
+	require.NoError(t, err)
+	var ids []int64
+	for _, r := range records {
+		if filter.Match(r.value) {
+			ids = append(ids, r.id)
+		}
+	}
+	return ids
+}
+
+func TestFilter_Match(t *testing.T) {
+	for expr, want := range map[string][]int64{
+		``:               {1, 2, 3, 4},
+		`status = "ok"`:  {1, 2},
+		`status = ok`:    {1, 2},
+		`status != 'ok'`: {3, 4},
+		`status = "ok" AND processed_at > "2025-01-01"`: {2},
+		`processed_at >= 2025-01-03`:                    {3, 4},
+		`processed_at < "2025-01-02T00:00:00Z"`:         {1},
+		`id <= 2 OR id > 3`:                             {1, 2, 4},
+		`success = false AND id < 4`:                    {3},
+		`success != true`:                               {3, 4},
+		`NOT status = "ok"`:                             {3, 4},
+		`NOT (id = 1 OR id = 4)`:                        {2, 3},
+		`id = 1 OR id = 3 AND status = "ok"`:            {1},
+		`(id = 1 OR id = 3) AND status = "ok"`:          {1},
+		`id = 1 OR (id = 3 AND status = "ok")`:          {1},
+		`((status = "internal"))`:                       {4},
+		`status > "j"`:                                  {1, 2},
+	} {
+		t.Run(expr, func(t *testing.T) {
+			assert.Equal(t, want, matching(t, expr))
+		})
+	}
+}
+
+func TestFilter_QuotedValues(t *testing.T) {
+	for _, value := range []string{`say "hi"`, `a\b`, "tab\there", "héllo", `it's`} {
+		filter, err := ParseFilter("status = "+strconv.Quote(value), testFields)
+		require.NoError(t, err, value)
+		assert.True(t, filter.Match(record{status: value}.value), value)
+	}
+
+	filter, err := ParseFilter(`status = 'say "hi"'`, testFields)
+	require.NoError(t, err)
+	assert.True(t, filter.Match(record{status: `say "hi"`}.value))
+}
+
+func TestFilter_Errors(t *testing.T) {
+	for expr, want := range map[string]string{
+		`state = "ok"`:           `filter: unknown field "state" (available: id, processed_at, status, success) at position 1`,
+		`status "ok"`:            `filter: expected a comparison operator after status but found "ok" at position 8`,
+		`status =`:               `filter: expected a value after status = but found end of filter at position 9`,
+		`status = "ok`:           `filter: unterminated string at position 10`,
+		`status = "ok" AND`:      `filter: expected a field name but found end of filter at position 18`,
+		`status = "ok" OR OR`:    `filter: expected a field name but found "OR" at position 18`,
+		`(status = "ok"`:         `filter: expected ) but found end of filter at position 15`,
+		`status = "ok")`:         `filter: unexpected ")" at position 14`,
+		`status ! "ok"`:          `filter: expected != at position 8`,
+		`id = one`:               `filter: id: expected an integer, got "one" at position 6`,
+		`success = yes`:          `filter: success: expected true or false, got "yes" at position 11`,
+		`success > false`:        `filter: success is a boolean and only supports = and != at position 9`,
+		`processed_at > "today"`: `filter: processed_at: expected an RFC 3339 timestamp or a date like 2025-01-01, got "today" at position 16`,
+		`status = "\q"`:          `filter: invalid escape in string at position 11`,
+	} {
+		t.Run(expr, func(t *testing.T) {
+			_, err := ParseFilter(expr, testFields)
+			var filterErr *Error
+			require.ErrorAs(t, err, &filterErr)
+			assert.Equal(t, "filter", filterErr.Field)
+			assert.EqualError(t, err, want)
+		})
+	}
+}
+
+func TestFilter_Limits(t *testing.T) {
+	_, err := ParseFilter(strings.Repeat(" ", MaxFilterLength+1), testFields)
+	assert.ErrorContains(t, err, "must be at most 1024 bytes")
+
+	_, err = ParseFilter(strings.Repeat("(", 40)+"id = 1"+strings.Repeat(")", 40), testFields)
+	assert.ErrorContains(t, err, "nesting deeper than 32")
+
+	_, err = ParseFilter(strings.Repeat("NOT ", 40)+"id = 1", testFields)
+	assert.ErrorContains(t, err, "nesting deeper than 32")
+}
+
+func TestFilter_SQL(t *testing.T) {
+	filter, err := ParseFilter(`NOT status = "ok" AND (id != 3 OR processed_at >= 2025-01-01) AND success = false`, testFields)
+	require.NoError(t, err)
+
+	where, args := filter.SQL(func(field string) string { return "t." + field })
+
+	assert.Equal(t, "(NOT (t.status = ?) AND (t.id <> ? OR t.processed_at >= ?) AND t.success = ?)", where)
+	assert.Equal(t, []any{"ok", int64(3), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano(), false}, args)
+
+	var none *Filter
+	where, args = none.SQL(func(field string) string { return field })
+	assert.Empty(t, where)
+	assert.Nil(t, args)
+}
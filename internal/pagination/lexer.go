@@ -0,0 +1,111 @@
+package pagination
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+)
+
+type token struct {
+	kind tokenKind
+	// text is the word, the unquoted string or the operator
+	text string
+	// pos is the byte offset of the token in the expression
+	pos int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of filter"
+	case tokenString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+type lexer struct {
+	input string
+	pos   int
+}
+
+// next returns the next token. Words run until whitespace, a parenthesis, a
+// quote or an operator character; strings are double or single quoted and
+// accept Go escapes, so strconv.Quote output is always a valid value.
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.input) {
+		r, size := utf8.DecodeRuneInString(l.input[l.pos:])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		l.pos += size
+	}
+	start := l.pos
+	if l.pos == len(l.input) {
+		return token{kind: tokenEOF, pos: start}, nil
+	}
+	switch c := l.input[l.pos]; c {
+	case '(':
+		l.pos++
+		return token{kind: tokenLeftParen, text: "(", pos: start}, nil
+	case ')':
+		l.pos++
+		return token{kind: tokenRightParen, text: ")", pos: start}, nil
+	case '=':
+		l.pos++
+		return token{kind: tokenOperator, text: "=", pos: start}, nil
+	case '<', '>', '!':
+		l.pos++
+		if l.pos < len(l.input) && l.input[l.pos] == '=' {
+			l.pos++
+		} else if c == '!' {
+			return token{}, &Error{Field: "filter", Message: fmt.Sprintf("expected != at position %d", start+1)}
+		}
+		return token{kind: tokenOperator, text: l.input[start:l.pos], pos: start}, nil
+	case '"', '\'':
+		return l.quoted(c)
+	}
+	for l.pos < len(l.input) {
+		r, size := utf8.DecodeRuneInString(l.input[l.pos:])
+		if unicode.IsSpace(r) || strings.ContainsRune(`()"'=<>!`, r) {
+			break
+		}
+		l.pos += size
+	}
+	return token{kind: tokenWord, text: l.input[start:l.pos], pos: start}, nil
+}
+
+func (l *lexer) quoted(quote byte) (token, error) {
+	start := l.pos
+	var b strings.Builder
+	rest := l.input[l.pos+1:]
+	for {
+		if rest == "" {
+			return token{}, &Error{Field: "filter", Message: fmt.Sprintf("unterminated string at position %d", start+1)}
+		}
+		if rest[0] == quote {
+			l.pos = len(l.input) - len(rest) + 1
+			return token{kind: tokenString, text: b.String(), pos: start}, nil
+		}
+		value, _, tail, err := strconv.UnquoteChar(rest, quote)
+		if err != nil {
+			return token{}, &Error{Field: "filter", Message: fmt.Sprintf("invalid escape in string at position %d", len(l.input)-len(rest)+1)}
+		}
+		b.WriteRune(value)
+		rest = tail
+	}
+}
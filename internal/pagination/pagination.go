@@ -0,0 +1,48 @@
+// Package pagination implements the list conventions shared by the List RPCs
+// and StreamData, after AIP-158 and AIP-160: page size validation, opaque
+// signed page tokens tied to the request they continue, and a small filter
+// language.
+package pagination
+
+import "fmt"
+
+// Error is a problem with one field of a request
+type Error struct {
+	// Field is the request field at fault, e.g. page_size
+	Field   string
+	Message string
+	// OverMax reports a size above the maximum of a Sizes that rejects them
+	OverMax bool
+}
+
+func (e *Error) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// Sizes holds the page size conventions of one RPC
+type Sizes struct {
+	// Default is used when the client asks for 0
+	Default int
+	// Max caps larger requests
+	Max int
+	// RejectAboveMax makes larger requests an *Error instead of reducing
+	// them to Max
+	RejectAboveMax bool
+}
+
+// Resolve returns the page size to use when the client asked for requested
+// in field. Zero gives the default and sizes over the maximum are reduced to
+// it, as AIP-158 recommends, unless RejectAboveMax is set; negative sizes are
+// an *Error.
+func (s Sizes) Resolve(field string, requested int32) (int, error) {
+	switch {
+	case requested < 0:
+		return 0, &Error{Field: field, Message: fmt.Sprintf("must not be negative, got %d", requested)}
+	case requested == 0:
+		return s.Default, nil
+	case s.RejectAboveMax && int(requested) > s.Max:
+		return 0, &Error{Field: field, Message: fmt.Sprintf("must be at most %d, got %d", s.Max, requested), OverMax: true}
+	default:
+		return min(int(requested), s.Max), nil
+	}
+}
@@ -0,0 +1,90 @@
+package pagination
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSizes_Resolve(t *testing.T) {
+	sizes := Sizes{Default: 50, Max: 1000}
+
+	size, err := sizes.Resolve("page_size", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 50, size)
+
+	size, err = sizes.Resolve("page_size", 20)
+	require.NoError(t, err)
+	assert.Equal(t, 20, size)
+
+	size, err = sizes.Resolve("page_size", 5000)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, size, "sizes over the maximum are coerced")
+
+	_, err = sizes.Resolve("limit", -1)
+	var pageErr *Error
+	require.ErrorAs(t, err, &pageErr)
+	assert.Equal(t, "limit", pageErr.Field)
+	assert.EqualError(t, err, "limit: must not be negative, got -1")
+	assert.False(t, pageErr.OverMax)
+}
+
+func TestSizes_RejectAboveMax(t *testing.T) {
+	sizes := Sizes{Default: 10, Max: 1000, RejectAboveMax: true}
+
+	size, err := sizes.Resolve("limit", 1000)
+	require.NoError(t, err)
+	assert.Equal(t, 1000, size)
+
+	_, err = sizes.Resolve("limit", 1001)
+	var pageErr *Error
+	require.ErrorAs(t, err, &pageErr)
+	assert.True(t, pageErr.OverMax)
+	assert.EqualError(t, err, "limit: must be at most 1000, got 1001")
+
+	_, err = sizes.Resolve("limit", -1)
+	assert.EqualError(t, err, "limit: must not be negative, got -1")
+}
+
+func TestTokens_RoundTrip(t *testing.T) {
+	tokens := NewTokens([]byte("secret"))
+	token := tokens.Encode("42", "ListResults", `status = "ok"`)
+
+	cursor, err := tokens.Decode(token, "ListResults", `status = "ok"`)
+
+	require.NoError(t, err)
+	assert.Equal(t, "42", cursor)
+	assert.NotContains(t, token, "status", "tokens do not expose the request")
+
+	cursor, err = NewTokens([]byte("secret")).Decode(token, "ListResults", `status = "ok"`)
+	require.NoError(t, err, "tokens are valid for any holder of the key")
+	assert.Equal(t, "42", cursor)
+}
+
+func TestTokens_Invalid(t *testing.T) {
+	tokens := NewTokens([]byte("secret"))
+	token := tokens.Encode("42", "ListResults", `status = "ok"`)
+
+	for name, decode := range map[string]func() (string, error){
+		"different filter": func() (string, error) { return tokens.Decode(token, "ListResults", `status = "failed"`) },
+		"different method": func() (string, error) { return tokens.Decode(token, "ListJobs", `status = "ok"`) },
+		"shifted request":  func() (string, error) { return tokens.Decode(token, "ListResult", `sstatus = "ok"`) },
+		"different key": func() (string, error) {
+			return NewTokens([]byte("other")).Decode(token, "ListResults", `status = "ok"`)
+		},
+		"random key": func() (string, error) { return NewTokens(nil).Decode(token, "ListResults", `status = "ok"`) },
+		"forged cursor": func() (string, error) {
+			_, mac, _ := strings.Cut(token, ".")
+			return tokens.Decode("OTk."+mac, "ListResults", `status = "ok"`) // 99
+		},
+		"no signature": func() (string, error) { return tokens.Decode("NDI", "ListResults", `status = "ok"`) },
+		"garbage":      func() (string, error) { return tokens.Decode("not a token!", "ListResults", `status = "ok"`) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := decode()
+			assert.ErrorIs(t, err, ErrInvalidToken)
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package processor
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Names of the built-in processors
+const (
+	Hash         = "hash"
+	Base64       = "base64"
+	JSONValidate = "json_validate"
+	Uppercase    = "uppercase"
+	WordCount    = "word_count"
+	RegexExtract = "regex_extract"
+)
+
+// Options read by the built-in processors
+const (
+	OptionAlgorithm = "algorithm"
+	OptionMode      = "mode"
+	OptionPattern   = "pattern"
+	OptionGroup     = "group"
+)
+
+func builtins() map[string]Processor {
+	return map[string]Processor{
+		Hash:         Func(hashProcessor),
+		Base64:       Func(base64Processor),
+		JSONValidate: Func(jsonValidateProcessor),
+		Uppercase:    Func(uppercaseProcessor),
+		WordCount:    Func(wordCountProcessor),
+		RegexExtract: Func(regexExtractProcessor),
+	}
+}
+
+// hashProcessor returns the hex digest of the input; options["algorithm"] is
+// sha256 (default), sha512, sha1 or md5
+func hashProcessor(_ context.Context, input string, options map[string]string) (string, error) {
+	var h hash.Hash
+	switch algorithm := options[OptionAlgorithm]; algorithm {
+	case "", "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	case "sha1":
+		h = sha1.New()
+	case "md5":
+		h = md5.New()
+	default:
+		return "", &OptionError{Option: OptionAlgorithm, Err: fmt.Errorf("unsupported hash algorithm %q", algorithm)}
+	}
+	h.Write([]byte(input))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// base64Processor encodes the input, or decodes it when options["mode"] is
+// decode
+func base64Processor(_ context.Context, input string, options map[string]string) (string, error) {
+	switch mode := options[OptionMode]; mode {
+	case "", "encode":
+		return base64.StdEncoding.EncodeToString([]byte(input)), nil
+	case "decode":
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(input))
+		if err != nil {
+			return "", &InputError{Processor: Base64, Err: errors.New("input is not valid base64")}
+		}
+		return string(decoded), nil
+	default:
+		return "", &OptionError{Option: OptionMode, Err: fmt.Errorf("must be encode or decode, got %q", mode)}
+	}
+}
+
+// jsonValidateProcessor checks that the input is a JSON document and returns
+// it compacted
+func jsonValidateProcessor(_ context.Context, input string, _ map[string]string) (string, error) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(input)); err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			return "", &InputError{Processor: JSONValidate, Err: fmt.Errorf("invalid JSON at offset %d: %v", syntaxErr.Offset, err)}
+		}
+		return "", &InputError{Processor: JSONValidate, Err: fmt.Errorf("invalid JSON: %v", err)}
+	}
+	return buf.String(), nil
+}
+
+func uppercaseProcessor(_ context.Context, input string, _ map[string]string) (string, error) {
+	return strings.ToUpper(input), nil
+}
+
+// wordCountProcessor returns the number of whitespace separated words
+func wordCountProcessor(_ context.Context, input string, _ map[string]string) (string, error) {
+	return strconv.Itoa(len(strings.Fields(input))), nil
+}
+
+// regexExtractProcessor returns every match of options["pattern"], one per
+// line. options["group"] selects a capture group instead of the whole match.
+// No match is an input error.
+func regexExtractProcessor(_ context.Context, input string, options map[string]string) (string, error) {
+	pattern, ok := options[OptionPattern]
+	if !ok || pattern == "" {
+		return "", &OptionError{Option: OptionPattern, Err: errors.New("required by regex_extract")}
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", &OptionError{Option: OptionPattern, Err: err}
+	}
+	group := 0
+	if value, ok := options[OptionGroup]; ok {
+		group, err = strconv.Atoi(value)
+		if err != nil || group < 0 || group > re.NumSubexp() {
+			return "", &OptionError{Option: OptionGroup, Err: fmt.Errorf("must be between 0 and %d", re.NumSubexp())}
+		}
+	}
+
+	var matches []string
+	for _, match := range re.FindAllStringSubmatch(input, -1) {
+		matches = append(matches, match[group])
+	}
+	if len(matches) == 0 {
+		return "", &InputError{Processor: RegexExtract, Err: errors.New("pattern did not match the input")}
+	}
+	return strings.Join(matches, "\n"), nil
+}
@@ -0,0 +1,160 @@
+package processor
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuiltins(t *testing.T) {
+	registry := NewBuiltinRegistry()
+
+	tests := []struct {
+		name      string
+		processor string
+		input     string
+		options   map[string]string
+		expected  string
+	}{
+		{"sha256 by default", Hash, "hello", nil, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{"md5", Hash, "hello", map[string]string{OptionAlgorithm: "md5"}, "5d41402abc4b2a76b9719d911017c592"},
+		{"base64 encode", Base64, "hello", nil, "aGVsbG8="},
+		{"base64 decode", Base64, "aGVsbG8=", map[string]string{OptionMode: "decode"}, "hello"},
+		{"json compacts", JSONValidate, "{ \"a\": [1, 2] }", nil, `{"a":[1,2]}`},
+		{"uppercase", Uppercase, "Hello", nil, "HELLO"},
+		{"word count", WordCount, "  one two\tthree\n", nil, "3"},
+		{"word count empty", WordCount, "", nil, "0"},
+		{"regex matches", RegexExtract, "a1 b22 c333", map[string]string{OptionPattern: `\d+`}, "1\n22\n333"},
+		{"regex group", RegexExtract, "id=7 id=9", map[string]string{OptionPattern: `id=(\d)`, OptionGroup: "1"}, "7\n9"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := registry.Run(context.Background(), []string{tt.processor}, tt.input, tt.options)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, out)
+		})
+	}
+}
+
+func TestBuiltins_Errors(t *testing.T) {
+	registry := NewBuiltinRegistry()
+
+	tests := []struct {
+		name      string
+		processor string
+		input     string
+		options   map[string]string
+		inputErr  bool
+	}{
+		{"unknown algorithm", Hash, "x", map[string]string{OptionAlgorithm: "crc32"}, false},
+		{"invalid base64", Base64, "not base64!", map[string]string{OptionMode: "decode"}, true},
+		{"invalid mode", Base64, "x", map[string]string{OptionMode: "rot13"}, false},
+		{"invalid json", JSONValidate, `{"a":`, nil, true},
+		{"missing pattern", RegexExtract, "x", nil, false},
+		{"invalid pattern", RegexExtract, "x", map[string]string{OptionPattern: "("}, false},
+		{"group out of range", RegexExtract, "x", map[string]string{OptionPattern: "x", OptionGroup: "1"}, false},
+		{"no match", RegexExtract, "abc", map[string]string{OptionPattern: `\d`}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := registry.Run(context.Background(), []string{tt.processor}, tt.input, tt.options)
+			require.Error(t, err)
+			var inputErr *InputError
+			var optionErr *OptionError
+			if tt.inputErr {
+				assert.ErrorAs(t, err, &inputErr)
+			} else {
+				assert.ErrorAs(t, err, &optionErr)
+			}
+		})
+	}
+}
+
+func TestSteps(t *testing.T) {
+	steps, err := Steps(map[string]string{OptionProcessor: "hash"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"hash"}, steps)
+
+	steps, err = Steps(map[string]string{OptionPipeline: "uppercase, base64 ,hash"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"uppercase", "base64", "hash"}, steps)
+
+	steps, err = Steps(map[string]string{"other": "x"})
+	require.NoError(t, err)
+	assert.Nil(t, steps)
+
+	_, err = Steps(map[string]string{OptionProcessor: "hash", OptionPipeline: "hash"})
+	var optionErr *OptionError
+	assert.ErrorAs(t, err, &optionErr)
+}
+
+func TestRegistry_Pipeline(t *testing.T) {
+	registry := NewBuiltinRegistry()
+
+	out, err := registry.Run(context.Background(), []string{Uppercase, Base64}, "hi", nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, "SEk=", out)
+}
+
+func TestRegistry_Progress(t *testing.T) {
+	registry := NewBuiltinRegistry()
+	var reports [][2]int
+	ctx := WithProgress(context.Background(), func(done, total int) {
+		reports = append(reports, [2]int{done, total})
+	})
+
+	_, err := registry.Run(ctx, []string{Uppercase, Base64, Hash}, "hi", nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, [][2]int{{1, 3}, {2, 3}, {3, 3}}, reports)
+}
+
+func TestRegistry_UnknownProcessor(t *testing.T) {
+	registry := NewBuiltinRegistry()
+
+	_, err := registry.Run(context.Background(), []string{Uppercase, "reverse"}, "hi", nil)
+
+	assert.ErrorIs(t, err, ErrUnknownProcessor)
+	assert.Contains(t, err.Error(), `"reverse"`)
+	assert.Contains(t, err.Error(), "word_count")
+}
+
+func TestRegistry_Register(t *testing.T) {
+	registry := NewRegistry()
+	reverse := Func(func(_ context.Context, input string, _ map[string]string) (string, error) {
+		runes := []rune(input)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes), nil
+	})
+
+	require.NoError(t, registry.Register("reverse", reverse))
+	assert.Error(t, registry.Register("reverse", reverse), "duplicate names are rejected")
+	assert.Error(t, registry.Register("a,b", reverse))
+	assert.Error(t, registry.Register("", reverse))
+	assert.Equal(t, []string{"reverse"}, registry.Names())
+
+	out, err := registry.Run(context.Background(), []string{"reverse"}, "abc", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "cba", out)
+}
+
+func TestRegistry_WrapsProcessorErrors(t *testing.T) {
+	registry := NewRegistry()
+	failure := errors.New("backend unavailable")
+	registry.MustRegister("remote", Func(func(context.Context, string, map[string]string) (string, error) {
+		return "", failure
+	}))
+
+	_, err := registry.Run(context.Background(), []string{"remote"}, "x", nil)
+
+	assert.ErrorIs(t, err, failure)
+	assert.EqualError(t, err, "remote: backend unavailable")
+}
@@ -0,0 +1,20 @@
+package processor
+
+import "context"
+
+// ProgressFunc receives the number of pipeline steps completed so far
+type ProgressFunc func(done, total int)
+
+type progressKey struct{}
+
+// WithProgress returns a context under which Run reports progress to fn
+// after every step
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+func reportProgress(ctx context.Context, done, total int) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok {
+		fn(done, total)
+	}
+}
@@ -0,0 +1,199 @@
+// Package processor transforms ProcessData input with named processors that
+// can be chained into pipelines.
+package processor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Request options that select processors
+const (
+	OptionProcessor = "processor"
+	OptionPipeline  = "pipeline"
+)
+
+// MaxPipelineLength limits the number of steps in a pipeline
+const MaxPipelineLength = 16
+
+// ErrUnknownProcessor is returned when a request names a processor that is
+// not registered
+var ErrUnknownProcessor = errors.New("unknown processor")
+
+// Processor transforms its input. Options are the request's options, shared
+// by every step of a pipeline.
+type Processor interface {
+	Process(ctx context.Context, input string, options map[string]string) (string, error)
+}
+
+// Func adapts a function to a Processor
+type Func func(ctx context.Context, input string, options map[string]string) (string, error)
+
+// Process implements Processor
+func (f Func) Process(ctx context.Context, input string, options map[string]string) (string, error) {
+	return f(ctx, input, options)
+}
+
+// InputError reports input a processor could not handle, e.g. invalid JSON.
+// It describes a problem with the data rather than with the request.
+type InputError struct {
+	Processor string
+	Err       error
+}
+
+func (e *InputError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Processor, e.Err)
+}
+
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+// OptionError reports a missing or invalid option
+type OptionError struct {
+	Option string
+	Err    error
+}
+
+func (e *OptionError) Error() string {
+	return fmt.Sprintf("option %q: %v", e.Option, e.Err)
+}
+
+func (e *OptionError) Unwrap() error {
+	return e.Err
+}
+
+// Registry holds processors by name. It is safe for concurrent use.
+type Registry struct {
+	mu         sync.RWMutex
+	processors map[string]Processor
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{processors: make(map[string]Processor)}
+}
+
+// NewBuiltinRegistry creates a registry holding the built-in processors
+func NewBuiltinRegistry() *Registry {
+	r := NewRegistry()
+	for name, p := range builtins() {
+		r.MustRegister(name, p)
+	}
+	return r
+}
+
+// Register adds a processor under name, which must not be taken
+func (r *Registry) Register(name string, p Processor) error {
+	if name == "" || strings.ContainsAny(name, ", ") {
+		return fmt.Errorf("invalid processor name %q", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.processors[name]; ok {
+		return fmt.Errorf("processor %q already registered", name)
+	}
+	r.processors[name] = p
+	return nil
+}
+
+// MustRegister is like Register but panics on error
+func (r *Registry) MustRegister(name string, p Processor) {
+	if err := r.Register(name, p); err != nil {
+		panic(err)
+	}
+}
+
+// Lookup returns the processor registered under name
+func (r *Registry) Lookup(name string) (Processor, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.processors[name]
+	return p, ok
+}
+
+// Names returns the registered processor names in order
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.processors))
+	for name := range r.processors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Steps returns the processors selected by options: a single
+// options["processor"] or a comma separated options["pipeline"]. It returns
+// nil when neither is set.
+func Steps(options map[string]string) ([]string, error) {
+	single, hasSingle := options[OptionProcessor]
+	pipeline, hasPipeline := options[OptionPipeline]
+	switch {
+	case hasSingle && hasPipeline:
+		return nil, &OptionError{Option: OptionPipeline, Err: errors.New("cannot be combined with processor")}
+	case hasSingle:
+		return []string{strings.TrimSpace(single)}, nil
+	case hasPipeline:
+		var steps []string
+		for _, step := range strings.Split(pipeline, ",") {
+			steps = append(steps, strings.TrimSpace(step))
+		}
+		if len(steps) > MaxPipelineLength {
+			return nil, &OptionError{Option: OptionPipeline, Err: fmt.Errorf("at most %d steps allowed, got %d", MaxPipelineLength, len(steps))}
+		}
+		return steps, nil
+	default:
+		return nil, nil
+	}
+}
+
+// Run passes input through the named processors in order, reporting
+// progress to the ProgressFunc of ctx if it has one (see WithProgress)
+func (r *Registry) Run(ctx context.Context, steps []string, input string, options map[string]string) (string, error) {
+	processors := make([]Processor, len(steps))
+	for i, name := range steps {
+		p, ok := r.Lookup(name)
+		if !ok {
+			return "", fmt.Errorf("%w %q (available: %s)", ErrUnknownProcessor, name, strings.Join(r.Names(), ", "))
+		}
+		processors[i] = p
+	}
+
+	output := input
+	for i, p := range processors {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		var err error
+		output, err = p.Process(ctx, output, options)
+		if err != nil {
+			var inputErr *InputError
+			if errors.As(err, &inputErr) || errors.As(err, new(*OptionError)) {
+				return "", err
+			}
+			return "", fmt.Errorf("%s: %w", steps[i], err)
+		}
+		reportProgress(ctx, i+1, len(processors))
+	}
+	return output, nil
+}
+
+var defaultRegistry = NewBuiltinRegistry()
+
+// Default returns the process-wide registry used by GrpcService unless
+// another one is configured. It starts with the built-in processors.
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register adds a processor to the default registry, typically from an init
+// function
+func Register(name string, p Processor) error {
+	return defaultRegistry.Register(name, p)
+}
@@ -0,0 +1,188 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/auth"
+)
+
+// RetryAfterHeader tells limited callers how many seconds to wait
+const RetryAfterHeader = "Retry-After"
+
+// Results recorded in the requests metric
+const (
+	resultAllowed = "allowed"
+	resultLimited = "limited"
+	resultError   = "error"
+)
+
+// errRateLimited is returned to callers that ran out of tokens
+var errRateLimited = errors.New("rate limit exceeded")
+
+// Interceptor limits handler calls per procedure and caller. Callers are
+// identified by their authenticated principal (e.g. JWT subject or API key)
+// and otherwise by client IP. Store failures let the request through.
+type Interceptor struct {
+	store             Store
+	limits            *Limits
+	trustForwardedFor bool
+	trustedProxies    int
+	requests          *prometheus.CounterVec
+}
+
+// Option configures an Interceptor
+type Option func(*Interceptor)
+
+// WithTrustForwardedFor identifies anonymous callers by X-Forwarded-For, for
+// servers behind trusted proxies. The caller is the address the outermost
+// trusted proxy appended, not the client-supplied first entry.
+func WithTrustForwardedFor(trust bool) Option {
+	return func(i *Interceptor) {
+		i.trustForwardedFor = trust
+	}
+}
+
+// WithTrustedProxies sets how many trusted proxies append to
+// X-Forwarded-For in front of the server. The default is 1.
+func WithTrustedProxies(n int) Option {
+	return func(i *Interceptor) {
+		i.trustedProxies = n
+	}
+}
+
+// WithRegisterer registers the limiter metrics with reg
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(i *Interceptor) {
+		i.requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "ratelimit_requests_total",
+			Help: "Requests checked by the rate limiter, by procedure and result.",
+		}, []string{"procedure", "result"})
+		reg.MustRegister(i.requests)
+		if sizer, ok := i.store.(interface{ Len() int }); ok {
+			reg.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+				Name: "ratelimit_buckets",
+				Help: "Token buckets currently tracked by the rate limiter.",
+			}, func() float64 { return float64(sizer.Len()) }))
+		}
+	}
+}
+
+// NewInterceptor creates a rate limiting interceptor
+func NewInterceptor(store Store, limits *Limits, opts ...Option) *Interceptor {
+	i := &Interceptor{store: store, limits: limits, trustedProxies: 1}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// WrapUnary implements connect.Interceptor
+func (i *Interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		if err := i.take(ctx, req.Spec().Procedure, req.Peer(), req.Header()); err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor
+func (i *Interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor. A stream takes one
+// token when it starts.
+func (i *Interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		if err := i.take(ctx, conn.Spec().Procedure, conn.Peer(), conn.RequestHeader()); err != nil {
+			return err
+		}
+		return next(ctx, conn)
+	}
+}
+
+func (i *Interceptor) take(ctx context.Context, procedure string, peer connect.Peer, header http.Header) error {
+	limit := i.limits.For(procedure)
+	if limit.Unlimited() {
+		return nil
+	}
+
+	result, err := i.store.Take(ctx, procedure+"|"+i.callerKey(ctx, peer, header), limit)
+	if err != nil {
+		i.record(procedure, resultError)
+		return nil
+	}
+	if result.Allowed {
+		i.record(procedure, resultAllowed)
+		return nil
+	}
+
+	i.record(procedure, resultLimited)
+	return limitedError(result)
+}
+
+// callerKey identifies the caller a bucket belongs to
+func (i *Interceptor) callerKey(ctx context.Context, peer connect.Peer, header http.Header) string {
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		return principal.ID()
+	}
+	if i.trustForwardedFor {
+		if client, ok := i.forwardedFor(header); ok {
+			return "ip:" + client
+		}
+	}
+	host, _, err := net.SplitHostPort(peer.Addr)
+	if err != nil {
+		host = peer.Addr
+	}
+	return "ip:" + host
+}
+
+// forwardedFor returns the client address appended by the outermost trusted
+// proxy: the entry trustedProxies from the right of X-Forwarded-For. Entries
+// left of it come from the client and could be anything.
+func (i *Interceptor) forwardedFor(header http.Header) (string, bool) {
+	var entries []string
+	for _, value := range header.Values("X-Forwarded-For") {
+		entries = append(entries, strings.Split(value, ",")...)
+	}
+	if i.trustedProxies < 1 || len(entries) < i.trustedProxies {
+		return "", false
+	}
+	client := strings.TrimSpace(entries[len(entries)-i.trustedProxies])
+	return client, client != ""
+}
+
+func (i *Interceptor) record(procedure, result string) {
+	if i.requests != nil {
+		i.requests.WithLabelValues(procedure, result).Inc()
+	}
+}
+
+// limitedError creates a ResourceExhausted error with the RATE_LIMITED
+// reason, carrying the retry delay as a Retry-After header and a RetryInfo
+// detail
+func limitedError(result Result) *connect.Error {
+	err := apierror.New(connect.CodeResourceExhausted, apierror.ReasonRateLimited, errRateLimited,
+		apierror.WithRetryDelay(result.RetryAfter))
+	seconds := int(math.Ceil(result.RetryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	err.Meta().Set(RetryAfterHeader, strconv.Itoa(seconds))
+	return err
+}
@@ -0,0 +1,92 @@
+// Package ratelimit throttles callers with token buckets kept in a
+// pluggable store.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit is a token bucket refilled at Rate tokens per second up to Burst
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited reports whether the limit lets every request through
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0
+}
+
+// String describes a limit, e.g. "5/s burst 10"
+func (l Limit) String() string {
+	if l.Unlimited() {
+		return "unlimited"
+	}
+	return fmt.Sprintf("%g/s burst %d", l.Rate, l.Burst)
+}
+
+// Result is the outcome of taking a token from a bucket
+type Result struct {
+	Allowed bool
+	// Remaining is the number of whole tokens left after the request
+	Remaining int
+	// RetryAfter is how long until a token is available when not allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps token buckets. Implementations must be safe for concurrent
+// use; a shared store lets several replicas enforce one limit.
+type Store interface {
+	// Take removes one token from the bucket identified by key, creating
+	// it full when it does not exist
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Limits holds the default limit and per-procedure overrides
+type Limits struct {
+	Default    Limit
+	Procedures map[string]Limit
+}
+
+// ParseLimits builds limits from a default and overrides of the form
+// "procedure=rate:burst", where procedure is a method name such as
+// "ProcessData" or a full procedure name. A rate of 0 disables limiting.
+func ParseLimits(defaultLimit Limit, overrides []string) (*Limits, error) {
+	limits := &Limits{Default: defaultLimit, Procedures: make(map[string]Limit, len(overrides))}
+	for _, override := range overrides {
+		procedure, value, ok := strings.Cut(override, "=")
+		if !ok || procedure == "" {
+			return nil, fmt.Errorf("rate limit %q: expected procedure=rate:burst", override)
+		}
+		rateText, burstText, ok := strings.Cut(value, ":")
+		if !ok {
+			return nil, fmt.Errorf("rate limit %q: expected procedure=rate:burst", override)
+		}
+		rate, err := strconv.ParseFloat(rateText, 64)
+		if err != nil || rate < 0 {
+			return nil, fmt.Errorf("rate limit %q: invalid rate %q", override, rateText)
+		}
+		burst, err := strconv.Atoi(burstText)
+		if err != nil || (rate > 0 && burst < 1) {
+			return nil, fmt.Errorf("rate limit %q: invalid burst %q", override, burstText)
+		}
+		limits.Procedures[procedure] = Limit{Rate: rate, Burst: burst}
+	}
+	return limits, nil
+}
+
+// For returns the limit applying to a procedure
+func (l *Limits) For(procedure string) Limit {
+	if limit, ok := l.Procedures[procedure]; ok {
+		return limit
+	}
+	method := procedure[strings.LastIndex(procedure, "/")+1:]
+	if limit, ok := l.Procedures[method]; ok {
+		return limit
+	}
+	return l.Default
+}
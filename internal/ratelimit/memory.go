@@ -0,0 +1,86 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often full buckets are dropped from a MemoryStore
+const sweepInterval = time.Minute
+
+// MemoryStore keeps buckets in process memory. Buckets that have refilled
+// completely are dropped, since a new bucket starts full anyway.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	// fullAt is when the bucket will have refilled to its burst
+	fullAt time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{now: time.Now, buckets: make(map[string]*bucket)}
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	if limit.Unlimited() {
+		return Result{Allowed: true, Remaining: limit.Burst}, nil
+	}
+
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	burst := float64(limit.Burst)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, updated: now}
+		s.buckets[key] = b
+	} else {
+		elapsed := now.Sub(b.updated).Seconds()
+		b.tokens = math.Min(burst, b.tokens+elapsed*limit.Rate)
+		b.updated = now
+	}
+
+	result := Result{}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+		result.Remaining = int(b.tokens)
+	} else {
+		result.RetryAfter = time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+	}
+	b.fullAt = now.Add(time.Duration((burst - b.tokens) / limit.Rate * float64(time.Second)))
+	return result, nil
+}
+
+// Len returns the number of tracked buckets
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if !now.Before(b.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
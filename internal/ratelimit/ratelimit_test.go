@@ -0,0 +1,194 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api/apiv1connect"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/auth"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/server"
+)
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits(Limit{Rate: 10, Burst: 20}, []string{
+		"StreamData=0.5:1",
+		"/api.v1.GrpcService/GetInfo=0:0",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, Limit{Rate: 0.5, Burst: 1}, limits.For(apiv1connect.GrpcServiceStreamDataProcedure))
+	assert.True(t, limits.For(apiv1connect.GrpcServiceGetInfoProcedure).Unlimited())
+	assert.Equal(t, Limit{Rate: 10, Burst: 20}, limits.For(apiv1connect.GrpcServiceProcessDataProcedure))
+
+	for _, bad := range []string{"StreamData", "=1:1", "StreamData=1", "StreamData=x:1", "StreamData=-1:1", "StreamData=1:0"} {
+		_, err := ParseLimits(Limit{}, []string{bad})
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	now := time.Unix(1000, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Rate: 2, Burst: 3}
+	ctx := context.Background()
+
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take(ctx, "k", limit)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, err := store.Take(ctx, "k", limit)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	// Other keys have their own bucket
+	result, err = store.Take(ctx, "other", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+
+	now = now.Add(500 * time.Millisecond)
+	result, err = store.Take(ctx, "k", limit)
+	require.NoError(t, err)
+	assert.True(t, result.Allowed, "one token refilled")
+}
+
+func TestMemoryStore_SweepsFullBuckets(t *testing.T) {
+	now := time.Unix(1000, 0)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err := store.Take(ctx, "a", Limit{Rate: 1, Burst: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Len())
+
+	now = now.Add(sweepInterval)
+	_, err = store.Take(ctx, "b", Limit{Rate: 1, Burst: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Len(), "refilled bucket a is dropped")
+}
+
+func newTestServer(t *testing.T, interceptors ...connect.Interceptor) apiv1connect.GrpcServiceClient {
+	mux := http.NewServeMux()
+	mux.Handle(apiv1connect.NewGrpcServiceHandler(
+		server.NewGrpcService(logrus.New()),
+		connect.WithInterceptors(interceptors...),
+	))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return apiv1connect.NewGrpcServiceClient(srv.Client(), srv.URL)
+}
+
+func TestInterceptor_LimitsPerCaller(t *testing.T) {
+	limits, err := ParseLimits(Limit{Rate: 0.001, Burst: 1}, []string{"GetHealth=0:0"})
+	require.NoError(t, err)
+	reg := prometheus.NewRegistry()
+	limiter := NewInterceptor(NewMemoryStore(), limits, WithTrustForwardedFor(true), WithTrustedProxies(2), WithRegisterer(reg))
+	client := newTestServer(t, limiter)
+	ctx := context.Background()
+
+	call := func(ip string) error {
+		req := connect.NewRequest(&apiv1.GetInfoRequest{})
+		req.Header().Set("X-Forwarded-For", ip+", 10.0.0.1")
+		_, err := client.GetInfo(ctx, req)
+		return err
+	}
+
+	require.NoError(t, call("192.0.2.1"))
+	err = call("192.0.2.1")
+	require.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	assert.NotEmpty(t, connectErr.Meta().Get(RetryAfterHeader))
+	assert.Equal(t, apierror.ReasonRateLimited, apierror.Reason(err))
+	assert.NotNil(t, apierror.DetailsOf(err).Retry)
+
+	require.NoError(t, call("192.0.2.2"), "other callers have their own bucket")
+
+	for i := 0; i < 3; i++ {
+		_, err := client.GetHealth(ctx, connect.NewRequest(&apiv1.GetHealthRequest{}))
+		require.NoError(t, err, "unlimited procedure")
+	}
+
+	expected := `
+# HELP ratelimit_requests_total Requests checked by the rate limiter, by procedure and result.
+# TYPE ratelimit_requests_total counter
+ratelimit_requests_total{procedure="/api.v1.GrpcService/GetInfo",result="allowed"} 2
+ratelimit_requests_total{procedure="/api.v1.GrpcService/GetInfo",result="limited"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "ratelimit_requests_total"))
+	assert.Equal(t, 1, testutil.CollectAndCount(reg, "ratelimit_buckets"))
+}
+
+func TestInterceptor_KeysByPrincipal(t *testing.T) {
+	limiter := NewInterceptor(NewMemoryStore(), &Limits{Default: Limit{Rate: 1, Burst: 1}})
+	ctx := context.Background()
+	peer := connect.Peer{Addr: "192.0.2.1:1234"}
+
+	assert.Equal(t, "ip:192.0.2.1", limiter.callerKey(ctx, peer, http.Header{}))
+	assert.Equal(t, "ip:192.0.2.1", limiter.callerKey(ctx, peer, http.Header{"X-Forwarded-For": {"203.0.113.9"}}),
+		"X-Forwarded-For is ignored unless trusted")
+
+	ctx = auth.WithPrincipal(ctx, &auth.Principal{Name: "batch", Method: auth.MethodAPIKey})
+	assert.Equal(t, "api_key:batch", limiter.callerKey(ctx, peer, http.Header{}))
+}
+
+func TestInterceptor_ForwardedFor(t *testing.T) {
+	limiter := NewInterceptor(NewMemoryStore(), &Limits{Default: Limit{Rate: 1, Burst: 1}}, WithTrustForwardedFor(true))
+	ctx := context.Background()
+	peer := connect.Peer{Addr: "10.0.0.1:1234"}
+	forwarded := func(values ...string) http.Header {
+		return http.Header{"X-Forwarded-For": values}
+	}
+
+	assert.Equal(t, "ip:192.0.2.1", limiter.callerKey(ctx, peer, forwarded("192.0.2.1")))
+	for _, spoofed := range []string{"203.0.113.9", "198.51.100.7, 203.0.113.9", ""} {
+		assert.Equal(t, "ip:192.0.2.1", limiter.callerKey(ctx, peer, forwarded(spoofed+", 192.0.2.1")),
+			"client-supplied entries do not change the bucket")
+	}
+	assert.Equal(t, "ip:192.0.2.1", limiter.callerKey(ctx, peer, forwarded("203.0.113.9", "192.0.2.1")),
+		"repeated headers are one list")
+	assert.Equal(t, "ip:10.0.0.1", limiter.callerKey(ctx, peer, http.Header{}))
+
+	behindTwo := NewInterceptor(NewMemoryStore(), &Limits{}, WithTrustForwardedFor(true), WithTrustedProxies(2))
+	assert.Equal(t, "ip:192.0.2.1", behindTwo.callerKey(ctx, peer, forwarded("203.0.113.9, 192.0.2.1, 10.0.0.2")))
+	assert.Equal(t, "ip:10.0.0.1", behindTwo.callerKey(ctx, peer, forwarded("192.0.2.1")),
+		"too few entries fall back to the peer address")
+}
+
+func TestInterceptor_Streams(t *testing.T) {
+	limits, err := ParseLimits(Limit{}, []string{"StreamData=0.001:1"})
+	require.NoError(t, err)
+	client := newTestServer(t, NewInterceptor(NewMemoryStore(), limits))
+	ctx := context.Background()
+
+	stream, err := client.StreamData(ctx, connect.NewRequest(&apiv1.StreamDataRequest{Query: "q", Limit: 1}))
+	require.NoError(t, err)
+	for stream.Receive() {
+	}
+	require.NoError(t, stream.Err())
+	require.NoError(t, stream.Close())
+
+	stream, err = client.StreamData(ctx, connect.NewRequest(&apiv1.StreamDataRequest{Query: "q", Limit: 1}))
+	require.NoError(t, err)
+	assert.False(t, stream.Receive())
+	assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(stream.Err()))
+	require.NoError(t, stream.Close())
+}
@@ -0,0 +1,139 @@
+// Package recovery turns handler panics into Internal errors.
+package recovery
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/logging"
+)
+
+// Field names added to the log line of a recovered panic
+const (
+	FieldErrorID = "error_id"
+	FieldPanic   = "panic"
+	FieldStack   = "stack"
+)
+
+// Interceptor recovers panics in unary and streaming handlers. The panic
+// and its stack are logged with an error ID, and the caller receives
+// CodeInternal carrying only that ID in a RequestInfo detail. In debug mode
+// the panic value and stack are also sent in a DebugInfo detail.
+type Interceptor struct {
+	logger *logrus.Logger
+	debug  bool
+	panics *prometheus.CounterVec
+}
+
+// Option configures an Interceptor
+type Option func(*Interceptor)
+
+// WithDebug includes panic details in the returned error
+func WithDebug(debug bool) Option {
+	return func(i *Interceptor) {
+		i.debug = debug
+	}
+}
+
+// WithRegisterer registers the panic counter with reg
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(i *Interceptor) {
+		i.panics = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_req_panics_recovered_total",
+			Help: "Total number of RPC handler panics recovered.",
+		}, []string{"grpc_service", "grpc_method"})
+		reg.MustRegister(i.panics)
+	}
+}
+
+// NewInterceptor creates a recovery interceptor logging to logger
+func NewInterceptor(logger *logrus.Logger, opts ...Option) *Interceptor {
+	i := &Interceptor{logger: logger}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// WrapUnary implements connect.Interceptor
+func (i *Interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (resp connect.AnyResponse, err error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		defer func() {
+			if r := recover(); r != nil {
+				resp, err = nil, i.recovered(ctx, req.Spec().Procedure, r)
+			}
+		}()
+		return next(ctx, req)
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor
+func (i *Interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+// WrapStreamingHandler implements connect.Interceptor
+func (i *Interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = i.recovered(ctx, conn.Spec().Procedure, r)
+			}
+		}()
+		return next(ctx, conn)
+	}
+}
+
+func (i *Interceptor) recovered(ctx context.Context, procedure string, r any) error {
+	// net/http uses this panic to abort a response deliberately
+	if r == http.ErrAbortHandler {
+		panic(r)
+	}
+
+	errorID := newErrorID()
+	stack := string(debug.Stack())
+	logging.FromContext(ctx, i.logger).WithFields(logrus.Fields{
+		FieldErrorID: errorID,
+		FieldPanic:   fmt.Sprint(r),
+		FieldStack:   stack,
+	}).Error("Recovered from panic in handler")
+
+	if i.panics != nil {
+		service, method, _ := strings.Cut(strings.TrimPrefix(procedure, "/"), "/")
+		i.panics.WithLabelValues(service, method).Inc()
+	}
+
+	err := apierror.New(connect.CodeInternal, apierror.ReasonInternal, fmt.Errorf("internal error (error ID %s)", errorID))
+	if detail, detailErr := connect.NewErrorDetail(&errdetails.RequestInfo{RequestId: errorID}); detailErr == nil {
+		err.AddDetail(detail)
+	}
+	if i.debug {
+		if detail, detailErr := connect.NewErrorDetail(&errdetails.DebugInfo{
+			Detail:       fmt.Sprint(r),
+			StackEntries: strings.Split(strings.TrimSpace(stack), "\n"),
+		}); detailErr == nil {
+			err.AddDetail(detail)
+		}
+	}
+	return err
+}
+
+func newErrorID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
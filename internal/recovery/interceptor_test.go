@@ -0,0 +1,123 @@
+package recovery
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api/apiv1connect"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+)
+
+// panicking panics in ProcessData and after the first StreamData message
+type panicking struct {
+	apiv1connect.UnimplementedGrpcServiceHandler
+}
+
+func (panicking) ProcessData(context.Context, *connect.Request[apiv1.ProcessDataRequest]) (*connect.Response[apiv1.ProcessDataResponse], error) {
+	panic("processor exploded")
+}
+
+func (panicking) StreamData(_ context.Context, _ *connect.Request[apiv1.StreamDataRequest], stream *connect.ServerStream[apiv1.StreamDataResponse]) error {
+	if err := stream.Send(&apiv1.StreamDataResponse{Sequence: 1}); err != nil {
+		return err
+	}
+	var m map[string]int
+	m["boom"]++
+	return nil
+}
+
+func newTestClient(t *testing.T, interceptor *Interceptor) apiv1connect.GrpcServiceClient {
+	mux := http.NewServeMux()
+	mux.Handle(apiv1connect.NewGrpcServiceHandler(panicking{}, connect.WithInterceptors(interceptor)))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return apiv1connect.NewGrpcServiceClient(srv.Client(), srv.URL)
+}
+
+func details(t *testing.T, err error) []any {
+	var connectErr *connect.Error
+	require.ErrorAs(t, err, &connectErr)
+	var values []any
+	for _, detail := range connectErr.Details() {
+		value, err := detail.Value()
+		require.NoError(t, err)
+		values = append(values, value)
+	}
+	return values
+}
+
+func TestInterceptor_Unary(t *testing.T) {
+	var logs bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logs)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	reg := prometheus.NewRegistry()
+	client := newTestClient(t, NewInterceptor(logger, WithRegisterer(reg)))
+
+	_, err := client.ProcessData(context.Background(), connect.NewRequest(&apiv1.ProcessDataRequest{}))
+
+	require.Equal(t, connect.CodeInternal, connect.CodeOf(err))
+	assert.NotContains(t, err.Error(), "exploded", "panic values are not leaked")
+	assert.Equal(t, apierror.ReasonInternal, apierror.Reason(err))
+	values := details(t, err)
+	require.Len(t, values, 3, "ErrorInfo, LocalizedMessage and RequestInfo")
+	errorID := values[2].(*errdetails.RequestInfo).RequestId
+	assert.Contains(t, err.Error(), errorID)
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &line))
+	assert.Equal(t, "error", line["level"])
+	assert.Equal(t, errorID, line[FieldErrorID])
+	assert.Equal(t, "processor exploded", line[FieldPanic])
+	assert.Contains(t, line[FieldStack], "recovery.panicking.ProcessData")
+
+	expected := `
+# HELP grpc_req_panics_recovered_total Total number of RPC handler panics recovered.
+# TYPE grpc_req_panics_recovered_total counter
+grpc_req_panics_recovered_total{grpc_method="ProcessData",grpc_service="api.v1.GrpcService"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "grpc_req_panics_recovered_total"))
+}
+
+func TestInterceptor_StreamingDebug(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(&bytes.Buffer{})
+	client := newTestClient(t, NewInterceptor(logger, WithDebug(true)))
+
+	stream, err := client.StreamData(context.Background(), connect.NewRequest(&apiv1.StreamDataRequest{}))
+	require.NoError(t, err)
+	require.True(t, stream.Receive(), "messages sent before the panic are delivered")
+	assert.False(t, stream.Receive())
+	streamErr := stream.Err()
+	require.NoError(t, stream.Close())
+
+	require.Equal(t, connect.CodeInternal, connect.CodeOf(streamErr))
+	assert.Equal(t, apierror.ReasonInternal, apierror.Reason(streamErr))
+	values := details(t, streamErr)
+	require.Len(t, values, 4)
+	debugInfo := values[3].(*errdetails.DebugInfo)
+	assert.Contains(t, debugInfo.Detail, "assignment to entry in nil map")
+	assert.NotEmpty(t, debugInfo.StackEntries)
+}
+
+func TestInterceptor_AbortHandler(t *testing.T) {
+	interceptor := NewInterceptor(logrus.New())
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		_ = interceptor.recovered(context.Background(), "/api.v1.GrpcService/ProcessData", http.ErrAbortHandler)
+	})
+}
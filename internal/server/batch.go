@@ -0,0 +1,82 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/logging"
+)
+
+// BatchProcessData processes every item like ProcessData on a pool of
+// process.batch_workers goroutines and returns the results in item order.
+// Items fail individually; the call itself only fails for batches over
+// process.max_batch_size or when it is canceled.
+func (s *GrpcService) BatchProcessData(ctx context.Context, req *connect.Request[apiv1.BatchProcessDataRequest]) (*connect.Response[apiv1.BatchProcessDataResponse], error) {
+	log := logging.FromContext(ctx, s.logger)
+	log.Info("BatchProcessData called")
+
+	items := req.Msg.Items
+	if maxSize := s.config.Process.MaxBatchSize; len(items) > maxSize {
+		return nil, apierror.New(connect.CodeInvalidArgument, apierror.ReasonLimitExceeded,
+			fmt.Errorf("batch must have at most %d items, got %d", maxSize, len(items)),
+			apierror.WithFieldViolation("items", fmt.Sprintf("must have at most %d items, got %d", maxSize, len(items))))
+	}
+
+	started := s.clock.Now()
+	results := make([]*apiv1.BatchProcessDataResult, len(items))
+	indexes := make(chan int)
+	var workers sync.WaitGroup
+	for w := 0; w < min(s.config.Process.BatchWorkers, len(items)); w++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for i := range indexes {
+				response, seedText, code := s.processItem(ctx, items[i].Data, items[i].Options)
+				results[i] = &apiv1.BatchProcessDataResult{
+					Result:       response.Result,
+					Success:      response.Success,
+					ErrorMessage: response.ErrorMessage,
+					ErrorCode:    code,
+					ErrorReason:  response.ErrorReason,
+					ProcessedAt:  response.ProcessedAt,
+					Seed:         seedText,
+				}
+			}
+		}()
+	}
+	for i := range items {
+		indexes <- i
+	}
+	close(indexes)
+	workers.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, apierror.From(ctx, err)
+	}
+
+	stats := &apiv1.BatchProcessDataStats{
+		Total:    int32(len(results)),
+		Duration: durationpb.New(s.clock.Now().Sub(started)),
+	}
+	for _, result := range results {
+		if result.Success {
+			stats.Succeeded++
+		} else {
+			stats.Failed++
+		}
+	}
+	log.WithFields(logrus.Fields{
+		"total":     stats.Total,
+		"succeeded": stats.Succeeded,
+		"failed":    stats.Failed,
+	}).Info("BatchProcessData completed")
+
+	return connect.NewResponse(&apiv1.BatchProcessDataResponse{Results: results, Stats: stats}), nil
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/config"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/processor"
+)
+
+func TestGrpcService_BatchProcessData(t *testing.T) {
+	service, _ := newTestService()
+
+	req := connect.NewRequest(&apiv1.BatchProcessDataRequest{Items: []*apiv1.ProcessDataRequest{
+		{Data: "hello", Options: map[string]string{"processor": "uppercase"}},
+		{Data: `{"broken":`, Options: map[string]string{"processor": "json_validate"}},
+		{Data: "x", Options: map[string]string{"processor": "reverse"}},
+		{Options: map[string]string{"seed": "42"}},
+	}})
+
+	resp, err := service.BatchProcessData(context.Background(), req)
+
+	require.NoError(t, err)
+	results := resp.Msg.Results
+	require.Len(t, results, 4)
+
+	assert.True(t, results[0].Success)
+	assert.Equal(t, "HELLO", results[0].Result)
+	assert.Equal(t, fixedTime, results[0].ProcessedAt.AsTime())
+
+	assert.False(t, results[1].Success)
+	assert.Contains(t, results[1].ErrorMessage, "invalid JSON")
+	assert.Empty(t, results[1].ErrorCode)
+
+	assert.False(t, results[2].Success)
+	assert.Equal(t, "invalid_argument", results[2].ErrorCode)
+	assert.Contains(t, results[2].ErrorMessage, `unknown processor "reverse"`)
+
+	assert.True(t, results[3].Success)
+	assert.Equal(t, "306", results[3].Result)
+	assert.Equal(t, "42", results[3].Seed)
+
+	assert.Equal(t, int32(4), resp.Msg.Stats.Total)
+	assert.Equal(t, int32(2), resp.Msg.Stats.Succeeded)
+	assert.Equal(t, int32(2), resp.Msg.Stats.Failed)
+	assert.NotNil(t, resp.Msg.Stats.Duration)
+}
+
+func TestGrpcService_BatchProcessData_MatchesProcessData(t *testing.T) {
+	service, _ := newTestService()
+	items := []*apiv1.ProcessDataRequest{
+		{Data: "hello world", Options: map[string]string{"pipeline": "uppercase,base64"}},
+		{Data: "a b c", Options: map[string]string{"processor": "word_count"}},
+		{Options: map[string]string{"seed": "7"}},
+	}
+
+	resp, err := service.BatchProcessData(context.Background(), connect.NewRequest(&apiv1.BatchProcessDataRequest{Items: items}))
+	require.NoError(t, err)
+
+	for i, item := range items {
+		single, err := service.ProcessData(context.Background(), connect.NewRequest(item))
+		require.NoError(t, err)
+		assert.Equal(t, single.Msg.Result, resp.Msg.Results[i].Result)
+		assert.Equal(t, single.Msg.Success, resp.Msg.Results[i].Success)
+	}
+}
+
+func TestGrpcService_BatchProcessData_Empty(t *testing.T) {
+	service, _ := newTestService()
+
+	resp, err := service.BatchProcessData(context.Background(), connect.NewRequest(&apiv1.BatchProcessDataRequest{}))
+
+	require.NoError(t, err)
+	assert.Empty(t, resp.Msg.Results)
+	assert.Zero(t, resp.Msg.Stats.Total)
+}
+
+func TestGrpcService_BatchProcessData_TooLarge(t *testing.T) {
+	cfg := config.Default()
+	cfg.Process.MaxBatchSize = 2
+	service, _ := newTestService(WithConfig(cfg))
+
+	req := connect.NewRequest(&apiv1.BatchProcessDataRequest{Items: make([]*apiv1.ProcessDataRequest, 3)})
+	_, err := service.BatchProcessData(context.Background(), req)
+
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	assert.Contains(t, err.Error(), "batch must have at most 2 items, got 3")
+}
+
+func TestGrpcService_BatchProcessData_Workers(t *testing.T) {
+	cfg := config.Default()
+	cfg.Process.BatchWorkers = 3
+	var (
+		mu        sync.Mutex
+		active    int
+		maxActive int
+	)
+	registry := processor.NewRegistry()
+	registry.MustRegister("slow", processor.Func(func(_ context.Context, input string, _ map[string]string) (string, error) {
+		mu.Lock()
+		active++
+		maxActive = max(maxActive, active)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return input, nil
+	}))
+	service, _ := newTestService(WithConfig(cfg), WithProcessors(registry))
+
+	items := make([]*apiv1.ProcessDataRequest, 12)
+	for i := range items {
+		items[i] = &apiv1.ProcessDataRequest{Data: string(rune('a' + i)), Options: map[string]string{"processor": "slow"}}
+	}
+	resp, err := service.BatchProcessData(context.Background(), connect.NewRequest(&apiv1.BatchProcessDataRequest{Items: items}))
+
+	require.NoError(t, err)
+	for i, result := range resp.Msg.Results {
+		assert.Equal(t, items[i].Data, result.Result, "results keep the order of the items")
+	}
+	assert.LessOrEqual(t, maxActive, 3)
+	assert.Greater(t, maxActive, 1, "items are processed concurrently")
+}
+
+func TestGrpcService_BatchProcessData_Canceled(t *testing.T) {
+	service, _ := newTestService()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req := connect.NewRequest(&apiv1.BatchProcessDataRequest{Items: []*apiv1.ProcessDataRequest{{Data: "x"}}})
+	_, err := service.BatchProcessData(ctx, req)
+
+	assert.Equal(t, connect.CodeCanceled, connect.CodeOf(err))
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+)
+
+// errInvalidCursor is returned for resume tokens that cannot be decoded or
+// belong to a stream with a different query
+var errInvalidCursor = errors.New("invalid resume token")
+
+// streamCursor is the content of a StreamData resume token. The query is
+// stored as a hash so tokens stay short and do not echo the query back.
+// Cursors are not signed: the sequence is no more trusted than
+// start_after_sequence, so they work across restarts and replicas.
+type streamCursor struct {
+	QueryHash string `json:"q"`
+	Sequence  int32  `json:"s"`
+}
+
+// encodeCursor returns the token resuming a stream for query after sequence
+func encodeCursor(query string, sequence int32) string {
+	data, _ := json.Marshal(streamCursor{QueryHash: queryHash(query), Sequence: sequence})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor returns the sequence a token resumes after, checking it was
+// issued for query
+func decodeCursor(token, query string) (int32, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, errInvalidCursor
+	}
+	var cursor streamCursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.QueryHash == "" || cursor.Sequence < 0 {
+		return 0, errInvalidCursor
+	}
+	if cursor.QueryHash != queryHash(query) {
+		return 0, fmt.Errorf("%w: issued for a different query", errInvalidCursor)
+	}
+	return cursor.Sequence, nil
+}
+
+func queryHash(query string) string {
+	h := fnv.New64a()
+	h.Write([]byte(query))
+	return strconv.FormatUint(h.Sum64(), 36)
+}
@@ -0,0 +1,29 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursor_RoundTrip(t *testing.T) {
+	token := encodeCursor("orders", 7)
+
+	sequence, err := decodeCursor(token, "orders")
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(7), sequence)
+	assert.NotContains(t, token, "orders", "cursors do not expose the query")
+}
+
+func TestCursor_Invalid(t *testing.T) {
+	_, err := decodeCursor(encodeCursor("orders", 7), "invoices")
+	assert.ErrorContains(t, err, "different query")
+
+	_, err = decodeCursor("not a token!", "orders")
+	assert.ErrorIs(t, err, errInvalidCursor)
+
+	_, err = decodeCursor("e30", "orders") // {}
+	assert.ErrorIs(t, err, errInvalidCursor)
+}
@@ -2,9 +2,11 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
-	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/bufbuild/connect-go"
@@ -12,33 +14,188 @@ import (
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api/apiv1connect"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/clock"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/config"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/health"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/jobs"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/logging"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/pagination"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/processor"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/storage"
 )
 
+// OptionSeed is the ProcessData option that seeds the random number, making
+// the result reproducible
+const OptionSeed = "seed"
+
+// SeedHeader is the response header carrying the seed ProcessData used for a
+// random number. Sending it back as options["seed"] replays the result.
+const SeedHeader = "X-Random-Seed"
+
+// defaultStreamLimit is the StreamData limit used when a request asks for 0
+const defaultStreamLimit = 10
+
 // GrpcService implements the gRPC service
 type GrpcService struct {
-	logger    *logrus.Logger
-	startTime time.Time
+	logger     *logrus.Logger
+	config     *config.Config
+	health     *health.Registry
+	processors *processor.Registry
+	clock      clock.Clock
+	startTime  time.Time
+
+	jobs       *jobs.Manager
+	jobStore   jobs.Store
+	jobOptions []jobs.Option
+	results    storage.Store
+	// owner identifies the caller that owns the jobs it submits and the
+	// results it records
+	owner func(context.Context) string
+	// tokens signs page tokens
+	tokens *pagination.Tokens
+
+	// rand picks seeds for requests without one; *rand.Rand is not safe for
+	// concurrent use
+	randMu sync.Mutex
+	rand   *rand.Rand
+}
+
+// Option configures a GrpcService
+type Option func(*GrpcService)
+
+// WithConfig sets the effective configuration reported by GetInfo
+func WithConfig(cfg *config.Config) Option {
+	return func(s *GrpcService) {
+		s.config = cfg
+	}
+}
+
+// WithHealth sets the health registry the service reports to and reads from
+func WithHealth(registry *health.Registry) Option {
+	return func(s *GrpcService) {
+		s.health = registry
+	}
+}
+
+// WithProcessors sets the registry ProcessData looks processors up in. The
+// default is processor.Default().
+func WithProcessors(registry *processor.Registry) Option {
+	return func(s *GrpcService) {
+		s.processors = registry
+	}
 }
 
-// NewGrpcService creates a new gRPC service instance
-func NewGrpcService(logger *logrus.Logger) *GrpcService {
-	return &GrpcService{
-		logger:    logger,
-		startTime: time.Now(),
+// WithRandSource sets the source seeds are drawn from when a request does
+// not pass options["seed"]. The default is seeded from the current time.
+func WithRandSource(src rand.Source) Option {
+	return func(s *GrpcService) {
+		s.rand = rand.New(src)
 	}
 }
 
+// WithClock sets the clock used for the start time, response timestamps,
+// uptime and the delay between StreamData messages. The default is
+// clock.Real().
+func WithClock(c clock.Clock) Option {
+	return func(s *GrpcService) {
+		s.clock = c
+	}
+}
+
+// WithJobStore sets where jobs started with SubmitJob are kept. The default
+// is a jobs.MemoryStore.
+func WithJobStore(store jobs.Store) Option {
+	return func(s *GrpcService) {
+		s.jobStore = store
+	}
+}
+
+// WithJobOptions passes options to the job manager, e.g. to register its
+// metrics
+func WithJobOptions(opts ...jobs.Option) Option {
+	return func(s *GrpcService) {
+		s.jobOptions = append(s.jobOptions, opts...)
+	}
+}
+
+// WithOwner sets how callers are identified as the owners of their jobs and
+// recorded results, e.g. auth.CallerID. Callers only see what they own. The
+// default treats every caller as the same owner.
+func WithOwner(owner func(context.Context) string) Option {
+	return func(s *GrpcService) {
+		s.owner = owner
+	}
+}
+
+// WithResultStore sets where processed calls are recorded for ListResults.
+// The default is a storage.MemoryStore keeping the most recent results.
+func WithResultStore(store storage.Store) Option {
+	return func(s *GrpcService) {
+		s.results = store
+	}
+}
+
+// NewGrpcService creates a new gRPC service instance and marks it SERVING in
+// its health registry
+func NewGrpcService(logger *logrus.Logger, opts ...Option) *GrpcService {
+	s := &GrpcService{
+		logger:     logger,
+		config:     config.Default(),
+		processors: processor.Default(),
+		clock:      clock.Real(),
+		owner:      func(context.Context) string { return "" },
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.rand == nil {
+		s.rand = rand.New(rand.NewSource(s.clock.Now().UnixNano()))
+	}
+	s.startTime = s.clock.Now()
+	s.tokens = pagination.NewTokens([]byte(s.config.Pagination.TokenKey))
+	if s.health == nil {
+		s.health = health.NewRegistry()
+	}
+	if s.jobStore == nil {
+		s.jobStore = jobs.NewMemoryStore()
+	}
+	if s.results == nil {
+		s.results = storage.NewMemoryStore(storage.DefaultMemoryResults)
+	}
+	s.jobs = jobs.NewManager(s.jobStore, s.runJob, append([]jobs.Option{
+		jobs.WithWorkers(s.config.Jobs.Workers),
+		jobs.WithQueueSize(s.config.Jobs.QueueSize),
+		jobs.WithRetention(s.config.Jobs.Retention),
+		jobs.WithClock(s.clock),
+		jobs.WithLogger(s.logger),
+	}, s.jobOptions...)...)
+	s.health.SetServingStatus(apiv1connect.GrpcServiceName, health.StatusServing)
+	return s
+}
+
 // GetHealth returns the health status of the service
 func (s *GrpcService) GetHealth(ctx context.Context, req *connect.Request[apiv1.GetHealthRequest]) (*connect.Response[apiv1.GetHealthResponse], error) {
-	s.logger.Info("GetHealth called")
+	logging.FromContext(ctx, s.logger).Info("GetHealth called")
+
+	status := "healthy"
+	if overall, _ := s.health.Status(health.OverallService); overall != health.StatusServing {
+		status = "unhealthy"
+	}
+
+	details := map[string]string{
+		"uptime":  clock.Since(s.clock, s.startTime).String(),
+		"version": "1.0.0",
+	}
+	for service, serviceStatus := range s.health.Snapshot() {
+		details["service."+service] = health.StatusText(serviceStatus)
+	}
 
 	response := &apiv1.GetHealthResponse{
-		Status:    "healthy",
-		Timestamp: timestamppb.Now(),
-		Details: map[string]string{
-			"uptime":  time.Since(s.startTime).String(),
-			"version": "1.0.0",
-		},
+		Status:    status,
+		Timestamp: timestamppb.New(s.clock.Now()),
+		Details:   details,
 	}
 
 	return connect.NewResponse(response), nil
@@ -46,75 +203,235 @@ func (s *GrpcService) GetHealth(ctx context.Context, req *connect.Request[apiv1.
 
 // GetInfo returns information about the service
 func (s *GrpcService) GetInfo(ctx context.Context, req *connect.Request[apiv1.GetInfoRequest]) (*connect.Response[apiv1.GetInfoResponse], error) {
-	s.logger.Info("GetInfo called")
+	logging.FromContext(ctx, s.logger).Info("GetInfo called")
+
+	metadata := map[string]string{
+		"go_version":   "1.21",
+		"architecture": "amd64",
+		"os":           "linux",
+	}
+	for key, value := range s.config.Redacted() {
+		metadata["config."+key] = value
+	}
 
 	response := &apiv1.GetInfoResponse{
 		Version:     "1.0.0",
-		Environment: getEnvironment(),
+		Environment: s.config.Environment,
 		StartTime:   timestamppb.New(s.startTime),
-		Metadata: map[string]string{
-			"go_version":   "1.21",
-			"architecture": "amd64",
-			"os":           "linux",
-		},
+		Metadata:    metadata,
 	}
 
 	return connect.NewResponse(response), nil
 }
 
-// ProcessData processes some data and returns a random number
+// ProcessData runs the data through the processors selected by
+// options["processor"] or options["pipeline"]. Without either it returns a
+// random number, seeded by options["seed"] when present. Input a processor
+// rejects is reported with success=false and error_reason INVALID_INPUT;
+// unknown processors and invalid options are InvalidArgument errors with
+// google.rpc details.
 func (s *GrpcService) ProcessData(ctx context.Context, req *connect.Request[apiv1.ProcessDataRequest]) (*connect.Response[apiv1.ProcessDataResponse], error) {
-	s.logger.WithField("data", req.Msg.Data).Info("ProcessData called")
+	logging.FromContext(ctx, s.logger).WithField("data", req.Msg.Data).Info("ProcessData called")
 
-	// Generate a random number between 1 and 1000
-	randomNumber := rand.Intn(1000) + 1
-	result := fmt.Sprintf("%d", randomNumber)
-	success := true
-	var errorMessage string
+	response, seed, err := s.process(ctx, req.Msg.Data, req.Msg.Options)
+	result := &storage.Result{Method: methodProcessData, Input: req.Msg.Data, Options: req.Msg.Options}
+	if response != nil {
+		result.Output = response.Result
+		result.Success = response.Success
+		result.ErrorMessage = response.ErrorMessage
+	}
+	s.record(ctx, result, err)
+	if err != nil {
+		return nil, err
+	}
+	resp := connect.NewResponse(response)
+	if seed != "" {
+		resp.Header().Set(SeedHeader, seed)
+	}
+	return resp, nil
+}
 
-	response := &apiv1.ProcessDataResponse{
-		Result:       result,
-		Success:      success,
-		ErrorMessage: errorMessage,
-		ProcessedAt:  timestamppb.Now(),
+// process is the processing path shared by ProcessData and the streaming
+// RPCs. It returns the seed used when the result is a random number.
+func (s *GrpcService) process(ctx context.Context, data string, options map[string]string) (*apiv1.ProcessDataResponse, string, error) {
+	steps, err := processor.Steps(options)
+	if err != nil {
+		return nil, "", apierror.From(ctx, err)
 	}
 
-	return connect.NewResponse(response), nil
+	response := &apiv1.ProcessDataResponse{Success: true}
+	var seedText string
+	if steps == nil {
+		seed, err := s.seed(options)
+		if err != nil {
+			return nil, "", err
+		}
+		// Generate a random number between 1 and 1000
+		response.Result = fmt.Sprintf("%d", rand.New(rand.NewSource(seed)).Intn(1000)+1)
+		seedText = strconv.FormatInt(seed, 10)
+	} else {
+		result, err := s.processors.Run(ctx, steps, data, options)
+		var inputErr *processor.InputError
+		switch {
+		case err == nil:
+			response.Result = result
+		case errors.As(err, &inputErr):
+			// Rejected input is not an error, so existing clients keep
+			// checking success
+			logging.FromContext(ctx, s.logger).WithError(err).Info("Processor rejected input")
+			response.Success = false
+			response.ErrorMessage = err.Error()
+			response.ErrorReason = apierror.ReasonInvalidInput
+		default:
+			return nil, "", apierror.From(ctx, err)
+		}
+	}
+	response.ProcessedAt = timestamppb.New(s.clock.Now())
+
+	return response, seedText, nil
+}
+
+// processItem processes one item of a batch or stream like ProcessData. Where
+// ProcessData would fail, the item fails instead, with the status code name
+// returned as code; panics fail only the item too.
+func (s *GrpcService) processItem(ctx context.Context, data string, options map[string]string) (response *apiv1.ProcessDataResponse, seedText, code string) {
+	defer func() {
+		if r := recover(); r != nil {
+			logging.FromContext(ctx, s.logger).WithField("panic", r).Error("Processing item panicked")
+			response = &apiv1.ProcessDataResponse{
+				ErrorMessage: "internal error",
+				ErrorReason:  apierror.ReasonInternal,
+				ProcessedAt:  timestamppb.New(s.clock.Now()),
+			}
+			seedText, code = "", connect.CodeInternal.String()
+		}
+	}()
+
+	response, seedText, err := s.process(ctx, data, options)
+	if err != nil {
+		response = &apiv1.ProcessDataResponse{
+			ErrorMessage: errorMessage(err),
+			ErrorReason:  apierror.Reason(err),
+			ProcessedAt:  timestamppb.New(s.clock.Now()),
+		}
+		return response, "", connect.CodeOf(err).String()
+	}
+	return response, seedText, ""
+}
+
+// seed returns options["seed"], or a new seed from the service's source
+func (s *GrpcService) seed(options map[string]string) (int64, error) {
+	if value, ok := options[OptionSeed]; ok {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return 0, apierror.New(connect.CodeInvalidArgument, apierror.ReasonInvalidOption,
+				fmt.Errorf("option %q: must be a 64-bit integer, got %q", OptionSeed, value),
+				apierror.WithFieldViolation("options."+OptionSeed, fmt.Sprintf("must be a 64-bit integer, got %q", value)))
+		}
+		return seed, nil
+	}
+	s.randMu.Lock()
+	defer s.randMu.Unlock()
+	return s.rand.Int63(), nil
 }
 
-// StreamData streams data processing results
-func (s *GrpcService) StreamData(ctx context.Context, req *connect.Request[apiv1.StreamDataRequest], stream *connect.ServerStream[apiv1.StreamDataResponse]) error {
-	s.logger.WithField("query", req.Msg.Query).Info("StreamData called")
+// StreamData streams data processing results. Messages depend only on the
+// query and sequence number, so a stream resumed from a cursor or
+// start_after_sequence continues exactly where the previous one stopped.
+// Limits above stream.max_limit are rejected, and streams running longer than
+// stream.max_duration end with DEADLINE_EXCEEDED.
+func (s *GrpcService) StreamData(ctx context.Context, req *connect.Request[apiv1.StreamDataRequest], stream *connect.ServerStream[apiv1.StreamDataResponse]) (err error) {
+	log := logging.FromContext(ctx, s.logger)
+	log.WithField("query", req.Msg.Query).Info("StreamData called")
+
+	sent := 0
+	defer func() {
+		s.record(ctx, &storage.Result{
+			Method:  methodStreamData,
+			Input:   req.Msg.Query,
+			Output:  fmt.Sprintf("sent %d messages", sent),
+			Success: err == nil,
+		}, err)
+	}()
 
-	limit := req.Msg.Limit
-	if limit <= 0 {
-		limit = 10
+	limits := s.config.Stream
+	sizes := pagination.Sizes{Default: defaultStreamLimit, Max: limits.MaxLimit, RejectAboveMax: true}
+	size, err := sizes.Resolve("limit", req.Msg.Limit)
+	if err != nil {
+		return apierror.From(ctx, err)
 	}
+	limit := int32(size)
+
+	after, err := startAfter(req.Msg)
+	if err != nil {
+		return err
+	}
+	if after > 0 {
+		log.WithField("start_after_sequence", after).Info("Resuming stream")
+	}
+
+	deadline := s.clock.Now().Add(limits.MaxDuration)
+	for sequence := after + 1; sequence <= limit; sequence++ {
+		if err := ctx.Err(); err != nil {
+			return apierror.From(ctx, err)
+		}
+		if !s.clock.Now().Before(deadline) {
+			return apierror.New(connect.CodeDeadlineExceeded, apierror.ReasonStreamTimeout,
+				fmt.Errorf("stream exceeded the maximum duration of %s; resume it from the last cursor", limits.MaxDuration),
+				apierror.WithMetadata("max_duration", limits.MaxDuration.String()))
+		}
 
-	for i := 0; i < int(limit); i++ {
 		response := &apiv1.StreamDataResponse{
-			Data:      fmt.Sprintf("Stream data %d for query: %s", i+1, req.Msg.Query),
-			Sequence:  int32(i + 1),
-			Timestamp: timestamppb.Now(),
+			Data:      fmt.Sprintf("Stream data %d for query: %s", sequence, req.Msg.Query),
+			Sequence:  sequence,
+			Timestamp: timestamppb.New(s.clock.Now()),
+			Cursor:    encodeCursor(req.Msg.Query, sequence),
 		}
 
 		if err := stream.Send(response); err != nil {
-			s.logger.WithError(err).Error("Failed to send stream response")
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return apierror.From(ctx, ctxErr)
+			}
+			log.WithError(err).Error("Failed to send stream response")
 			return err
 		}
+		sent++
 
-		// Simulate processing time
-		time.Sleep(100 * time.Millisecond)
+		if sequence == limit {
+			break
+		}
+
+		// Simulate processing time, never waiting past the deadline and
+		// stopping early if the call is canceled
+		wait := limits.Interval
+		if remaining := deadline.Sub(s.clock.Now()); remaining < wait {
+			wait = remaining
+		}
+		select {
+		case <-ctx.Done():
+			return apierror.From(ctx, ctx.Err())
+		case <-s.clock.After(wait):
+		}
 	}
 
 	return nil
 }
 
-// getEnvironment returns the current environment
-func getEnvironment() string {
-	env := os.Getenv("ENVIRONMENT")
-	if env == "" {
-		env = "development"
+// startAfter returns the sequence number a stream resumes after, 0 for a new
+// stream
+func startAfter(req *apiv1.StreamDataRequest) (int32, error) {
+	switch {
+	case req.ResumeToken != "" && req.StartAfterSequence != 0:
+		return 0, apierror.InvalidField(apierror.ReasonInvalidArgument, "start_after_sequence", errors.New("cannot be combined with resume_token"))
+	case req.ResumeToken != "":
+		after, err := decodeCursor(req.ResumeToken, req.Query)
+		if err != nil {
+			return 0, apierror.InvalidField(apierror.ReasonInvalidPageToken, "resume_token", err)
+		}
+		return after, nil
+	case req.StartAfterSequence < 0:
+		return 0, apierror.InvalidField(apierror.ReasonInvalidArgument, "start_after_sequence", fmt.Errorf("must not be negative, got %d", req.StartAfterSequence))
+	default:
+		return req.StartAfterSequence, nil
 	}
-	return env
 }
@@ -2,6 +2,9 @@ package server
 
 import (
 	"context"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -11,8 +14,27 @@ import (
 	"github.com/stretchr/testify/require"
 
 	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api/apiv1connect"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/clock"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/config"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/health"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/processor"
 )
 
+// fixedTime is the start time of services created by newTestService
+var fixedTime = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestService creates a service with a seeded random source and a fake
+// clock so results can be asserted exactly
+func newTestService(opts ...Option) (*GrpcService, *clock.Fake) {
+	fake := clock.NewFake(fixedTime)
+	opts = append([]Option{
+		WithRandSource(rand.NewSource(1)),
+		WithClock(fake),
+	}, opts...)
+	return NewGrpcService(logrus.New(), opts...), fake
+}
+
 func TestNewGrpcService(t *testing.T) {
 	logger := logrus.New()
 	service := NewGrpcService(logger)
@@ -35,6 +57,32 @@ func TestGrpcService_GetHealth(t *testing.T) {
 	assert.NotNil(t, resp.Msg.Timestamp)
 	assert.Contains(t, resp.Msg.Details, "uptime")
 	assert.Contains(t, resp.Msg.Details, "version")
+	assert.Equal(t, "serving", resp.Msg.Details["service.api.v1.GrpcService"])
+}
+
+func TestGrpcService_GetHealth_Uptime(t *testing.T) {
+	service, fake := newTestService()
+	fake.Advance(90 * time.Second)
+
+	resp, err := service.GetHealth(context.Background(), connect.NewRequest(&apiv1.GetHealthRequest{}))
+
+	require.NoError(t, err)
+	assert.Equal(t, "1m30s", resp.Msg.Details["uptime"])
+	assert.Equal(t, fixedTime.Add(90*time.Second), resp.Msg.Timestamp.AsTime())
+}
+
+func TestGrpcService_GetHealth_UnhealthyDependency(t *testing.T) {
+	logger := logrus.New()
+	registry := health.NewRegistry()
+	service := NewGrpcService(logger, WithHealth(registry))
+	registry.SetServingStatus("database", health.StatusNotServing)
+
+	req := connect.NewRequest(&apiv1.GetHealthRequest{})
+	resp, err := service.GetHealth(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.Equal(t, "unhealthy", resp.Msg.Status)
+	assert.Equal(t, "not_serving", resp.Msg.Details["service.database"])
 }
 
 func TestGrpcService_GetInfo(t *testing.T) {
@@ -51,11 +99,37 @@ func TestGrpcService_GetInfo(t *testing.T) {
 	assert.Contains(t, resp.Msg.Metadata, "go_version")
 	assert.Contains(t, resp.Msg.Metadata, "architecture")
 	assert.Contains(t, resp.Msg.Metadata, "os")
+	assert.Equal(t, "development", resp.Msg.Environment)
+	assert.Equal(t, "9090", resp.Msg.Metadata["config.server.grpc_port"])
 }
 
-func TestGrpcService_ProcessData_Success(t *testing.T) {
+func TestGrpcService_GetInfo_WithConfig(t *testing.T) {
 	logger := logrus.New()
-	service := NewGrpcService(logger)
+	cfg := config.Default()
+	cfg.Environment = "staging"
+	cfg.Log.Level = "debug"
+	service := NewGrpcService(logger, WithConfig(cfg))
+
+	req := connect.NewRequest(&apiv1.GetInfoRequest{})
+	resp, err := service.GetInfo(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.Equal(t, "staging", resp.Msg.Environment)
+	assert.Equal(t, "debug", resp.Msg.Metadata["config.log.level"])
+}
+
+func TestGrpcService_GetInfo_StartTime(t *testing.T) {
+	service, fake := newTestService()
+	fake.Advance(time.Hour)
+
+	resp, err := service.GetInfo(context.Background(), connect.NewRequest(&apiv1.GetInfoRequest{}))
+
+	require.NoError(t, err)
+	assert.Equal(t, fixedTime, resp.Msg.StartTime.AsTime())
+}
+
+func TestGrpcService_ProcessData_Success(t *testing.T) {
+	service, _ := newTestService()
 
 	testData := "test data"
 	req := connect.NewRequest(&apiv1.ProcessDataRequest{
@@ -68,15 +142,14 @@ func TestGrpcService_ProcessData_Success(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, resp)
 	assert.True(t, resp.Msg.Success)
-	// Check that result is a number between 1 and 1000
-	assert.NotEmpty(t, resp.Msg.Result)
+	assert.Equal(t, "830", resp.Msg.Result)
+	assert.Equal(t, "5577006791947779410", resp.Header().Get(SeedHeader))
 	assert.Empty(t, resp.Msg.ErrorMessage)
-	assert.NotNil(t, resp.Msg.ProcessedAt)
+	assert.Equal(t, fixedTime, resp.Msg.ProcessedAt.AsTime())
 }
 
 func TestGrpcService_ProcessData_EmptyData(t *testing.T) {
-	logger := logrus.New()
-	service := NewGrpcService(logger)
+	service, _ := newTestService()
 
 	req := connect.NewRequest(&apiv1.ProcessDataRequest{
 		Data: "",
@@ -88,18 +161,143 @@ func TestGrpcService_ProcessData_EmptyData(t *testing.T) {
 	assert.NotNil(t, resp)
 	assert.True(t, resp.Msg.Success)
 	// Even with empty data, we still get a random number
-	assert.NotEmpty(t, resp.Msg.Result)
+	assert.Equal(t, "830", resp.Msg.Result)
 	assert.Empty(t, resp.Msg.ErrorMessage)
 	assert.NotNil(t, resp.Msg.ProcessedAt)
 }
 
-// TODO: Add streaming tests when mock implementation is complete
-func TestGrpcService_StreamData(t *testing.T) {
-	t.Skip("Streaming tests not implemented yet")
+func TestGrpcService_ProcessData_Seed(t *testing.T) {
+	service := NewGrpcService(logrus.New())
+
+	for i := 0; i < 3; i++ {
+		req := connect.NewRequest(&apiv1.ProcessDataRequest{
+			Options: map[string]string{"seed": "42"},
+		})
+
+		resp, err := service.ProcessData(context.Background(), req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "306", resp.Msg.Result, "the same seed always gives the same result")
+		assert.Equal(t, "42", resp.Header().Get(SeedHeader))
+	}
+}
+
+func TestGrpcService_ProcessData_ReplaySeed(t *testing.T) {
+	service := NewGrpcService(logrus.New())
+
+	first, err := service.ProcessData(context.Background(), connect.NewRequest(&apiv1.ProcessDataRequest{}))
+	require.NoError(t, err)
+
+	replay, err := service.ProcessData(context.Background(), connect.NewRequest(&apiv1.ProcessDataRequest{
+		Options: map[string]string{"seed": first.Header().Get(SeedHeader)},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, first.Msg.Result, replay.Msg.Result)
+}
+
+func TestGrpcService_ProcessData_InvalidSeed(t *testing.T) {
+	service, _ := newTestService()
+
+	req := connect.NewRequest(&apiv1.ProcessDataRequest{
+		Options: map[string]string{"seed": "abc"},
+	})
+
+	_, err := service.ProcessData(context.Background(), req)
+
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+}
+
+func TestGrpcService_ProcessData_Processor(t *testing.T) {
+	logger := logrus.New()
+	service := NewGrpcService(logger)
+
+	req := connect.NewRequest(&apiv1.ProcessDataRequest{
+		Data:    "hello world",
+		Options: map[string]string{"pipeline": "uppercase,base64"},
+	})
+
+	resp, err := service.ProcessData(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.True(t, resp.Msg.Success)
+	assert.Equal(t, "SEVMTE8gV09STEQ=", resp.Msg.Result)
+	assert.NotNil(t, resp.Msg.ProcessedAt)
+}
+
+func TestGrpcService_ProcessData_InvalidInput(t *testing.T) {
+	logger := logrus.New()
+	service := NewGrpcService(logger)
+
+	req := connect.NewRequest(&apiv1.ProcessDataRequest{
+		Data:    `{"broken":`,
+		Options: map[string]string{"processor": "json_validate"},
+	})
+
+	resp, err := service.ProcessData(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.False(t, resp.Msg.Success)
+	assert.Empty(t, resp.Msg.Result)
+	assert.Contains(t, resp.Msg.ErrorMessage, "invalid JSON")
+}
+
+func TestGrpcService_ProcessData_UnknownProcessor(t *testing.T) {
+	logger := logrus.New()
+	service := NewGrpcService(logger)
+
+	req := connect.NewRequest(&apiv1.ProcessDataRequest{
+		Data:    "x",
+		Options: map[string]string{"processor": "reverse"},
+	})
+
+	_, err := service.ProcessData(context.Background(), req)
+
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	assert.Contains(t, err.Error(), "unknown processor")
 }
 
-func TestGrpcService_StreamData_DefaultLimit(t *testing.T) {
-	t.Skip("Streaming tests not implemented yet")
+func TestGrpcService_ProcessData_CustomProcessor(t *testing.T) {
+	logger := logrus.New()
+	registry := processor.NewRegistry()
+	registry.MustRegister("greet", processor.Func(func(_ context.Context, input string, _ map[string]string) (string, error) {
+		return "hello " + input, nil
+	}))
+	service := NewGrpcService(logger, WithProcessors(registry))
+
+	req := connect.NewRequest(&apiv1.ProcessDataRequest{
+		Data:    "team",
+		Options: map[string]string{"processor": "greet"},
+	})
+
+	resp, err := service.ProcessData(context.Background(), req)
+
+	require.NoError(t, err)
+	assert.Equal(t, "hello team", resp.Msg.Result)
 }
 
-// TODO: Implement mock server stream for testing
+func TestGrpcService_StreamData_FakeClock(t *testing.T) {
+	service, fake := newTestService()
+	mux := http.NewServeMux()
+	mux.Handle(apiv1connect.NewGrpcServiceHandler(service))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	client := apiv1connect.NewGrpcServiceClient(srv.Client(), srv.URL)
+
+	stream, err := client.StreamData(context.Background(), connect.NewRequest(&apiv1.StreamDataRequest{Query: "q", Limit: 3}))
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		require.True(t, stream.Receive())
+		assert.Equal(t, int32(i+1), stream.Msg().Sequence)
+		assert.Equal(t, fixedTime.Add(time.Duration(i)*100*time.Millisecond), stream.Msg().Timestamp.AsTime())
+
+		// The handler waits between messages until the clock moves on
+		if i < 2 {
+			fake.BlockUntil(1)
+			fake.Advance(100 * time.Millisecond)
+		}
+	}
+	assert.False(t, stream.Receive())
+	require.NoError(t, stream.Err())
+	require.NoError(t, stream.Close())
+}
@@ -0,0 +1,283 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/jobs"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/logging"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/pagination"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/processor"
+)
+
+// jobNamePrefix starts the resource name of every job
+const jobNamePrefix = "jobs/"
+
+// jobFields are the fields a ListJobs filter may use. state holds the
+// JobState name, e.g. JOB_STATE_RUNNING.
+var jobFields = pagination.Fields{
+	"state":       pagination.String,
+	"done":        pagination.Bool,
+	"create_time": pagination.Time,
+	"start_time":  pagination.Time,
+	"end_time":    pagination.Time,
+}
+
+// SubmitJob queues a ProcessData request on the job manager and returns the
+// pending job. A full queue fails with RESOURCE_EXHAUSTED. The job belongs to
+// the authenticated caller: other callers cannot see, wait for or cancel it.
+func (s *GrpcService) SubmitJob(ctx context.Context, req *connect.Request[apiv1.SubmitJobRequest]) (*connect.Response[apiv1.Job], error) {
+	if req.Msg.Request == nil {
+		return nil, apierror.InvalidField(apierror.ReasonInvalidArgument, "request", errors.New("is required"))
+	}
+	job, err := s.jobs.Submit(ctx, s.owner(ctx), req.Msg.Request.Data, req.Msg.Request.Options)
+	if err != nil {
+		return nil, jobError(ctx, err, "")
+	}
+	logging.FromContext(ctx, s.logger).WithField("job", job.ID).Info("Job submitted")
+	return connect.NewResponse(jobToProto(job)), nil
+}
+
+// GetJob returns the latest state of a job
+func (s *GrpcService) GetJob(ctx context.Context, req *connect.Request[apiv1.GetJobRequest]) (*connect.Response[apiv1.Job], error) {
+	job, err := s.ownJob(ctx, req.Msg.Name)
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(jobToProto(job)), nil
+}
+
+// ownJob returns the job named name if it belongs to the caller. Other
+// callers' jobs are NOT_FOUND, so their names do not leak.
+func (s *GrpcService) ownJob(ctx context.Context, name string) (*jobs.Job, error) {
+	id, err := parseJobName(name)
+	if err != nil {
+		return nil, err
+	}
+	job, err := s.jobs.Get(ctx, id)
+	if err == nil && job.Owner != s.owner(ctx) {
+		err = jobs.ErrNotFound
+	}
+	if err != nil {
+		return nil, jobError(ctx, err, name)
+	}
+	return job, nil
+}
+
+// ListJobs lists the caller's jobs in submission order. Finished jobs are
+// listed until jobs.retention expires.
+func (s *GrpcService) ListJobs(ctx context.Context, req *connect.Request[apiv1.ListJobsRequest]) (*connect.Response[apiv1.ListJobsResponse], error) {
+	size, err := s.pageSize(ctx, req.Msg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := parseFilter(ctx, req.Msg.Filter, jobFields)
+	if err != nil {
+		return nil, err
+	}
+	afterID, err := s.decodePageToken(ctx, req.Msg.PageToken, "ListJobs", req.Msg.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read the caller's jobs a page at a time until one more matching job
+	// than needed is found, to know whether another page follows
+	owner := s.owner(ctx)
+	var list []*jobs.Job
+	for len(list) <= size {
+		batch, err := s.jobs.List(ctx, owner, afterID, size+1)
+		if err != nil {
+			return nil, jobError(ctx, err, "")
+		}
+		for _, job := range batch {
+			if filter.Match(func(field string) any { return jobField(job, field) }) {
+				list = append(list, job)
+			}
+		}
+		if len(batch) <= size {
+			break
+		}
+		afterID = batch[len(batch)-1].ID
+	}
+	resp := &apiv1.ListJobsResponse{}
+	if len(list) > size {
+		list = list[:size]
+		resp.NextPageToken = s.tokens.Encode(list[size-1].ID, "ListJobs", req.Msg.Filter)
+	}
+	for _, job := range list {
+		resp.Jobs = append(resp.Jobs, jobToProto(job))
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// jobField returns the value of one of jobFields. Unset times are nil, so
+// no comparison matches them.
+func jobField(job *jobs.Job, field string) any {
+	optionalTime := func(t time.Time) any {
+		if t.IsZero() {
+			return nil
+		}
+		return t
+	}
+	switch field {
+	case "state":
+		return jobStates[job.State].String()
+	case "done":
+		return job.State.Done()
+	case "create_time":
+		return optionalTime(job.CreateTime)
+	case "start_time":
+		return optionalTime(job.StartTime)
+	case "end_time":
+		return optionalTime(job.EndTime)
+	default:
+		return nil
+	}
+}
+
+// CancelJob cancels a pending job at once and asks a running job to stop.
+// Cancelling a finished job returns it unchanged.
+func (s *GrpcService) CancelJob(ctx context.Context, req *connect.Request[apiv1.CancelJobRequest]) (*connect.Response[apiv1.Job], error) {
+	job, err := s.ownJob(ctx, req.Msg.Name)
+	if err != nil {
+		return nil, err
+	}
+	job, err = s.jobs.Cancel(ctx, job.ID)
+	if err != nil {
+		return nil, jobError(ctx, err, req.Msg.Name)
+	}
+	logging.FromContext(ctx, s.logger).WithField("job", job.ID).Info("Job cancellation requested")
+	return connect.NewResponse(jobToProto(job)), nil
+}
+
+// WaitJob blocks until a job is done or the timeout passes, then returns the
+// job. The timeout defaults to and is capped at jobs.max_wait.
+func (s *GrpcService) WaitJob(ctx context.Context, req *connect.Request[apiv1.WaitJobRequest]) (*connect.Response[apiv1.Job], error) {
+	timeout := s.config.Jobs.MaxWait
+	if req.Msg.Timeout != nil {
+		if err := req.Msg.Timeout.CheckValid(); err != nil || req.Msg.Timeout.AsDuration() < 0 {
+			return nil, apierror.InvalidField(apierror.ReasonInvalidArgument, "timeout", fmt.Errorf("must be a non-negative duration, got %s", req.Msg.Timeout))
+		}
+		timeout = min(timeout, req.Msg.Timeout.AsDuration())
+	}
+	job, err := s.ownJob(ctx, req.Msg.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	job, err = s.jobs.Wait(waitCtx, job.ID)
+	switch {
+	case err == nil:
+	case ctx.Err() != nil:
+		return nil, apierror.From(ctx, ctx.Err())
+	case errors.Is(err, context.DeadlineExceeded):
+		// The wait timed out; the job is returned as it is
+	default:
+		return nil, jobError(ctx, err, req.Msg.Name)
+	}
+	return connect.NewResponse(jobToProto(job)), nil
+}
+
+// Close stops the job manager. Running jobs get until ctx ends to finish.
+func (s *GrpcService) Close(ctx context.Context) error {
+	return s.jobs.Close(ctx)
+}
+
+// RecoverJobs fails the jobs a previous process left unfinished in a
+// persistent job store and returns how many there were
+func (s *GrpcService) RecoverJobs(ctx context.Context) (int, error) {
+	return s.jobs.Recover(ctx)
+}
+
+// runJob runs a job through the ProcessData path, reporting the share of
+// pipeline steps completed as its progress
+func (s *GrpcService) runJob(ctx context.Context, job *jobs.Job, progress func(int32)) (*jobs.Output, error) {
+	ctx = logging.WithEntry(ctx, s.logger.WithField("job", job.ID))
+	ctx = processor.WithProgress(ctx, func(done, total int) {
+		progress(int32(done * 100 / total))
+	})
+	response, seedText, err := s.process(ctx, job.Data, job.Options)
+	if err != nil {
+		return nil, err
+	}
+	return &jobs.Output{
+		Result:       response.Result,
+		Success:      response.Success,
+		ErrorMessage: response.ErrorMessage,
+		ErrorReason:  response.ErrorReason,
+		Seed:         seedText,
+	}, nil
+}
+
+// jobError maps job manager errors to connect errors, naming the job when
+// it does not exist
+func jobError(ctx context.Context, err error, name string) error {
+	if errors.Is(err, jobs.ErrNotFound) {
+		return apierror.New(connect.CodeNotFound, apierror.ReasonJobNotFound, fmt.Errorf("job %q not found", name),
+			apierror.WithMetadata("name", name))
+	}
+	return apierror.From(ctx, err)
+}
+
+// parseJobName returns the ID of a "jobs/{id}" resource name
+func parseJobName(name string) (string, error) {
+	id, ok := strings.CutPrefix(name, jobNamePrefix)
+	if !ok || id == "" || strings.Contains(id, "/") {
+		return "", apierror.InvalidField(apierror.ReasonInvalidArgument, "name", fmt.Errorf("must have the form jobs/{id}, got %q", name))
+	}
+	return id, nil
+}
+
+var jobStates = map[jobs.State]apiv1.JobState{
+	jobs.StatePending:   apiv1.JobState_JOB_STATE_PENDING,
+	jobs.StateRunning:   apiv1.JobState_JOB_STATE_RUNNING,
+	jobs.StateSucceeded: apiv1.JobState_JOB_STATE_SUCCEEDED,
+	jobs.StateFailed:    apiv1.JobState_JOB_STATE_FAILED,
+	jobs.StateCancelled: apiv1.JobState_JOB_STATE_CANCELLED,
+}
+
+func jobToProto(job *jobs.Job) *apiv1.Job {
+	out := &apiv1.Job{
+		Name:  jobNamePrefix + job.ID,
+		State: jobStates[job.State],
+		Done:  job.State.Done(),
+		Metadata: &apiv1.JobMetadata{
+			ProgressPercent: job.Progress,
+			CreateTime:      timestamp(job.CreateTime),
+			StartTime:       timestamp(job.StartTime),
+			EndTime:         timestamp(job.EndTime),
+		},
+	}
+	switch {
+	case job.Output != nil:
+		out.Metadata.Seed = job.Output.Seed
+		out.Result = &apiv1.Job_Response{Response: &apiv1.ProcessDataResponse{
+			Result:       job.Output.Result,
+			Success:      job.Output.Success,
+			ErrorMessage: job.Output.ErrorMessage,
+			ErrorReason:  job.Output.ErrorReason,
+			ProcessedAt:  timestamp(job.EndTime),
+		}}
+	case job.Error != nil:
+		out.Result = &apiv1.Job_Error{Error: &apiv1.JobError{Code: job.Error.Code, Message: job.Error.Message, Reason: job.Error.Reason}}
+	}
+	return out
+}
+
+// timestamp converts t, leaving zero times unset
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
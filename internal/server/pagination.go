@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/pagination"
+)
+
+// pageSize applies pagination.default_page_size and max_page_size to a
+// requested page size
+func (s *GrpcService) pageSize(ctx context.Context, requested int32) (int, error) {
+	sizes := pagination.Sizes{Default: s.config.Pagination.DefaultPageSize, Max: s.config.Pagination.MaxPageSize}
+	size, err := sizes.Resolve("page_size", requested)
+	if err != nil {
+		return 0, apierror.From(ctx, err)
+	}
+	return size, nil
+}
+
+// parseFilter parses the filter of a List request
+func parseFilter(ctx context.Context, expr string, fields pagination.Fields) (*pagination.Filter, error) {
+	filter, err := pagination.ParseFilter(expr, fields)
+	if err != nil {
+		return nil, apierror.From(ctx, err)
+	}
+	return filter, nil
+}
+
+// decodePageToken returns the cursor of a page token issued for a request
+// with the same parameters, or "" for the first page
+func (s *GrpcService) decodePageToken(ctx context.Context, token string, request ...string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	cursor, err := s.tokens.Decode(token, request...)
+	if err != nil {
+		return "", apierror.From(ctx, err)
+	}
+	return cursor, nil
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/bufbuild/connect-go"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/logging"
+)
+
+// ProcessStream runs every request of the stream through the ProcessData path
+// and sends each result as soon as it is ready, tagged with the request's id.
+// At most process.max_in_flight requests of a stream are processed at once;
+// the next request is only read once one of them has finished, so a client
+// sending faster than that is slowed down by HTTP/2 flow control. A request
+// that fails is answered with an error_code instead of ending the stream.
+func (s *GrpcService) ProcessStream(ctx context.Context, stream *connect.BidiStream[apiv1.ProcessStreamRequest, apiv1.ProcessStreamResponse]) error {
+	log := logging.FromContext(ctx, s.logger)
+	log.Info("ProcessStream called")
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Send is not safe for concurrent use, so one goroutine sends all results
+	results := make(chan *apiv1.ProcessStreamResponse)
+	sendDone := make(chan error, 1)
+	go func() {
+		var sendErr error
+		for result := range results {
+			if sendErr != nil {
+				continue
+			}
+			if sendErr = stream.Send(result); sendErr != nil {
+				cancel()
+			}
+		}
+		sendDone <- sendErr
+	}()
+
+	var (
+		received int
+		workers  sync.WaitGroup
+		recvErr  error
+	)
+	slots := make(chan struct{}, s.config.Process.MaxInFlight)
+	for {
+		req, err := stream.Receive()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				recvErr = err
+			}
+			break
+		}
+		received++
+
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			defer func() { <-slots }()
+			results <- s.processStreamRequest(ctx, req)
+		}()
+	}
+	workers.Wait()
+	close(results)
+	sendErr := <-sendDone
+
+	log.WithField("received", received).Info("ProcessStream completed")
+
+	if ctxErr := ctx.Err(); ctxErr != nil && sendErr == nil {
+		// The client went away or the server is shutting down
+		return apierror.From(ctx, ctxErr)
+	}
+	if recvErr != nil {
+		return recvErr
+	}
+	return sendErr
+}
+
+// processStreamRequest processes one request of a ProcessStream stream
+func (s *GrpcService) processStreamRequest(ctx context.Context, req *apiv1.ProcessStreamRequest) *apiv1.ProcessStreamResponse {
+	response, seedText, code := s.processItem(ctx, req.Data, req.Options)
+	return &apiv1.ProcessStreamResponse{
+		Id:           req.Id,
+		Result:       response.Result,
+		Success:      response.Success,
+		ErrorMessage: response.ErrorMessage,
+		ErrorCode:    code,
+		ErrorReason:  response.ErrorReason,
+		ProcessedAt:  response.ProcessedAt,
+		Seed:         seedText,
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/logging"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/pagination"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/storage"
+)
+
+// Methods recorded in the result store
+const (
+	methodProcessData = "ProcessData"
+	methodStreamData  = "StreamData"
+)
+
+// ListResults lists recorded calls, newest first
+func (s *GrpcService) ListResults(ctx context.Context, req *connect.Request[apiv1.ListResultsRequest]) (*connect.Response[apiv1.ListResultsResponse], error) {
+	size, err := s.pageSize(ctx, req.Msg.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := parseFilter(ctx, req.Msg.Filter, storage.ResultFields)
+	if err != nil {
+		return nil, err
+	}
+	q := storage.Query{
+		Owner:   s.owner(ctx),
+		Method:  req.Msg.Method,
+		Success: req.Msg.Success,
+		Filter:  filter,
+		// Fetch one more result than needed to know whether another page
+		// follows
+		Limit: size + 1,
+	}
+	if req.Msg.StartTime != nil {
+		if err := req.Msg.StartTime.CheckValid(); err != nil {
+			return nil, apierror.InvalidField(apierror.ReasonInvalidArgument, "start_time", err)
+		}
+		q.Since = req.Msg.StartTime.AsTime()
+	}
+	if req.Msg.EndTime != nil {
+		if err := req.Msg.EndTime.CheckValid(); err != nil {
+			return nil, apierror.InvalidField(apierror.ReasonInvalidArgument, "end_time", err)
+		}
+		q.Until = req.Msg.EndTime.AsTime()
+	}
+	// Page tokens only continue a listing with the same filters
+	request := resultsRequest(req.Msg)
+	cursor, err := s.decodePageToken(ctx, req.Msg.PageToken, request...)
+	if err != nil {
+		return nil, err
+	}
+	if cursor != "" {
+		if q.BeforeID, err = strconv.ParseInt(cursor, 10, 64); err != nil {
+			return nil, apierror.From(ctx, pagination.ErrInvalidToken)
+		}
+	}
+
+	results, err := s.results.ListResults(ctx, q)
+	if err != nil {
+		logging.FromContext(ctx, s.logger).WithError(err).Error("Failed to list results")
+		return nil, apierror.New(connect.CodeInternal, apierror.ReasonInternal, errors.New("failed to list results"))
+	}
+	resp := &apiv1.ListResultsResponse{}
+	if len(results) > size {
+		results = results[:size]
+		resp.NextPageToken = s.tokens.Encode(strconv.FormatInt(results[size-1].ID, 10), request...)
+	}
+	for _, result := range results {
+		resp.Results = append(resp.Results, &apiv1.ProcessedResult{
+			Id:           result.ID,
+			Method:       result.Method,
+			Input:        result.Input,
+			Options:      result.Options,
+			Output:       result.Output,
+			Success:      result.Success,
+			ErrorCode:    result.ErrorCode,
+			ErrorMessage: result.ErrorMessage,
+			CreateTime:   timestamp(result.CreateTime),
+			Status:       result.Status(),
+		})
+	}
+	return connect.NewResponse(resp), nil
+}
+
+// resultsRequest returns the parameters of a ListResults request that its
+// page tokens are tied to
+func resultsRequest(req *apiv1.ListResultsRequest) []string {
+	success := ""
+	if req.Success != nil {
+		success = strconv.FormatBool(*req.Success)
+	}
+	formatTime := func(t *timestamppb.Timestamp) string {
+		if t == nil {
+			return ""
+		}
+		return t.AsTime().Format(time.RFC3339Nano)
+	}
+	return []string{"ListResults", req.Method, success, formatTime(req.StartTime), formatTime(req.EndTime), req.Filter}
+}
+
+// record saves the outcome of a call in the result store, taking the error
+// code and message from err when the call failed. Storage failures are
+// logged rather than failing the call.
+func (s *GrpcService) record(ctx context.Context, result *storage.Result, err error) {
+	if err != nil {
+		result.Success = false
+		result.ErrorCode = connect.CodeOf(err).String()
+		result.ErrorMessage = errorMessage(err)
+	}
+	result.Owner = s.owner(ctx)
+	result.CreateTime = s.clock.Now()
+	// Record calls the client canceled too
+	if err := s.results.RecordResult(context.WithoutCancel(ctx), result); err != nil {
+		logging.FromContext(ctx, s.logger).WithError(err).WithField("method", result.Method).Warn("Failed to record result")
+	}
+}
+
+// errorMessage returns the message of err without the code prefix connect
+// errors add
+func errorMessage(err error) string {
+	var connectErr *connect.Error
+	if errors.As(err, &connectErr) {
+		return connectErr.Message()
+	}
+	return err.Error()
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/logging"
+)
+
+// UploadData runs every record of the stream through the ProcessData path
+// and summarizes the results when the client closes the stream. Records that
+// are rejected, including those naming an unknown processor, are counted as
+// failures without ending the upload. Uploads over upload.max_records or
+// upload.max_bytes fail with RESOURCE_EXHAUSTED. A single record over
+// upload.max_bytes is refused before it is read when the handler is built
+// with UploadReadMaxBytes.
+func (s *GrpcService) UploadData(ctx context.Context, stream *connect.ClientStream[apiv1.UploadDataRequest]) (*connect.Response[apiv1.UploadDataResponse], error) {
+	log := logging.FromContext(ctx, s.logger)
+	log.Info("UploadData called")
+
+	limits := s.config.Upload
+	started := s.clock.Now()
+	summary := &apiv1.UploadDataResponse{StartedAt: timestamppb.New(started)}
+
+	for stream.Receive() {
+		record := stream.Msg()
+		index := summary.Received
+		if int(index) >= limits.MaxRecords {
+			return nil, apierror.New(connect.CodeResourceExhausted, apierror.ReasonLimitExceeded,
+				fmt.Errorf("upload exceeds %d records", limits.MaxRecords),
+				apierror.WithMetadata("max_records", strconv.Itoa(limits.MaxRecords)))
+		}
+		summary.Received++
+		summary.BytesReceived += int64(proto.Size(record))
+		if summary.BytesReceived > int64(limits.MaxBytes) {
+			return nil, s.uploadTooLarge(index)
+		}
+
+		result, _, err := s.process(ctx, record.Data, record.Options)
+		var connectErr *connect.Error
+		switch {
+		case err == nil && result.Success:
+			summary.Succeeded++
+		case err == nil:
+			s.uploadFailure(summary, index, result.ErrorMessage)
+		case errors.As(err, &connectErr) && connectErr.Code() == connect.CodeInvalidArgument:
+			s.uploadFailure(summary, index, connectErr.Message())
+		default:
+			return nil, err
+		}
+	}
+	if err := stream.Err(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, apierror.From(ctx, ctxErr)
+		}
+		if connect.CodeOf(err) == connect.CodeResourceExhausted {
+			// The next record alone is over the read limit
+			return nil, s.uploadTooLarge(summary.Received)
+		}
+		return nil, err
+	}
+
+	summary.Duration = durationpb.New(s.clock.Now().Sub(started))
+	log.WithFields(logrus.Fields{
+		"received":  summary.Received,
+		"succeeded": summary.Succeeded,
+		"failed":    summary.Failed,
+	}).Info("UploadData completed")
+
+	return connect.NewResponse(summary), nil
+}
+
+// UploadReadMaxBytes returns the handler option that bounds each UploadData
+// record by upload.max_bytes. Connect checks the size in the record's prefix
+// and refuses larger records without buffering them.
+func (s *GrpcService) UploadReadMaxBytes() connect.HandlerOption {
+	return connect.WithReadMaxBytes(s.config.Upload.MaxBytes)
+}
+
+// uploadTooLarge is the error for an upload over upload.max_bytes at the
+// record with index
+func (s *GrpcService) uploadTooLarge(index int32) error {
+	maxBytes := s.config.Upload.MaxBytes
+	return apierror.New(connect.CodeResourceExhausted, apierror.ReasonLimitExceeded,
+		fmt.Errorf("upload exceeds %d bytes at record %d", maxBytes, index),
+		apierror.WithMetadata("max_bytes", strconv.Itoa(maxBytes)))
+}
+
+// uploadFailure counts a failed record, listing it while the summary has
+// room for it
+func (s *GrpcService) uploadFailure(summary *apiv1.UploadDataResponse, index int32, message string) {
+	summary.Failed++
+	if len(summary.Failures) < s.config.Upload.MaxFailures {
+		summary.Failures = append(summary.Failures, &apiv1.UploadFailure{Index: index, ErrorMessage: message})
+	}
+}
@@ -0,0 +1,72 @@
+package servertest_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/servertest"
+)
+
+func TestErrorDetails(t *testing.T) {
+	srv := servertest.New(t)
+
+	for _, client := range srv.Clients() {
+		t.Run(client.Protocol, func(t *testing.T) {
+			ctx := context.Background()
+
+			_, err := client.ProcessData(ctx, connect.NewRequest(&apiv1.ProcessDataRequest{
+				Options: map[string]string{"processor": "regex_extract"},
+			}))
+			require.Error(t, err)
+			assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+			d := apierror.DetailsOf(err)
+			require.NotNil(t, d.Info)
+			assert.Equal(t, apierror.ReasonInvalidOption, d.Info.Reason)
+			assert.Equal(t, apierror.Domain, d.Info.Domain)
+			require.NotNil(t, d.BadRequest)
+			assert.Equal(t, "options.pattern", d.BadRequest.FieldViolations[0].Field)
+			require.NotNil(t, d.Localized)
+			assert.Equal(t, "en-US", d.Localized.Locale)
+
+			_, err = client.ListResults(ctx, connect.NewRequest(&apiv1.ListResultsRequest{Filter: "nope = 1"}))
+			assert.Equal(t, apierror.ReasonInvalidFilter, apierror.Reason(err))
+			assert.Equal(t, "filter", apierror.DetailsOf(err).BadRequest.GetFieldViolations()[0].GetField())
+
+			_, err = client.GetJob(ctx, connect.NewRequest(&apiv1.GetJobRequest{Name: "jobs/missing"}))
+			assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+			assert.Equal(t, apierror.ReasonJobNotFound, apierror.Reason(err))
+			assert.Equal(t, "jobs/missing", apierror.DetailsOf(err).Info.GetMetadata()["name"])
+		})
+	}
+}
+
+func TestErrorDetails_RejectedInput(t *testing.T) {
+	client := servertest.New(t).Client()
+
+	resp, err := client.ProcessData(context.Background(), connect.NewRequest(&apiv1.ProcessDataRequest{
+		Data:    "{broken",
+		Options: map[string]string{"processor": "json_validate"},
+	}))
+
+	require.NoError(t, err, "rejected input is still reported with success=false")
+	assert.False(t, resp.Msg.Success)
+	assert.NotEmpty(t, resp.Msg.ErrorMessage)
+	assert.Equal(t, apierror.ReasonInvalidInput, resp.Msg.ErrorReason)
+
+	batch, err := client.BatchProcessData(context.Background(), connect.NewRequest(&apiv1.BatchProcessDataRequest{
+		Items: []*apiv1.ProcessDataRequest{
+			{Data: "{broken", Options: map[string]string{"processor": "json_validate"}},
+			{Data: "x", Options: map[string]string{"processor": "missing"}},
+		},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, apierror.ReasonInvalidInput, batch.Msg.Results[0].ErrorReason)
+	assert.Equal(t, apierror.ReasonUnknownProcessor, batch.Msg.Results[1].ErrorReason)
+	assert.Equal(t, "invalid_argument", batch.Msg.Results[1].ErrorCode)
+}
@@ -0,0 +1,262 @@
+package servertest_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api/apiv1connect"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/processor"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/server"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/servertest"
+)
+
+func submit(t *testing.T, client apiv1connect.GrpcServiceClient, data string, options map[string]string) *apiv1.Job {
+	t.Helper()
+	resp, err := client.SubmitJob(context.Background(), connect.NewRequest(&apiv1.SubmitJobRequest{
+		Request: &apiv1.ProcessDataRequest{Data: data, Options: options},
+	}))
+	require.NoError(t, err)
+	return resp.Msg
+}
+
+func waitJob(t *testing.T, client apiv1connect.GrpcServiceClient, name string, timeout time.Duration) *apiv1.Job {
+	t.Helper()
+	resp, err := client.WaitJob(context.Background(), connect.NewRequest(&apiv1.WaitJobRequest{
+		Name:    name,
+		Timeout: durationpb.New(timeout),
+	}))
+	require.NoError(t, err)
+	return resp.Msg
+}
+
+// waitJobState polls GetJob until the job reaches state
+func waitJobState(t *testing.T, client apiv1connect.GrpcServiceClient, name string, state apiv1.JobState) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		resp, err := client.GetJob(context.Background(), connect.NewRequest(&apiv1.GetJobRequest{Name: name}))
+		return err == nil && resp.Msg.State == state
+	}, 5*time.Second, 5*time.Millisecond)
+}
+
+func TestJobs(t *testing.T) {
+	srv := servertest.New(t)
+
+	for _, client := range srv.Clients() {
+		t.Run(client.Protocol, func(t *testing.T) {
+			job := submit(t, client, "hello", map[string]string{"pipeline": "uppercase,base64"})
+			assert.Regexp(t, `^jobs/[0-9a-f]{24}$`, job.Name)
+			assert.False(t, job.Done)
+
+			done := waitJob(t, client, job.Name, 5*time.Second)
+			assert.Equal(t, apiv1.JobState_JOB_STATE_SUCCEEDED, done.State)
+			assert.True(t, done.Done)
+			assert.Equal(t, "SEVMTE8=", done.GetResponse().Result)
+			assert.True(t, done.GetResponse().Success)
+			assert.Equal(t, int32(100), done.Metadata.ProgressPercent)
+			assert.NotNil(t, done.Metadata.StartTime)
+			assert.Equal(t, done.Metadata.EndTime.AsTime(), done.GetResponse().ProcessedAt.AsTime())
+
+			got, err := client.GetJob(context.Background(), connect.NewRequest(&apiv1.GetJobRequest{Name: job.Name}))
+			require.NoError(t, err)
+			assert.Equal(t, done.String(), got.Msg.String())
+		})
+	}
+	assert.Equal(t, 3.0, counter(t, srv, "jobs_submitted_total"))
+}
+
+// counter returns the value of an unlabelled counter from the server's
+// registry
+func counter(t *testing.T, srv *servertest.Server, name string) float64 {
+	t.Helper()
+	families, err := srv.Registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	t.Fatalf("metric %s not registered", name)
+	return 0
+}
+
+func TestJobs_Failed(t *testing.T) {
+	client := servertest.New(t).Client()
+
+	job := submit(t, client, "x", map[string]string{"processor": "missing"})
+	done := waitJob(t, client, job.Name, 5*time.Second)
+
+	assert.Equal(t, apiv1.JobState_JOB_STATE_FAILED, done.State)
+	assert.Equal(t, "invalid_argument", done.GetError().Code)
+	assert.Equal(t, apierror.ReasonUnknownProcessor, done.GetError().Reason)
+	assert.Contains(t, done.GetError().Message, "missing")
+	assert.Nil(t, done.GetResponse())
+
+	// Rejected input succeeds the job and keeps its reason on the response
+	job = submit(t, client, "{broken", map[string]string{"processor": "json_validate"})
+	done = waitJob(t, client, job.Name, 5*time.Second)
+	assert.Equal(t, apiv1.JobState_JOB_STATE_SUCCEEDED, done.State)
+	assert.False(t, done.GetResponse().Success)
+	assert.Equal(t, apierror.ReasonInvalidInput, done.GetResponse().ErrorReason)
+}
+
+func TestJobs_Panic(t *testing.T) {
+	processors := processor.NewBuiltinRegistry()
+	processors.MustRegister("panic", processor.Func(func(context.Context, string, map[string]string) (string, error) {
+		panic("boom")
+	}))
+	client := servertest.New(t, servertest.WithServiceOptions(server.WithProcessors(processors))).Client()
+
+	job := submit(t, client, "x", map[string]string{"processor": "panic"})
+	done := waitJob(t, client, job.Name, 5*time.Second)
+
+	assert.Equal(t, apiv1.JobState_JOB_STATE_FAILED, done.State)
+	assert.Equal(t, "internal", done.GetError().Code)
+	assert.Equal(t, apierror.ReasonInternal, done.GetError().Reason)
+
+	// The server is still up and its workers still run jobs
+	next := submit(t, client, "x", map[string]string{"processor": "uppercase"})
+	assert.Equal(t, apiv1.JobState_JOB_STATE_SUCCEEDED, waitJob(t, client, next.Name, 5*time.Second).State)
+}
+
+func TestJobs_Owner(t *testing.T) {
+	cfg, keys := apiKeyConfig(t, "alice", "bob")
+	srv := servertest.New(t, servertest.WithConfig(cfg))
+	alice, bob := srv.Client(withAPIKey(keys[0])), srv.Client(withAPIKey(keys[1]))
+	ctx := context.Background()
+
+	job := submit(t, alice, "x", map[string]string{"processor": "uppercase"})
+	waitJob(t, alice, job.Name, 5*time.Second)
+
+	// Bob's calls treat Alice's job as missing
+	_, err := bob.GetJob(ctx, connect.NewRequest(&apiv1.GetJobRequest{Name: job.Name}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	_, err = bob.WaitJob(ctx, connect.NewRequest(&apiv1.WaitJobRequest{Name: job.Name}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	_, err = bob.CancelJob(ctx, connect.NewRequest(&apiv1.CancelJobRequest{Name: job.Name}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	list, err := bob.ListJobs(ctx, connect.NewRequest(&apiv1.ListJobsRequest{}))
+	require.NoError(t, err)
+	assert.Empty(t, list.Msg.Jobs)
+
+	list, err = alice.ListJobs(ctx, connect.NewRequest(&apiv1.ListJobsRequest{}))
+	require.NoError(t, err)
+	require.Len(t, list.Msg.Jobs, 1)
+	assert.Equal(t, job.Name, list.Msg.Jobs[0].Name)
+}
+
+func TestJobs_Cancel(t *testing.T) {
+	client := gatedServer(t, newGate()).Client()
+	job := submit(t, client, "x", map[string]string{"processor": "gate"})
+	waitJobState(t, client, job.Name, apiv1.JobState_JOB_STATE_RUNNING)
+
+	_, err := client.CancelJob(context.Background(), connect.NewRequest(&apiv1.CancelJobRequest{Name: job.Name}))
+	require.NoError(t, err)
+
+	done := waitJob(t, client, job.Name, 5*time.Second)
+	assert.Equal(t, apiv1.JobState_JOB_STATE_CANCELLED, done.State)
+	assert.Equal(t, "canceled", done.GetError().Code)
+	assert.Equal(t, apierror.ReasonCanceled, done.GetError().Reason)
+}
+
+func TestJobs_WaitTimeout(t *testing.T) {
+	client := gatedServer(t, newGate()).Client()
+	job := submit(t, client, "x", map[string]string{"processor": "gate"})
+
+	current := waitJob(t, client, job.Name, 20*time.Millisecond)
+
+	assert.False(t, current.Done)
+	assert.Nil(t, current.Result)
+}
+
+func TestJobs_List(t *testing.T) {
+	client := servertest.New(t).Client()
+	var names []string
+	for _, data := range []string{"a", "b", "c"} {
+		names = append(names, submit(t, client, data, nil).Name)
+	}
+
+	first, err := client.ListJobs(context.Background(), connect.NewRequest(&apiv1.ListJobsRequest{PageSize: 2}))
+	require.NoError(t, err)
+	require.Len(t, first.Msg.Jobs, 2)
+	assert.Equal(t, names[:2], []string{first.Msg.Jobs[0].Name, first.Msg.Jobs[1].Name})
+	require.NotEmpty(t, first.Msg.NextPageToken)
+
+	second, err := client.ListJobs(context.Background(), connect.NewRequest(&apiv1.ListJobsRequest{
+		PageSize:  2,
+		PageToken: first.Msg.NextPageToken,
+	}))
+	require.NoError(t, err)
+	require.Len(t, second.Msg.Jobs, 1)
+	assert.Equal(t, names[2], second.Msg.Jobs[0].Name)
+	assert.Empty(t, second.Msg.NextPageToken)
+}
+
+func TestJobs_ListFilter(t *testing.T) {
+	client := servertest.New(t).Client()
+	ctx := context.Background()
+	var failed []string
+	for i, processor := range []string{"missing", "uppercase", "missing", "uppercase", "missing"} {
+		job := submit(t, client, fmt.Sprint(i), map[string]string{"processor": processor})
+		waitJob(t, client, job.Name, 5*time.Second)
+		if processor == "missing" {
+			failed = append(failed, job.Name)
+		}
+	}
+
+	filter := `state = "JOB_STATE_FAILED" AND done = true`
+	var names []string
+	token := ""
+	for pages := 1; ; pages++ {
+		resp, err := client.ListJobs(ctx, connect.NewRequest(&apiv1.ListJobsRequest{PageSize: 2, PageToken: token, Filter: filter}))
+		require.NoError(t, err)
+		for _, job := range resp.Msg.Jobs {
+			names = append(names, job.Name)
+		}
+		if token = resp.Msg.NextPageToken; token == "" {
+			assert.Equal(t, 2, pages)
+			break
+		}
+	}
+	assert.Equal(t, failed, names)
+
+	first, err := client.ListJobs(ctx, connect.NewRequest(&apiv1.ListJobsRequest{PageSize: 1, Filter: filter}))
+	require.NoError(t, err)
+	_, err = client.ListJobs(ctx, connect.NewRequest(&apiv1.ListJobsRequest{PageToken: first.Msg.NextPageToken, Filter: "done = true"}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	assert.ErrorContains(t, err, "issued for a request with different parameters")
+
+	_, err = client.ListJobs(ctx, connect.NewRequest(&apiv1.ListJobsRequest{Filter: "status = ok"}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	assert.ErrorContains(t, err, `filter: unknown field "status"`)
+}
+
+func TestJobs_InvalidRequests(t *testing.T) {
+	client := servertest.New(t).Client()
+	ctx := context.Background()
+
+	_, err := client.SubmitJob(ctx, connect.NewRequest(&apiv1.SubmitJobRequest{}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	_, err = client.GetJob(ctx, connect.NewRequest(&apiv1.GetJobRequest{Name: "operations/1"}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	_, err = client.GetJob(ctx, connect.NewRequest(&apiv1.GetJobRequest{Name: "jobs/missing"}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	_, err = client.CancelJob(ctx, connect.NewRequest(&apiv1.CancelJobRequest{Name: "jobs/missing"}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	_, err = client.WaitJob(ctx, connect.NewRequest(&apiv1.WaitJobRequest{Name: "jobs/missing"}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+	_, err = client.WaitJob(ctx, connect.NewRequest(&apiv1.WaitJobRequest{Name: "jobs/missing", Timeout: durationpb.New(-time.Second)}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	_, err = client.ListJobs(ctx, connect.NewRequest(&apiv1.ListJobsRequest{PageSize: -1}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	_, err = client.ListJobs(ctx, connect.NewRequest(&apiv1.ListJobsRequest{PageToken: "!"}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+}
@@ -0,0 +1,189 @@
+package servertest_test
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/config"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/processor"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/server"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/servertest"
+)
+
+// gate is a processor that holds every request until it is opened and
+// records how many requests it held at once
+type gate struct {
+	open chan struct{}
+
+	mu        sync.Mutex
+	active    int
+	maxActive int
+}
+
+func newGate() *gate {
+	return &gate{open: make(chan struct{})}
+}
+
+func (g *gate) Process(ctx context.Context, input string, _ map[string]string) (string, error) {
+	g.mu.Lock()
+	g.active++
+	g.maxActive = max(g.maxActive, g.active)
+	g.mu.Unlock()
+	defer func() {
+		g.mu.Lock()
+		g.active--
+		g.mu.Unlock()
+	}()
+
+	select {
+	case <-g.open:
+		return input, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (g *gate) counts() (active, maxActive int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.active, g.maxActive
+}
+
+// gatedServer starts a server with a "gate" processor next to the builtins
+func gatedServer(t *testing.T, g *gate, opts ...servertest.Option) *servertest.Server {
+	registry := processor.NewBuiltinRegistry()
+	registry.MustRegister("gate", g)
+	return servertest.New(t, append(opts, servertest.WithServiceOptions(server.WithProcessors(registry)))...)
+}
+
+func TestProcessStream(t *testing.T) {
+	srv := servertest.New(t)
+	requests := []*apiv1.ProcessStreamRequest{
+		{Id: "a", Data: "hello", Options: map[string]string{"processor": "uppercase"}},
+		{Id: "b", Data: `{"broken":`, Options: map[string]string{"processor": "json_validate"}},
+		{Id: "c", Data: "x", Options: map[string]string{"processor": "reverse"}},
+		{Id: "d", Options: map[string]string{"seed": "42"}},
+	}
+
+	for _, client := range srv.Clients() {
+		t.Run(client.Protocol, func(t *testing.T) {
+			stream := client.ProcessStream(context.Background())
+			for _, req := range requests {
+				require.NoError(t, stream.Send(req))
+			}
+			require.NoError(t, stream.CloseRequest())
+
+			results := map[string]*apiv1.ProcessStreamResponse{}
+			for range requests {
+				result, err := stream.Receive()
+				require.NoError(t, err)
+				results[result.Id] = result
+			}
+			_, err := stream.Receive()
+			assert.ErrorIs(t, err, io.EOF)
+			require.NoError(t, stream.CloseResponse())
+
+			assert.True(t, results["a"].Success)
+			assert.Equal(t, "HELLO", results["a"].Result)
+			assert.NotNil(t, results["a"].ProcessedAt)
+
+			assert.False(t, results["b"].Success)
+			assert.Contains(t, results["b"].ErrorMessage, "invalid JSON")
+			assert.Empty(t, results["b"].ErrorCode)
+
+			assert.False(t, results["c"].Success)
+			assert.Equal(t, "invalid_argument", results["c"].ErrorCode)
+			assert.Contains(t, results["c"].ErrorMessage, `unknown processor "reverse"`)
+
+			assert.Equal(t, "306", results["d"].Result)
+			assert.Equal(t, "42", results["d"].Seed)
+		})
+	}
+}
+
+func TestProcessStream_OutOfOrder(t *testing.T) {
+	g := newGate()
+	srv := gatedServer(t, g)
+	stream := srv.Client().ProcessStream(context.Background())
+
+	require.NoError(t, stream.Send(&apiv1.ProcessStreamRequest{Id: "slow", Data: "1", Options: map[string]string{"processor": "gate"}}))
+	require.NoError(t, stream.Send(&apiv1.ProcessStreamRequest{Id: "fast", Data: "2", Options: map[string]string{"processor": "uppercase"}}))
+
+	// The second request finishes while the first is still held
+	first, err := stream.Receive()
+	require.NoError(t, err)
+	assert.Equal(t, "fast", first.Id)
+
+	close(g.open)
+	second, err := stream.Receive()
+	require.NoError(t, err)
+	assert.Equal(t, "slow", second.Id)
+	assert.Equal(t, "1", second.Result)
+
+	require.NoError(t, stream.CloseRequest())
+	_, err = stream.Receive()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestProcessStream_MaxInFlight(t *testing.T) {
+	cfg := config.Default()
+	cfg.Process.MaxInFlight = 2
+	g := newGate()
+	srv := gatedServer(t, g, servertest.WithConfig(cfg))
+	stream := srv.Client().ProcessStream(context.Background())
+
+	for _, id := range []string{"1", "2", "3", "4", "5"} {
+		require.NoError(t, stream.Send(&apiv1.ProcessStreamRequest{Id: id, Data: id, Options: map[string]string{"processor": "gate"}}))
+	}
+	require.NoError(t, stream.CloseRequest())
+
+	require.Eventually(t, func() bool {
+		active, _ := g.counts()
+		return active == 2
+	}, 5*time.Second, 5*time.Millisecond)
+	// The remaining requests stay unread while both slots are taken
+	time.Sleep(50 * time.Millisecond)
+	_, maxActive := g.counts()
+	assert.Equal(t, 2, maxActive)
+
+	close(g.open)
+	ids := map[string]bool{}
+	for i := 0; i < 5; i++ {
+		result, err := stream.Receive()
+		require.NoError(t, err)
+		ids[result.Id] = true
+	}
+	assert.Len(t, ids, 5)
+	_, maxActive = g.counts()
+	assert.Equal(t, 2, maxActive)
+}
+
+func TestProcessStream_ClientCancel(t *testing.T) {
+	g := newGate()
+	srv := gatedServer(t, g)
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := srv.Client().ProcessStream(ctx)
+
+	require.NoError(t, stream.Send(&apiv1.ProcessStreamRequest{Id: "held", Options: map[string]string{"processor": "gate"}}))
+	require.Eventually(t, func() bool {
+		active, _ := g.counts()
+		return active == 1
+	}, 5*time.Second, 5*time.Millisecond)
+	cancel()
+
+	// Canceling the stream releases the request being processed
+	require.Eventually(t, func() bool {
+		active, _ := g.counts()
+		return active == 0
+	}, 5*time.Second, 5*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return handled(t, srv, "ProcessStream", "Canceled") == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
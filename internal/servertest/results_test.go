@@ -0,0 +1,179 @@
+package servertest_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api/apiv1connect"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/config"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/server"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/servertest"
+)
+
+// makeCalls makes two ProcessData calls, one of them failing, and a
+// StreamData call
+func makeCalls(t *testing.T, client apiv1connect.GrpcServiceClient) {
+	t.Helper()
+	ctx := context.Background()
+	_, err := client.ProcessData(ctx, connect.NewRequest(&apiv1.ProcessDataRequest{
+		Data:    "hello",
+		Options: map[string]string{"processor": "uppercase"},
+	}))
+	require.NoError(t, err)
+	_, err = client.ProcessData(ctx, connect.NewRequest(&apiv1.ProcessDataRequest{
+		Options: map[string]string{"processor": "missing"},
+	}))
+	require.Error(t, err)
+	stream, err := client.StreamData(ctx, connect.NewRequest(&apiv1.StreamDataRequest{Query: "orders", Limit: 2}))
+	require.NoError(t, err)
+	_, err = receiveAll(stream)
+	require.NoError(t, err)
+}
+
+func listResults(t *testing.T, client apiv1connect.GrpcServiceClient, req *apiv1.ListResultsRequest) *apiv1.ListResultsResponse {
+	t.Helper()
+	resp, err := client.ListResults(context.Background(), connect.NewRequest(req))
+	require.NoError(t, err)
+	return resp.Msg
+}
+
+func TestListResults(t *testing.T) {
+	client := servertest.New(t, servertest.WithServiceOptions(server.WithClock(newFakeClock()))).Client()
+	makeCalls(t, client)
+
+	all := listResults(t, client, &apiv1.ListResultsRequest{})
+	require.Len(t, all.Results, 3)
+	assert.Empty(t, all.NextPageToken)
+
+	stream, failed, ok := all.Results[0], all.Results[1], all.Results[2]
+	assert.Equal(t, "StreamData", stream.Method)
+	assert.Equal(t, "orders", stream.Input)
+	assert.Equal(t, "sent 2 messages", stream.Output)
+	assert.True(t, stream.Success)
+
+	assert.Equal(t, "ProcessData", failed.Method)
+	assert.False(t, failed.Success)
+	assert.Equal(t, "invalid_argument", failed.ErrorCode)
+	assert.Contains(t, failed.ErrorMessage, "unknown processor")
+
+	assert.Equal(t, "hello", ok.Input)
+	assert.Equal(t, "HELLO", ok.Output)
+	assert.Equal(t, map[string]string{"processor": "uppercase"}, ok.Options)
+	assert.True(t, ok.Success)
+	assert.Equal(t, start, ok.CreateTime.AsTime())
+	assert.Greater(t, failed.Id, ok.Id)
+
+	processed := listResults(t, client, &apiv1.ListResultsRequest{Method: "ProcessData", Success: proto.Bool(false)})
+	require.Len(t, processed.Results, 1)
+	assert.Equal(t, failed.Id, processed.Results[0].Id)
+
+	later := listResults(t, client, &apiv1.ListResultsRequest{StartTime: timestamppb.New(start.Add(time.Millisecond))})
+	require.Len(t, later.Results, 1, "the stream ended after the fake clock advanced")
+	assert.Equal(t, stream.Id, later.Results[0].Id)
+}
+
+func TestListResults_Pages(t *testing.T) {
+	client := servertest.New(t).Client()
+	makeCalls(t, client)
+
+	first := listResults(t, client, &apiv1.ListResultsRequest{PageSize: 2})
+	require.Len(t, first.Results, 2)
+	require.NotEmpty(t, first.NextPageToken)
+
+	second := listResults(t, client, &apiv1.ListResultsRequest{PageSize: 2, PageToken: first.NextPageToken})
+	require.Len(t, second.Results, 1)
+	assert.Empty(t, second.NextPageToken)
+	assert.Less(t, second.Results[0].Id, first.Results[1].Id)
+
+	_, err := client.ListResults(context.Background(), connect.NewRequest(&apiv1.ListResultsRequest{PageToken: "bad"}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	_, err = client.ListResults(context.Background(), connect.NewRequest(&apiv1.ListResultsRequest{PageSize: -1}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+}
+
+func TestListResults_Filter(t *testing.T) {
+	for name, cfg := range map[string]*config.Config{"memory": config.Default(), "sqlite": sqliteConfig(t)} {
+		t.Run(name, func(t *testing.T) {
+			client := servertest.New(t, servertest.WithConfig(cfg), servertest.WithServiceOptions(server.WithClock(newFakeClock()))).Client()
+			makeCalls(t, client)
+
+			ok := listResults(t, client, &apiv1.ListResultsRequest{Filter: `status = "ok" AND processed_at >= "2024-03-01"`})
+			require.Len(t, ok.Results, 2)
+			assert.Equal(t, "StreamData", ok.Results[0].Method)
+			assert.Equal(t, "ProcessData", ok.Results[1].Method)
+			assert.Equal(t, "ok", ok.Results[1].Status)
+
+			failed := listResults(t, client, &apiv1.ListResultsRequest{Filter: `NOT status = ok`})
+			require.Len(t, failed.Results, 1)
+			assert.Equal(t, "invalid_argument", failed.Results[0].Status)
+
+			combined := listResults(t, client, &apiv1.ListResultsRequest{Method: "ProcessData", Filter: `success = true OR input = "orders"`})
+			require.Len(t, combined.Results, 1, "filter is combined with the other fields")
+			assert.Equal(t, "HELLO", combined.Results[0].Output)
+
+			_, err := client.ListResults(context.Background(), connect.NewRequest(&apiv1.ListResultsRequest{Filter: `status = `}))
+			assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+			assert.ErrorContains(t, err, "filter: expected a value after status = but found end of filter")
+		})
+	}
+}
+
+func TestListResults_Owner(t *testing.T) {
+	cfg, keys := apiKeyConfig(t, "alice", "bob")
+	srv := servertest.New(t, servertest.WithConfig(cfg))
+	alice, bob := srv.Client(withAPIKey(keys[0])), srv.Client(withAPIKey(keys[1]))
+	makeCalls(t, alice)
+
+	assert.Empty(t, listResults(t, bob, &apiv1.ListResultsRequest{}).Results, "Bob does not see Alice's inputs")
+	assert.Len(t, listResults(t, alice, &apiv1.ListResultsRequest{}).Results, 3)
+}
+
+func TestListResults_TokenTiedToFilter(t *testing.T) {
+	client := servertest.New(t).Client()
+	makeCalls(t, client)
+
+	first := listResults(t, client, &apiv1.ListResultsRequest{PageSize: 1, Filter: `method = "ProcessData"`})
+	require.NotEmpty(t, first.NextPageToken)
+
+	for name, req := range map[string]*apiv1.ListResultsRequest{
+		"other filter":  {PageToken: first.NextPageToken, Filter: `method = "StreamData"`},
+		"no filter":     {PageToken: first.NextPageToken},
+		"added success": {PageToken: first.NextPageToken, Filter: `method = "ProcessData"`, Success: proto.Bool(true)},
+	} {
+		_, err := client.ListResults(context.Background(), connect.NewRequest(req))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err), name)
+	}
+
+	second := listResults(t, client, &apiv1.ListResultsRequest{PageSize: 5, PageToken: first.NextPageToken, Filter: `method = "ProcessData"`})
+	require.Len(t, second.Results, 1, "the page size may change between pages")
+	assert.Empty(t, second.NextPageToken)
+}
+
+// sqliteConfig returns the default configuration with SQLite storage in a
+// temporary directory
+func sqliteConfig(t *testing.T) *config.Config {
+	cfg := config.Default()
+	cfg.Storage.Driver = "sqlite"
+	cfg.Storage.Path = filepath.Join(t.TempDir(), "test.db")
+	return cfg
+}
+
+func TestSQLiteStorage(t *testing.T) {
+	client := servertest.New(t, servertest.WithConfig(sqliteConfig(t))).Client()
+
+	makeCalls(t, client)
+	job := submit(t, client, "hello", map[string]string{"processor": "uppercase"})
+	done := waitJob(t, client, job.Name, 5*time.Second)
+
+	assert.Equal(t, "HELLO", done.GetResponse().Result)
+	assert.Len(t, listResults(t, client, &apiv1.ListResultsRequest{}).Results, 3)
+}
@@ -0,0 +1,474 @@
+package servertest_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api/apiv1connect"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/auth"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/clock"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/config"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/server"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/servertest"
+)
+
+var start = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// newFakeClock returns a clock that advances whenever the service waits,
+// so streams finish without sleeping
+func newFakeClock() *clock.Fake {
+	fake := clock.NewFake(start)
+	fake.SetAutoAdvance(true)
+	return fake
+}
+
+func TestStreamData(t *testing.T) {
+	srv := servertest.New(t, servertest.WithServiceOptions(server.WithClock(newFakeClock())))
+
+	for _, client := range srv.Clients() {
+		t.Run(client.Protocol, func(t *testing.T) {
+			stream, err := client.StreamData(context.Background(), connect.NewRequest(&apiv1.StreamDataRequest{
+				Query: "orders",
+				Limit: 3,
+			}))
+			require.NoError(t, err)
+
+			var received []*apiv1.StreamDataResponse
+			for stream.Receive() {
+				received = append(received, stream.Msg())
+			}
+			require.NoError(t, stream.Err())
+			require.NoError(t, stream.Close())
+
+			require.Len(t, received, 3)
+			for i, msg := range received {
+				assert.Equal(t, int32(i+1), msg.Sequence)
+				assert.Equal(t, fmt.Sprintf("Stream data %d for query: orders", i+1), msg.Data)
+			}
+			assert.True(t, received[1].Timestamp.AsTime().Sub(received[0].Timestamp.AsTime()) >= 100*time.Millisecond)
+		})
+	}
+}
+
+func TestStreamData_DefaultLimit(t *testing.T) {
+	srv := servertest.New(t, servertest.WithServiceOptions(server.WithClock(newFakeClock())))
+
+	stream, err := srv.Client().StreamData(context.Background(), connect.NewRequest(&apiv1.StreamDataRequest{Query: "q"}))
+	require.NoError(t, err)
+
+	count := 0
+	for stream.Receive() {
+		count++
+	}
+	require.NoError(t, stream.Err())
+	require.NoError(t, stream.Close())
+	assert.Equal(t, 10, count)
+}
+
+// receiveAll drains a stream, returning its messages and final error
+func receiveAll(stream *connect.ServerStreamForClient[apiv1.StreamDataResponse]) ([]*apiv1.StreamDataResponse, error) {
+	var received []*apiv1.StreamDataResponse
+	for stream.Receive() {
+		received = append(received, stream.Msg())
+	}
+	err := stream.Err()
+	_ = stream.Close()
+	return received, err
+}
+
+func TestStreamData_Resume(t *testing.T) {
+	srv := servertest.New(t, servertest.WithServiceOptions(server.WithClock(newFakeClock())))
+
+	for _, client := range srv.Clients() {
+		t.Run(client.Protocol, func(t *testing.T) {
+			request := &apiv1.StreamDataRequest{Query: "orders", Limit: 10}
+			full, err := client.StreamData(context.Background(), connect.NewRequest(request))
+			require.NoError(t, err)
+			expected, err := receiveAll(full)
+			require.NoError(t, err)
+
+			// Disconnect after the fourth message
+			ctx, cancel := context.WithCancel(context.Background())
+			interrupted, err := client.StreamData(ctx, connect.NewRequest(request))
+			require.NoError(t, err)
+			var last *apiv1.StreamDataResponse
+			for i := 0; i < 4 && interrupted.Receive(); i++ {
+				last = interrupted.Msg()
+			}
+			cancel()
+			_ = interrupted.Close()
+			require.Equal(t, int32(4), last.Sequence)
+
+			resumed, err := client.StreamData(context.Background(), connect.NewRequest(&apiv1.StreamDataRequest{
+				Query:       "orders",
+				Limit:       10,
+				ResumeToken: last.Cursor,
+			}))
+			require.NoError(t, err)
+			rest, err := receiveAll(resumed)
+			require.NoError(t, err)
+
+			require.Len(t, rest, 6)
+			for i, msg := range rest {
+				assert.Equal(t, expected[4+i].Sequence, msg.Sequence)
+				assert.Equal(t, expected[4+i].Data, msg.Data)
+				assert.Equal(t, expected[4+i].Cursor, msg.Cursor, "cursors are deterministic")
+			}
+		})
+	}
+}
+
+func TestStreamData_ResumeOnAnotherServer(t *testing.T) {
+	cursor := firstCursor(t, servertest.New(t).Client(), "orders")
+
+	// A restarted pod or another replica has its own page token key
+	stream, err := servertest.New(t).Client().StreamData(context.Background(), connect.NewRequest(&apiv1.StreamDataRequest{
+		Query:       "orders",
+		Limit:       3,
+		ResumeToken: cursor,
+	}))
+	require.NoError(t, err)
+	received, err := receiveAll(stream)
+	require.NoError(t, err)
+
+	require.Len(t, received, 2)
+	assert.Equal(t, int32(2), received[0].Sequence)
+}
+
+func TestStreamData_StartAfterSequence(t *testing.T) {
+	srv := servertest.New(t, servertest.WithServiceOptions(server.WithClock(newFakeClock())))
+
+	stream, err := srv.Client().StreamData(context.Background(), connect.NewRequest(&apiv1.StreamDataRequest{
+		Query:              "q",
+		Limit:              5,
+		StartAfterSequence: 3,
+	}))
+	require.NoError(t, err)
+	received, err := receiveAll(stream)
+	require.NoError(t, err)
+
+	require.Len(t, received, 2)
+	assert.Equal(t, int32(4), received[0].Sequence)
+	assert.Equal(t, int32(5), received[1].Sequence)
+}
+
+func TestStreamData_InvalidResume(t *testing.T) {
+	srv := servertest.New(t)
+	client := srv.Client()
+
+	tests := map[string]*apiv1.StreamDataRequest{
+		"garbage token":  {Query: "q", ResumeToken: "garbage"},
+		"other query":    {Query: "q", ResumeToken: firstCursor(t, client, "other")},
+		"both set":       {Query: "q", ResumeToken: firstCursor(t, client, "q"), StartAfterSequence: 1},
+		"negative start": {Query: "q", StartAfterSequence: -1},
+	}
+	for name, request := range tests {
+		t.Run(name, func(t *testing.T) {
+			stream, err := client.StreamData(context.Background(), connect.NewRequest(request))
+			require.NoError(t, err)
+			_, err = receiveAll(stream)
+			assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+		})
+	}
+}
+
+// firstCursor returns the cursor of the first message of a stream for query
+func firstCursor(t *testing.T, client apiv1connect.GrpcServiceClient, query string) string {
+	t.Helper()
+	stream, err := client.StreamData(context.Background(), connect.NewRequest(&apiv1.StreamDataRequest{Query: query, Limit: 1}))
+	require.NoError(t, err)
+	received, err := receiveAll(stream)
+	require.NoError(t, err)
+	require.Len(t, received, 1)
+	return received[0].Cursor
+}
+
+func TestStreamData_LimitTooLarge(t *testing.T) {
+	srv := servertest.New(t)
+
+	stream, err := srv.Client().StreamData(context.Background(), connect.NewRequest(&apiv1.StreamDataRequest{Limit: 2147483647}))
+	require.NoError(t, err)
+	_, err = receiveAll(stream)
+
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	assert.ErrorContains(t, err, "limit: must be at most 1000")
+	assert.Equal(t, apierror.ReasonLimitExceeded, apierror.Reason(err))
+}
+
+// TestStreamData_LimitLikePageSize checks that StreamData rejects the sizes
+// the List RPCs reject, with the same status
+func TestStreamData_LimitLikePageSize(t *testing.T) {
+	srv := servertest.New(t)
+	ctx := context.Background()
+
+	for _, client := range srv.Clients() {
+		for _, size := range []int32{-1, -2147483648} {
+			t.Run(fmt.Sprintf("%s/%d", client.Protocol, size), func(t *testing.T) {
+				stream, err := client.StreamData(ctx, connect.NewRequest(&apiv1.StreamDataRequest{Limit: size}))
+				require.NoError(t, err)
+				_, streamErr := receiveAll(stream)
+				_, resultsErr := client.ListResults(ctx, connect.NewRequest(&apiv1.ListResultsRequest{PageSize: size}))
+				_, jobsErr := client.ListJobs(ctx, connect.NewRequest(&apiv1.ListJobsRequest{PageSize: size}))
+
+				want := fmt.Sprintf("must not be negative, got %d", size)
+				for _, got := range []struct {
+					field string
+					err   error
+				}{{"limit", streamErr}, {"page_size", resultsErr}, {"page_size", jobsErr}} {
+					field, err := got.field, got.err
+					assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+					assert.Equal(t, apierror.ReasonInvalidArgument, apierror.Reason(err))
+					assert.ErrorContains(t, err, field+": "+want)
+					violations := apierror.DetailsOf(err).BadRequest.GetFieldViolations()
+					require.Len(t, violations, 1)
+					assert.Equal(t, field, violations[0].GetField())
+					assert.Equal(t, want, violations[0].GetDescription())
+				}
+			})
+		}
+	}
+}
+
+func TestStreamData_ClientCancel(t *testing.T) {
+	// The clock never moves, so the handler waits after the first message
+	srv := servertest.New(t, servertest.WithServiceOptions(server.WithClock(clock.NewFake(start))))
+
+	for _, client := range srv.Clients() {
+		t.Run(client.Protocol, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			stream, err := client.StreamData(ctx, connect.NewRequest(&apiv1.StreamDataRequest{Limit: 5}))
+			require.NoError(t, err)
+			require.True(t, stream.Receive())
+			cancel()
+			assert.False(t, stream.Receive())
+			assert.Equal(t, connect.CodeCanceled, connect.CodeOf(stream.Err()))
+			_ = stream.Close()
+		})
+	}
+
+	assert.Eventually(t, func() bool {
+		return handled(t, srv, "StreamData", "Canceled") == 3
+	}, 5*time.Second, 10*time.Millisecond, "the server records every canceled stream")
+	assert.Zero(t, srv.App.Drainer.InFlight(), "no handler is left running")
+}
+
+func TestStreamData_ClientDeadline(t *testing.T) {
+	srv := servertest.New(t, servertest.WithServiceOptions(server.WithClock(clock.NewFake(start))))
+
+	// Send only the timeout header, without a client-side deadline, so the
+	// server's deadline is the one that expires
+	timeoutHeaders := map[string][2]string{
+		servertest.ProtocolConnect: {"Connect-Timeout-Ms", "100"},
+		servertest.ProtocolGRPC:    {"Grpc-Timeout", "100m"},
+		servertest.ProtocolGRPCWeb: {"Grpc-Timeout", "100m"},
+	}
+	for _, client := range srv.Clients() {
+		t.Run(client.Protocol, func(t *testing.T) {
+			req := connect.NewRequest(&apiv1.StreamDataRequest{Limit: 5})
+			header := timeoutHeaders[client.Protocol]
+			req.Header().Set(header[0], header[1])
+
+			stream, err := client.StreamData(context.Background(), req)
+			require.NoError(t, err)
+			received, err := receiveAll(stream)
+			assert.Len(t, received, 1)
+			assert.Equal(t, connect.CodeDeadlineExceeded, connect.CodeOf(err), "%v", err)
+		})
+	}
+
+	assert.Equal(t, float64(3), handled(t, srv, "StreamData", "DeadlineExceeded"))
+}
+
+func TestStreamData_MaxDuration(t *testing.T) {
+	cfg := config.Default()
+	cfg.Stream.MaxDuration = 250 * time.Millisecond
+	srv := servertest.New(t, servertest.WithConfig(cfg), servertest.WithServiceOptions(server.WithClock(newFakeClock())))
+
+	stream, err := srv.Client().StreamData(context.Background(), connect.NewRequest(&apiv1.StreamDataRequest{Limit: 10}))
+	require.NoError(t, err)
+	received, err := receiveAll(stream)
+
+	assert.Len(t, received, 3, "messages at 0, 100 and 200ms fit in 250ms")
+	assert.Equal(t, connect.CodeDeadlineExceeded, connect.CodeOf(err))
+	assert.ErrorContains(t, err, "maximum duration of 250ms")
+	assert.Equal(t, float64(1), handled(t, srv, "StreamData", "DeadlineExceeded"))
+}
+
+func TestStreamData_Interval(t *testing.T) {
+	cfg := config.Default()
+	cfg.Stream.Interval = time.Second
+	srv := servertest.New(t, servertest.WithConfig(cfg), servertest.WithServiceOptions(server.WithClock(newFakeClock())))
+
+	stream, err := srv.Client().StreamData(context.Background(), connect.NewRequest(&apiv1.StreamDataRequest{Limit: 3}))
+	require.NoError(t, err)
+	received, err := receiveAll(stream)
+	require.NoError(t, err)
+
+	require.Len(t, received, 3)
+	assert.Equal(t, start.Add(2*time.Second), received[2].Timestamp.AsTime())
+}
+
+func TestProcessData_AllProtocols(t *testing.T) {
+	srv := servertest.New(t)
+
+	for _, client := range srv.Clients() {
+		t.Run(client.Protocol, func(t *testing.T) {
+			resp, err := client.ProcessData(context.Background(), connect.NewRequest(&apiv1.ProcessDataRequest{
+				Data:    "abc",
+				Options: map[string]string{"processor": "uppercase"},
+			}))
+			require.NoError(t, err)
+			assert.Equal(t, "ABC", resp.Msg.Result)
+			assert.Len(t, resp.Header().Get("X-Request-Id"), 32, "the access log interceptor ran")
+		})
+	}
+}
+
+func TestProcessData_ErrorCode(t *testing.T) {
+	srv := servertest.New(t)
+
+	for _, client := range srv.Clients() {
+		t.Run(client.Protocol, func(t *testing.T) {
+			_, err := client.ProcessData(context.Background(), connect.NewRequest(&apiv1.ProcessDataRequest{
+				Options: map[string]string{"processor": "missing"},
+			}))
+			assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+		})
+	}
+
+	assert.Equal(t, float64(3), handled(t, srv, "ProcessData", "InvalidArgument"))
+}
+
+// handled sums grpc_server_handled_total for method and code across protocols
+func handled(t *testing.T, srv *servertest.Server, method, code string) float64 {
+	t.Helper()
+	families, err := srv.Registry.Gather()
+	require.NoError(t, err)
+	var total float64
+	for _, family := range families {
+		if family.GetName() != "grpc_server_handled_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["grpc_method"] == method && labels["grpc_code"] == code {
+				total += metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return total
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	srv := servertest.New(t)
+
+	req, err := http.NewRequest(http.MethodOptions, srv.URL+"/api.v1.GrpcService/ProcessData", nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", "https://example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	resp, err := srv.HTTPClient().Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "*", resp.Header.Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, resp.Header.Get("Access-Control-Allow-Headers"), "X-Api-Key")
+	assert.Contains(t, resp.Header.Get("Access-Control-Expose-Headers"), "X-Request-Id")
+}
+
+// withAPIKey sends key on every call
+func withAPIKey(key string) connect.ClientOption {
+	return connect.WithInterceptors(apiKeyInterceptor(key))
+}
+
+// apiKeyInterceptor sets the API key header on unary and streaming calls
+type apiKeyInterceptor string
+
+func (key apiKeyInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		req.Header().Set(auth.APIKeyHeader, string(key))
+		return next(ctx, req)
+	}
+}
+
+func (key apiKeyInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+		conn.RequestHeader().Set(auth.APIKeyHeader, string(key))
+		return conn
+	}
+}
+
+func (apiKeyInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return next
+}
+
+// apiKeyConfig enables API keys and returns the keys of principals ids,
+// each allowed to call any procedure
+func apiKeyConfig(t *testing.T, ids ...string) (*config.Config, []string) {
+	var keys []string
+	var entries []auth.APIKey
+	for _, id := range ids {
+		key, entry, err := auth.NewAPIKey(id, []string{"*"}, time.Time{})
+		require.NoError(t, err)
+		keys = append(keys, key)
+		entries = append(entries, entry)
+	}
+	store, err := yaml.Marshal(map[string]any{"keys": entries})
+	require.NoError(t, err)
+
+	cfg := config.Default()
+	cfg.Auth.APIKeys.Enabled = true
+	cfg.Auth.APIKeys.Keys = string(store)
+	return cfg, keys
+}
+
+func TestAuth_APIKey(t *testing.T) {
+	key, entry, err := auth.NewAPIKey("ci", []string{"ProcessData"}, time.Time{})
+	require.NoError(t, err)
+	store, err := yaml.Marshal(map[string]any{"keys": []auth.APIKey{entry}})
+	require.NoError(t, err)
+
+	cfg := config.Default()
+	cfg.Auth.APIKeys.Enabled = true
+	cfg.Auth.APIKeys.Keys = string(store)
+	srv := servertest.New(t, servertest.WithConfig(cfg))
+
+	withKey := withAPIKey(key)
+
+	for _, client := range srv.Clients() {
+		t.Run(client.Protocol, func(t *testing.T) {
+			_, err := client.ProcessData(context.Background(), connect.NewRequest(&apiv1.ProcessDataRequest{}))
+			assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+			assert.Equal(t, apierror.ReasonUnauthenticated, apierror.Reason(err))
+			assert.Equal(t, apierror.Domain, apierror.DetailsOf(err).Info.GetDomain())
+
+			_, err = client.GetHealth(context.Background(), connect.NewRequest(&apiv1.GetHealthRequest{}))
+			assert.NoError(t, err, "public procedures need no credentials")
+		})
+	}
+
+	for _, client := range srv.Clients(withKey) {
+		t.Run(client.Protocol+"/key", func(t *testing.T) {
+			_, err := client.ProcessData(context.Background(), connect.NewRequest(&apiv1.ProcessDataRequest{}))
+			assert.NoError(t, err)
+
+			_, err = client.GetInfo(context.Background(), connect.NewRequest(&apiv1.GetInfoRequest{}))
+			assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err))
+			assert.Equal(t, apierror.ReasonScopeDenied, apierror.Reason(err))
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package servertest_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api/apiv1connect"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/clock"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/config"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/server"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/servertest"
+)
+
+// upload sends records on one UploadData stream
+func upload(client apiv1connect.GrpcServiceClient, records []*apiv1.UploadDataRequest) (*apiv1.UploadDataResponse, error) {
+	stream := client.UploadData(context.Background())
+	for _, record := range records {
+		if err := stream.Send(record); err != nil {
+			break
+		}
+	}
+	resp, err := stream.CloseAndReceive()
+	if err != nil {
+		return nil, err
+	}
+	return resp.Msg, nil
+}
+
+func TestUploadData(t *testing.T) {
+	srv := servertest.New(t, servertest.WithServiceOptions(server.WithClock(clock.NewFake(start))))
+	records := []*apiv1.UploadDataRequest{
+		{Data: "one", Options: map[string]string{"processor": "uppercase"}},
+		{Data: `{"broken":`, Options: map[string]string{"processor": "json_validate"}},
+		{Data: "two", Options: map[string]string{"processor": "reverse"}},
+		{Data: "three four", Options: map[string]string{"processor": "word_count"}},
+		{Options: map[string]string{"seed": "7"}},
+	}
+
+	for _, client := range srv.Clients() {
+		t.Run(client.Protocol, func(t *testing.T) {
+			summary, err := upload(client, records)
+			require.NoError(t, err)
+
+			assert.Equal(t, int32(5), summary.Received)
+			assert.Equal(t, int32(3), summary.Succeeded)
+			assert.Equal(t, int32(2), summary.Failed)
+			require.Len(t, summary.Failures, 2)
+			assert.Equal(t, int32(1), summary.Failures[0].Index)
+			assert.Contains(t, summary.Failures[0].ErrorMessage, "invalid JSON")
+			assert.Equal(t, int32(2), summary.Failures[1].Index)
+			assert.Contains(t, summary.Failures[1].ErrorMessage, `unknown processor "reverse"`)
+			assert.Positive(t, summary.BytesReceived)
+			assert.Equal(t, start, summary.StartedAt.AsTime())
+			assert.NotNil(t, summary.Duration)
+		})
+	}
+}
+
+func TestUploadData_Empty(t *testing.T) {
+	srv := servertest.New(t)
+
+	summary, err := upload(srv.Client(), nil)
+
+	require.NoError(t, err)
+	assert.Zero(t, summary.Received)
+	assert.Empty(t, summary.Failures)
+}
+
+func TestUploadData_Limits(t *testing.T) {
+	cfg := config.Default()
+	cfg.Upload.MaxRecords = 3
+	cfg.Upload.MaxBytes = 256
+	cfg.Upload.MaxFailures = 1
+	srv := servertest.New(t, servertest.WithConfig(cfg))
+	client := srv.Client()
+
+	record := &apiv1.UploadDataRequest{Data: "x", Options: map[string]string{"processor": "uppercase"}}
+	_, err := upload(client, []*apiv1.UploadDataRequest{record, record, record, record})
+	assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+	assert.ErrorContains(t, err, "upload exceeds 3 records")
+
+	large := &apiv1.UploadDataRequest{Data: strings.Repeat("x", 300)}
+	_, err = upload(client, []*apiv1.UploadDataRequest{large})
+	assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err))
+	assert.ErrorContains(t, err, "upload exceeds 256 bytes at record 0")
+
+	// A record over max_bytes is refused by its size prefix, before it is read
+	huge := &apiv1.UploadDataRequest{Data: strings.Repeat("x", 1<<20)}
+	for _, client := range srv.Clients() {
+		_, err = upload(client, []*apiv1.UploadDataRequest{record, huge})
+		assert.Equal(t, connect.CodeResourceExhausted, connect.CodeOf(err), client.Protocol)
+		assert.ErrorContains(t, err, "upload exceeds 256 bytes at record 1", client.Protocol)
+		assert.Equal(t, apierror.ReasonLimitExceeded, apierror.Reason(err), client.Protocol)
+	}
+
+	invalid := &apiv1.UploadDataRequest{Options: map[string]string{"processor": "missing"}}
+	summary, err := upload(client, []*apiv1.UploadDataRequest{invalid, invalid, invalid})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), summary.Failed, "every failure is counted")
+	assert.Len(t, summary.Failures, 1, "only max_failures are listed")
+}
+
+func TestUploadData_Canceled(t *testing.T) {
+	srv := servertest.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := srv.Client().UploadData(ctx)
+	require.NoError(t, stream.Send(&apiv1.UploadDataRequest{Data: "x"}))
+	cancel()
+	_, err := stream.CloseAndReceive()
+
+	assert.Equal(t, connect.CodeCanceled, connect.CodeOf(err))
+	assert.Eventually(t, func() bool {
+		return handled(t, srv, "UploadData", "Canceled") == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
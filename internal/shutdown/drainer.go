@@ -0,0 +1,171 @@
+// Package shutdown coordinates draining in-flight RPCs during graceful
+// shutdown.
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/bufbuild/connect-go"
+
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+)
+
+// ErrShuttingDown is returned to RPCs rejected or aborted by a draining server
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// Drainer tracks in-flight RPCs through its interceptor. Once Drain is
+// called new RPCs are rejected with CodeUnavailable, Wait blocks until the
+// in-flight ones finish, and Abort cancels those still running.
+type Drainer struct {
+	mu       sync.Mutex
+	inFlight int
+	draining bool
+	idle     chan struct{}
+	abort    chan struct{}
+	aborted  bool
+}
+
+// NewDrainer creates a Drainer that accepts RPCs
+func NewDrainer() *Drainer {
+	idle := make(chan struct{})
+	close(idle)
+	return &Drainer{
+		idle:  idle,
+		abort: make(chan struct{}),
+	}
+}
+
+// Drain stops accepting new RPCs
+func (d *Drainer) Drain() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.draining = true
+}
+
+// InFlight returns the number of RPCs currently being handled
+func (d *Drainer) InFlight() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.inFlight
+}
+
+// Wait blocks until no RPCs are in flight or ctx is done
+func (d *Drainer) Wait(ctx context.Context) error {
+	d.mu.Lock()
+	idle := d.idle
+	d.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Abort cancels the context of every in-flight RPC. Handlers that return
+// because of it report CodeUnavailable so clients know to retry elsewhere.
+func (d *Drainer) Abort() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.aborted {
+		d.aborted = true
+		close(d.abort)
+	}
+}
+
+// Interceptor returns the connect interceptor that enforces draining
+func (d *Drainer) Interceptor() connect.Interceptor {
+	return &interceptor{drainer: d}
+}
+
+// begin registers a new RPC, returning false when draining
+func (d *Drainer) begin() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return false
+	}
+	if d.inFlight == 0 {
+		d.idle = make(chan struct{})
+	}
+	d.inFlight++
+	return true
+}
+
+func (d *Drainer) end() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.inFlight--
+	if d.inFlight == 0 {
+		close(d.idle)
+	}
+}
+
+// run executes an RPC with a context that is canceled on Abort
+func (d *Drainer) run(ctx context.Context, fn func(context.Context) error) error {
+	if !d.begin() {
+		return shuttingDown()
+	}
+	defer d.end()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-d.abort:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	err := fn(ctx)
+	select {
+	case <-d.abort:
+		if err != nil {
+			return shuttingDown()
+		}
+	default:
+	}
+	return err
+}
+
+type interceptor struct {
+	drainer *Drainer
+}
+
+func (i *interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			return next(ctx, req)
+		}
+		var resp connect.AnyResponse
+		err := i.drainer.run(ctx, func(ctx context.Context) error {
+			var err error
+			resp, err = next(ctx, req)
+			return err
+		})
+		return resp, err
+	}
+}
+
+func (i *interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (i *interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		return i.drainer.run(ctx, func(ctx context.Context) error {
+			return next(ctx, conn)
+		})
+	}
+}
+
+// shuttingDown is the error of RPCs rejected or aborted by a draining server.
+// Another replica can serve the retry.
+func shuttingDown() error {
+	return apierror.New(connect.CodeUnavailable, apierror.ReasonShuttingDown, ErrShuttingDown,
+		apierror.WithRetryDelay(apierror.RetryDelay))
+}
@@ -0,0 +1,85 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/apierror"
+)
+
+func TestDrainer_WaitIdle(t *testing.T) {
+	drainer := NewDrainer()
+
+	assert.NoError(t, drainer.Wait(context.Background()))
+}
+
+func TestDrainer_RejectsAfterDrain(t *testing.T) {
+	drainer := NewDrainer()
+	drainer.Drain()
+
+	called := false
+	err := drainer.run(context.Background(), func(context.Context) error {
+		called = true
+		return nil
+	})
+
+	assert.False(t, called)
+	assert.Equal(t, connect.CodeUnavailable, connect.CodeOf(err))
+}
+
+func TestDrainer_WaitsForInFlight(t *testing.T) {
+	drainer := NewDrainer()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error, 1)
+
+	go func() {
+		done <- drainer.run(context.Background(), func(context.Context) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+	drainer.Drain()
+	assert.Equal(t, 1, drainer.InFlight())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, drainer.Wait(ctx), context.DeadlineExceeded)
+
+	close(release)
+	require.NoError(t, <-done)
+	assert.NoError(t, drainer.Wait(context.Background()))
+	assert.Equal(t, 0, drainer.InFlight())
+}
+
+func TestDrainer_Abort(t *testing.T) {
+	drainer := NewDrainer()
+	started := make(chan struct{})
+	done := make(chan error, 1)
+
+	go func() {
+		done <- drainer.run(context.Background(), func(ctx context.Context) error {
+			close(started)
+			<-ctx.Done()
+			return connect.NewError(connect.CodeCanceled, ctx.Err())
+		})
+	}()
+	<-started
+	drainer.Drain()
+	drainer.Abort()
+
+	err := <-done
+	assert.Equal(t, connect.CodeUnavailable, connect.CodeOf(err))
+	assert.True(t, errors.Is(err, ErrShuttingDown))
+	assert.Equal(t, apierror.ReasonShuttingDown, apierror.Reason(err))
+	assert.NotNil(t, apierror.DetailsOf(err).Retry, "clients may retry on another replica")
+	assert.NoError(t, drainer.Wait(context.Background()))
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultMemoryResults is how many results a MemoryStore keeps by default
+const DefaultMemoryResults = 10000
+
+// MemoryStore keeps the most recent results in process memory, so they are
+// lost on restart
+type MemoryStore struct {
+	mu      sync.Mutex
+	limit   int
+	nextID  int64
+	results []*Result
+	closed  bool
+}
+
+// NewMemoryStore creates a store keeping at most limit results, dropping the
+// oldest first
+func NewMemoryStore(limit int) *MemoryStore {
+	return &MemoryStore{limit: limit, nextID: 1}
+}
+
+// RecordResult implements Store
+func (s *MemoryStore) RecordResult(_ context.Context, result *Result) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return ErrClosed
+	}
+	result.ID = s.nextID
+	s.nextID++
+	s.results = append(s.results, result.Clone())
+	if len(s.results) > s.limit {
+		s.results = s.results[len(s.results)-s.limit:]
+	}
+	return nil
+}
+
+// ListResults implements Store
+func (s *MemoryStore) ListResults(_ context.Context, q Query) ([]*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	var results []*Result
+	for i := len(s.results) - 1; i >= 0 && len(results) < q.Limit; i-- {
+		if q.matches(s.results[i]) {
+			results = append(results, s.results[i].Clone())
+		}
+	}
+	return results, nil
+}
+
+// Close implements Store
+func (s *MemoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.results = nil
+	return nil
+}
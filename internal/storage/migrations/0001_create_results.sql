@@ -0,0 +1,15 @@
+CREATE TABLE results (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    method        TEXT    NOT NULL,
+    input         TEXT    NOT NULL,
+    options       TEXT    NOT NULL,
+    output        TEXT    NOT NULL,
+    success       INTEGER NOT NULL,
+    error_code    TEXT    NOT NULL,
+    error_message TEXT    NOT NULL,
+    -- Unix time in nanoseconds
+    create_time   INTEGER NOT NULL
+);
+
+CREATE INDEX results_method_id ON results (method, id);
+CREATE INDEX results_create_time ON results (create_time);
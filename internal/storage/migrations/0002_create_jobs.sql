@@ -0,0 +1,16 @@
+CREATE TABLE jobs (
+    id          TEXT    PRIMARY KEY,
+    state       INTEGER NOT NULL,
+    data        TEXT    NOT NULL,
+    options     TEXT    NOT NULL,
+    progress    INTEGER NOT NULL,
+    -- JSON encoded jobs.Output and jobs.Error, NULL when unset
+    output      TEXT,
+    error       TEXT,
+    -- Unix times in nanoseconds, 0 when unset
+    create_time INTEGER NOT NULL,
+    start_time  INTEGER NOT NULL,
+    end_time    INTEGER NOT NULL
+);
+
+CREATE INDEX jobs_state_end_time ON jobs (state, end_time);
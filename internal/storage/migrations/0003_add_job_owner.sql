@@ -0,0 +1,3 @@
+-- The auth principal ID of the caller that submitted the job, '' when
+-- callers are not authenticated
+ALTER TABLE jobs ADD COLUMN owner TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,5 @@
+-- The auth principal ID of the caller that made the call, '' when callers
+-- are not authenticated
+ALTER TABLE results ADD COLUMN owner TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX results_owner_id ON results (owner, id);
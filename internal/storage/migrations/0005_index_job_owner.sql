@@ -0,0 +1,2 @@
+-- ListJobs pages through one owner's jobs in ID order
+CREATE INDEX jobs_owner_id ON jobs (owner, id);
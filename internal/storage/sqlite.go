@@ -0,0 +1,363 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	// Registers the pure-Go "sqlite" driver, so no cgo or system library is
+	// needed
+	_ "modernc.org/sqlite"
+
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/jobs"
+)
+
+// SQLite stores results and jobs in an SQLite database file. It is meant for
+// a single replica: SQLite files must not be shared over network storage.
+type SQLite struct {
+	// db is the one connection writes go through, as SQLite allows a
+	// single writer at a time
+	db *sql.DB
+	// reader is a pool of read-only connections for queries
+	reader  *sql.DB
+	version int
+	// maxResults is how many results are kept, or 0 to keep them all
+	maxResults int64
+}
+
+// maxReaders bounds the read-only connections of an SQLite store
+const maxReaders = 4
+
+// SQLiteOption configures an SQLite store
+type SQLiteOption func(*SQLite)
+
+// WithMaxResults keeps at most n results, deleting the oldest as new ones
+// are recorded. 0 keeps them all, which is the default.
+func WithMaxResults(n int) SQLiteOption {
+	return func(s *SQLite) {
+		s.maxResults = int64(n)
+	}
+}
+
+// OpenSQLite opens or creates the database at path and brings its schema up
+// to date
+func OpenSQLite(ctx context.Context, path string, opts ...SQLiteOption) (*SQLite, error) {
+	// busy_timeout makes a locked database wait instead of failing at once
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+
+	version, err := migrate(ctx, db)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("storage: migrating %s: %w", path, err)
+	}
+
+	// In WAL mode, which the writer set on the file, queries on these
+	// connections run alongside the writer and see every committed write
+	reader, err := sql.Open("sqlite", "file:"+path+"?_pragma=query_only(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	reader.SetMaxOpenConns(maxReaders)
+	s := &SQLite{db: db, reader: reader, version: version}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+// SchemaVersion returns the version of the last migration applied
+func (s *SQLite) SchemaVersion() int {
+	return s.version
+}
+
+// RecordResult implements Store
+func (s *SQLite) RecordResult(ctx context.Context, result *Result) error {
+	options, err := json.Marshal(result.Options)
+	if err != nil {
+		return err
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, `INSERT INTO results
+		(owner, method, input, options, output, success, error_code, error_message, create_time)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		result.Owner, result.Method, result.Input, string(options), result.Output, result.Success,
+		result.ErrorCode, result.ErrorMessage, unixNano(result.CreateTime),
+	)
+	if err != nil {
+		return err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	// IDs only grow, so the newest maxResults results are the ones above
+	// this one's ID minus maxResults
+	if s.maxResults > 0 && id > s.maxResults {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM results WHERE id <= ?`, id-s.maxResults); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	result.ID = id
+	return nil
+}
+
+// ListResults implements Store
+func (s *SQLite) ListResults(ctx context.Context, q Query) ([]*Result, error) {
+	where := []string{"owner = ?"}
+	args := []any{q.Owner}
+	if q.Method != "" {
+		where = append(where, "method = ?")
+		args = append(args, q.Method)
+	}
+	if q.Success != nil {
+		where = append(where, "success = ?")
+		args = append(args, *q.Success)
+	}
+	if !q.Since.IsZero() {
+		where = append(where, "create_time >= ?")
+		args = append(args, q.Since.UnixNano())
+	}
+	if !q.Until.IsZero() {
+		where = append(where, "create_time < ?")
+		args = append(args, q.Until.UnixNano())
+	}
+	if q.BeforeID > 0 {
+		where = append(where, "id < ?")
+		args = append(args, q.BeforeID)
+	}
+	if filter, filterArgs := q.Filter.SQL(resultColumn); filter != "" {
+		where = append(where, filter)
+		args = append(args, filterArgs...)
+	}
+	query := `SELECT id, owner, method, input, options, output, success, error_code, error_message, create_time
+		FROM results WHERE ` + strings.Join(where, " AND ") + ` ORDER BY id DESC LIMIT ?`
+	args = append(args, q.Limit)
+
+	rows, err := s.reader.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var results []*Result
+	for rows.Next() {
+		var (
+			r          Result
+			options    string
+			createTime int64
+		)
+		if err := rows.Scan(&r.ID, &r.Owner, &r.Method, &r.Input, &options, &r.Output, &r.Success,
+			&r.ErrorCode, &r.ErrorMessage, &createTime); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(options), &r.Options); err != nil {
+			return nil, fmt.Errorf("result %d: options: %w", r.ID, err)
+		}
+		r.CreateTime = fromUnixNano(createTime)
+		results = append(results, &r)
+	}
+	return results, rows.Err()
+}
+
+// resultColumn maps ResultFields to columns of the results table
+func resultColumn(field string) string {
+	switch field {
+	case "status":
+		return "(CASE WHEN success THEN 'ok' ELSE error_code END)"
+	case "processed_at":
+		return "create_time"
+	default:
+		return field
+	}
+}
+
+// Jobs returns a jobs.Store kept in the same database
+func (s *SQLite) Jobs() jobs.Store {
+	return &sqliteJobs{db: s.db, reader: s.reader}
+}
+
+// Close implements Store
+func (s *SQLite) Close() error {
+	return errors.Join(s.reader.Close(), s.db.Close())
+}
+
+type sqliteJobs struct {
+	db     *sql.DB
+	reader *sql.DB
+}
+
+const jobColumns = `id, state, data, options, progress, output, error, create_time, start_time, end_time, owner`
+
+// Create implements jobs.Store
+func (s *sqliteJobs) Create(ctx context.Context, job *jobs.Job) error {
+	values, err := jobValues(job)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO jobs (`+jobColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, values...)
+	return err
+}
+
+// Get implements jobs.Store
+func (s *sqliteJobs) Get(ctx context.Context, id string) (*jobs.Job, error) {
+	job, err := scanJob(s.reader.QueryRowContext(ctx, `SELECT `+jobColumns+` FROM jobs WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, jobs.ErrNotFound
+	}
+	return job, err
+}
+
+// Update implements jobs.Store
+func (s *sqliteJobs) Update(ctx context.Context, job *jobs.Job) error {
+	values, err := jobValues(job)
+	if err != nil {
+		return err
+	}
+	res, err := s.db.ExecContext(ctx, `UPDATE jobs SET
+		state = ?2, data = ?3, options = ?4, progress = ?5, output = ?6, error = ?7,
+		create_time = ?8, start_time = ?9, end_time = ?10, owner = ?11
+		WHERE id = ?1`, values...)
+	if err != nil {
+		return err
+	}
+	updated, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return jobs.ErrNotFound
+	}
+	return nil
+}
+
+// List implements jobs.Store
+func (s *sqliteJobs) List(ctx context.Context, owner, afterID string, limit int) ([]*jobs.Job, error) {
+	return s.query(ctx, `SELECT `+jobColumns+` FROM jobs WHERE owner = ? AND id > ? ORDER BY id LIMIT ?`, owner, afterID, limit)
+}
+
+// ListUnfinished implements jobs.Store
+func (s *sqliteJobs) ListUnfinished(ctx context.Context, afterID string, limit int) ([]*jobs.Job, error) {
+	return s.query(ctx, `SELECT `+jobColumns+` FROM jobs WHERE state IN (?, ?) AND id > ? ORDER BY id LIMIT ?`,
+		jobs.StatePending, jobs.StateRunning, afterID, limit)
+}
+
+// query returns the jobs selected by a query on jobColumns
+func (s *sqliteJobs) query(ctx context.Context, query string, args ...any) ([]*jobs.Job, error) {
+	rows, err := s.reader.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var list []*jobs.Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, job)
+	}
+	return list, rows.Err()
+}
+
+// DeleteFinishedBefore implements jobs.Store
+func (s *sqliteJobs) DeleteFinishedBefore(ctx context.Context, t time.Time) (int, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM jobs WHERE state IN (?, ?, ?) AND end_time < ?`,
+		jobs.StateSucceeded, jobs.StateFailed, jobs.StateCancelled, t.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := res.RowsAffected()
+	return int(deleted), err
+}
+
+// jobValues returns the values of jobColumns for job
+func jobValues(job *jobs.Job) ([]any, error) {
+	options, err := json.Marshal(job.Options)
+	if err != nil {
+		return nil, err
+	}
+	output, err := nullJSON(job.Output)
+	if err != nil {
+		return nil, err
+	}
+	jobErr, err := nullJSON(job.Error)
+	if err != nil {
+		return nil, err
+	}
+	return []any{
+		job.ID, job.State, job.Data, string(options), job.Progress, output, jobErr,
+		unixNano(job.CreateTime), unixNano(job.StartTime), unixNano(job.EndTime), job.Owner,
+	}, nil
+}
+
+// scanJob reads the jobColumns of a row
+func scanJob(row interface{ Scan(...any) error }) (*jobs.Job, error) {
+	var (
+		job                            jobs.Job
+		options                        string
+		output, jobErr                 sql.NullString
+		createTime, startTime, endTime int64
+	)
+	if err := row.Scan(&job.ID, &job.State, &job.Data, &options, &job.Progress, &output, &jobErr,
+		&createTime, &startTime, &endTime, &job.Owner); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(options), &job.Options); err != nil {
+		return nil, fmt.Errorf("job %s: options: %w", job.ID, err)
+	}
+	if output.Valid {
+		job.Output = &jobs.Output{}
+		if err := json.Unmarshal([]byte(output.String), job.Output); err != nil {
+			return nil, fmt.Errorf("job %s: output: %w", job.ID, err)
+		}
+	}
+	if jobErr.Valid {
+		job.Error = &jobs.Error{}
+		if err := json.Unmarshal([]byte(jobErr.String), job.Error); err != nil {
+			return nil, fmt.Errorf("job %s: error: %w", job.ID, err)
+		}
+	}
+	job.CreateTime = fromUnixNano(createTime)
+	job.StartTime = fromUnixNano(startTime)
+	job.EndTime = fromUnixNano(endTime)
+	return &job, nil
+}
+
+// nullJSON encodes v as JSON, or NULL when v is nil
+func nullJSON[T any](v *T) (sql.NullString, error) {
+	if v == nil {
+		return sql.NullString{}, nil
+	}
+	b, err := json.Marshal(v)
+	return sql.NullString{String: string(b), Valid: err == nil}, err
+}
+
+// unixNano stores zero times as 0
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n).UTC()
+}
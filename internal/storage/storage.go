@@ -0,0 +1,147 @@
+// Package storage keeps a record of processed requests and their results.
+// MemoryStore holds a bounded number of recent results in process memory;
+// SQLite persists them, together with jobs, in an embedded database file.
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/pagination"
+)
+
+// ErrClosed is returned by stores that have been closed
+var ErrClosed = errors.New("storage is closed")
+
+// Result is one processed request and its outcome
+type Result struct {
+	// ID is assigned by the store; later results have larger IDs
+	ID int64
+	// Owner identifies the caller that made the call; it is empty when
+	// callers are not identified
+	Owner string
+	// Method is the RPC that produced the result, e.g. ProcessData
+	Method  string
+	Input   string
+	Options map[string]string
+	Output  string
+	Success bool
+	// ErrorCode is the connect code name the call failed with, if any
+	ErrorCode    string
+	ErrorMessage string
+	CreateTime   time.Time
+}
+
+// Clone returns a deep copy of r
+func (r *Result) Clone() *Result {
+	c := *r
+	if r.Options != nil {
+		c.Options = make(map[string]string, len(r.Options))
+		for k, v := range r.Options {
+			c.Options[k] = v
+		}
+	}
+	return &c
+}
+
+// StatusOK is the status of results that succeeded
+const StatusOK = "ok"
+
+// Status is StatusOK for results that succeeded and the error code otherwise
+func (r *Result) Status() string {
+	if r.Success {
+		return StatusOK
+	}
+	return r.ErrorCode
+}
+
+// ResultFields are the fields a Query filter may use. processed_at is an
+// alias of create_time.
+var ResultFields = pagination.Fields{
+	"id":            pagination.Int,
+	"method":        pagination.String,
+	"status":        pagination.String,
+	"success":       pagination.Bool,
+	"input":         pagination.String,
+	"output":        pagination.String,
+	"error_code":    pagination.String,
+	"error_message": pagination.String,
+	"create_time":   pagination.Time,
+	"processed_at":  pagination.Time,
+}
+
+// field returns the value of one of ResultFields
+func (r *Result) field(name string) any {
+	switch name {
+	case "id":
+		return r.ID
+	case "method":
+		return r.Method
+	case "status":
+		return r.Status()
+	case "success":
+		return r.Success
+	case "input":
+		return r.Input
+	case "output":
+		return r.Output
+	case "error_code":
+		return r.ErrorCode
+	case "error_message":
+		return r.ErrorMessage
+	case "create_time", "processed_at":
+		return r.CreateTime
+	default:
+		return nil
+	}
+}
+
+// Query selects results. Zero fields other than Owner do not filter.
+type Query struct {
+	// Owner keeps only the results of this caller; it always filters, so
+	// the empty owner only sees results recorded without one
+	Owner  string
+	Method string
+	// Success, when set, keeps only results that did or did not succeed
+	Success *bool
+	// Since and Until bound CreateTime, inclusive and exclusive
+	Since time.Time
+	Until time.Time
+	// Filter, parsed with ResultFields, further selects results
+	Filter *pagination.Filter
+	// BeforeID keeps results older than the result with this ID
+	BeforeID int64
+	// Limit is the most results returned
+	Limit int
+}
+
+// matches reports whether r is selected by q, ignoring Limit
+func (q Query) matches(r *Result) bool {
+	switch {
+	case r.Owner != q.Owner:
+		return false
+	case q.Method != "" && r.Method != q.Method:
+		return false
+	case q.Success != nil && r.Success != *q.Success:
+		return false
+	case !q.Since.IsZero() && r.CreateTime.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && !r.CreateTime.Before(q.Until):
+		return false
+	case q.BeforeID > 0 && r.ID >= q.BeforeID:
+		return false
+	default:
+		return q.Filter.Match(r.field)
+	}
+}
+
+// Store records results. Implementations must be safe for concurrent use.
+type Store interface {
+	// RecordResult saves a result and sets its ID
+	RecordResult(ctx context.Context, result *Result) error
+	// ListResults returns the results selected by q, newest first
+	ListResults(ctx context.Context, q Query) ([]*Result, error)
+	// Close releases the store's resources
+	Close() error
+}
@@ -0,0 +1,277 @@
+package storage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/jobs"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/pagination"
+)
+
+var start = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func openSQLite(t *testing.T, path string) *SQLite {
+	t.Helper()
+	db, err := OpenSQLite(context.Background(), path)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// stores returns an empty store of every kind
+func stores(t *testing.T) map[string]Store {
+	return map[string]Store{
+		"memory": NewMemoryStore(DefaultMemoryResults),
+		"sqlite": openSQLite(t, filepath.Join(t.TempDir(), "test.db")),
+	}
+}
+
+// record saves results created a minute apart, alternating between
+// ProcessData successes and StreamData failures
+func record(t *testing.T, store Store, n int) []*Result {
+	t.Helper()
+	var results []*Result
+	for i := 0; i < n; i++ {
+		result := &Result{
+			Method:     "ProcessData",
+			Input:      "hello",
+			Options:    map[string]string{"processor": "uppercase"},
+			Output:     "HELLO",
+			Success:    true,
+			CreateTime: start.Add(time.Duration(i) * time.Minute),
+		}
+		if i%2 == 1 {
+			result = &Result{
+				Method:       "StreamData",
+				Input:        "query",
+				ErrorCode:    "canceled",
+				ErrorMessage: "context canceled",
+				CreateTime:   start.Add(time.Duration(i) * time.Minute),
+			}
+		}
+		require.NoError(t, store.RecordResult(context.Background(), result))
+		results = append(results, result)
+	}
+	return results
+}
+
+func ids(results []*Result) []int64 {
+	var ids []int64
+	for _, r := range results {
+		ids = append(ids, r.ID)
+	}
+	return ids
+}
+
+func TestStore_ListResults(t *testing.T) {
+	succeeded := true
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			results := record(t, store, 5)
+			ctx := context.Background()
+
+			all, err := store.ListResults(ctx, Query{Limit: 10})
+			require.NoError(t, err)
+			assert.Equal(t, []int64{results[4].ID, results[3].ID, results[2].ID, results[1].ID, results[0].ID}, ids(all))
+			assert.Equal(t, results[4], all[0])
+			assert.Equal(t, results[3], all[1])
+
+			streams, err := store.ListResults(ctx, Query{Method: "StreamData", Limit: 10})
+			require.NoError(t, err)
+			assert.Equal(t, []int64{results[3].ID, results[1].ID}, ids(streams))
+
+			ok, err := store.ListResults(ctx, Query{Success: &succeeded, Limit: 10})
+			require.NoError(t, err)
+			assert.Equal(t, []int64{results[4].ID, results[2].ID, results[0].ID}, ids(ok))
+
+			window, err := store.ListResults(ctx, Query{Since: start.Add(time.Minute), Until: start.Add(3 * time.Minute), Limit: 10})
+			require.NoError(t, err)
+			assert.Equal(t, []int64{results[2].ID, results[1].ID}, ids(window))
+
+			page, err := store.ListResults(ctx, Query{BeforeID: results[3].ID, Limit: 2})
+			require.NoError(t, err)
+			assert.Equal(t, []int64{results[2].ID, results[1].ID}, ids(page))
+		})
+	}
+}
+
+func TestStore_Filter(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			results := record(t, store, 5)
+
+			for expr, want := range map[string][]int64{
+				`status = "ok"`: {results[4].ID, results[2].ID, results[0].ID},
+				`status = "canceled" OR status = "internal"`:                           {results[3].ID, results[1].ID},
+				`success = false AND error_message = "context canceled"`:               {results[3].ID, results[1].ID},
+				`processed_at >= "2024-03-01T12:02:00Z" AND NOT method = "StreamData"`: {results[4].ID, results[2].ID},
+				`create_time < "2024-03-01T12:01:00Z"`:                                 {results[0].ID},
+				`id > 2 AND input != "query"`:                                          {results[4].ID, results[2].ID},
+				`output >= "H"`:                                                        {results[4].ID, results[2].ID, results[0].ID},
+			} {
+				filter, err := pagination.ParseFilter(expr, ResultFields)
+				require.NoError(t, err)
+
+				got, err := store.ListResults(context.Background(), Query{Filter: filter, Limit: 10})
+
+				require.NoError(t, err)
+				assert.Equal(t, want, ids(got), expr)
+			}
+		})
+	}
+}
+
+func TestStore_Owner(t *testing.T) {
+	for name, store := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			anonymous := record(t, store, 1)
+			alice := &Result{Owner: "jwt:alice", Method: "ProcessData", Input: "secret", Success: true, CreateTime: start}
+			require.NoError(t, store.RecordResult(ctx, alice))
+
+			got, err := store.ListResults(ctx, Query{Owner: "jwt:alice", Limit: 10})
+			require.NoError(t, err)
+			assert.Equal(t, []*Result{alice}, got)
+
+			got, err = store.ListResults(ctx, Query{Limit: 10})
+			require.NoError(t, err)
+			assert.Equal(t, ids(anonymous), ids(got), "the empty owner only sees results without one")
+
+			got, err = store.ListResults(ctx, Query{Owner: "jwt:bob", Limit: 10})
+			require.NoError(t, err)
+			assert.Empty(t, got)
+		})
+	}
+}
+
+func TestMemoryStore_Limit(t *testing.T) {
+	store := NewMemoryStore(3)
+	results := record(t, store, 5)
+
+	all, err := store.ListResults(context.Background(), Query{Limit: 10})
+
+	require.NoError(t, err)
+	assert.Equal(t, []int64{results[4].ID, results[3].ID, results[2].ID}, ids(all))
+}
+
+func TestSQLite_MaxResults(t *testing.T) {
+	db, err := OpenSQLite(context.Background(), filepath.Join(t.TempDir(), "test.db"), WithMaxResults(3))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	results := record(t, db, 5)
+
+	all, err := db.ListResults(context.Background(), Query{Limit: 10})
+
+	require.NoError(t, err)
+	assert.Equal(t, []int64{results[4].ID, results[3].ID, results[2].ID}, ids(all))
+	var rows int
+	require.NoError(t, db.db.QueryRow(`SELECT COUNT(*) FROM results`).Scan(&rows))
+	assert.Equal(t, 3, rows, "older results are deleted, not just hidden")
+}
+
+func TestSQLite_Migrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	migrations, err := loadMigrations()
+	require.NoError(t, err)
+	latest := migrations[len(migrations)-1].version
+
+	first := openSQLite(t, path)
+	assert.Equal(t, latest, first.SchemaVersion())
+	record(t, first, 1)
+	require.NoError(t, first.Close())
+
+	// Reopening applies nothing and keeps the data
+	second := openSQLite(t, path)
+	assert.Equal(t, latest, second.SchemaVersion())
+	var applied int
+	require.NoError(t, second.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&applied))
+	assert.Equal(t, len(migrations), applied)
+	results, err := second.ListResults(context.Background(), Query{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
+func TestSQLite_ReadDuringWrite(t *testing.T) {
+	store := openSQLite(t, filepath.Join(t.TempDir(), "test.db"))
+	results := record(t, store, 1)
+	ctx := context.Background()
+
+	// Hold the only write connection in an open transaction
+	tx, err := store.db.BeginTx(ctx, nil)
+	require.NoError(t, err)
+	defer tx.Rollback()
+	_, err = tx.Exec(`INSERT INTO results (method, input, options, output, success, error_code, error_message, create_time)
+		VALUES ('ProcessData', 'pending', '{}', '', 1, '', '', 0)`)
+	require.NoError(t, err)
+
+	queryCtx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	list, err := store.ListResults(queryCtx, Query{Limit: 10})
+	require.NoError(t, err, "queries do not wait for the writer")
+	assert.Equal(t, ids(results), ids(list), "uncommitted writes are not visible")
+
+	_, err = store.Jobs().Get(queryCtx, "missing")
+	assert.ErrorIs(t, err, jobs.ErrNotFound)
+}
+
+func TestSQLite_NewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db := openSQLite(t, path)
+	_, err := db.db.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (9999, 'future.sql', 0)`)
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	_, err = OpenSQLite(context.Background(), path)
+
+	assert.ErrorContains(t, err, "newer than this binary")
+}
+
+func TestSQLite_Jobs(t *testing.T) {
+	store := openSQLite(t, filepath.Join(t.TempDir(), "test.db")).Jobs()
+	ctx := context.Background()
+
+	job := &jobs.Job{ID: "a", Owner: "jwt:alice", State: jobs.StatePending, Data: "x", Options: map[string]string{"processor": "uppercase"}, CreateTime: start}
+	require.NoError(t, store.Create(ctx, job))
+	assert.Error(t, store.Create(ctx, job), "IDs are unique")
+
+	got, err := store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, job, got)
+
+	job.State = jobs.StateSucceeded
+	job.Progress = 100
+	job.Output = &jobs.Output{Result: "X", Success: true, Seed: "42"}
+	job.StartTime = start.Add(time.Second)
+	job.EndTime = start.Add(2 * time.Second)
+	require.NoError(t, store.Update(ctx, job))
+	got, err = store.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, job, got)
+
+	failed := &jobs.Job{ID: "b", State: jobs.StateFailed, Error: &jobs.Error{Code: "internal", Reason: "INTERNAL", Message: "boom"}, CreateTime: start, EndTime: start.Add(time.Hour)}
+	require.NoError(t, store.Create(ctx, failed))
+	pending := &jobs.Job{ID: "c", State: jobs.StatePending, CreateTime: start}
+	require.NoError(t, store.Create(ctx, pending))
+
+	list, err := store.List(ctx, "", "a", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []*jobs.Job{failed, pending}, list)
+	list, err = store.List(ctx, "jwt:alice", "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []*jobs.Job{job}, list, "only the owner's jobs are listed")
+	list, err = store.ListUnfinished(ctx, "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, []*jobs.Job{pending}, list)
+
+	deleted, err := store.DeleteFinishedBefore(ctx, start.Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	_, err = store.Get(ctx, "a")
+	assert.ErrorIs(t, err, jobs.ErrNotFound)
+	assert.ErrorIs(t, store.Update(ctx, job), jobs.ErrNotFound)
+}
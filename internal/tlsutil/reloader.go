@@ -0,0 +1,188 @@
+// Package tlsutil builds TLS configurations whose certificates are reloaded
+// from disk when the files change, e.g. when Kubernetes updates a mounted
+// secret.
+package tlsutil
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Reloader holds a server certificate and an optional client CA pool, and
+// re-reads them whenever the underlying files change
+type Reloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+	logger   *logrus.Logger
+
+	mu          sync.RWMutex
+	cert        *tls.Certificate
+	clientCAs   *x509.CertPool
+	fingerprint []byte
+}
+
+// NewReloader loads the certificate, key and optional client CA bundle. It
+// fails if the initial load fails; later reload errors keep the last good
+// material.
+func NewReloader(certFile, keyFile, caFile string, logger *logrus.Logger) (*Reloader, error) {
+	r := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		logger:   logger,
+	}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload re-reads the files and swaps in the new material if it changed. It
+// reports whether anything was swapped.
+func (r *Reloader) Reload() (bool, error) {
+	certPEM, err := os.ReadFile(r.certFile)
+	if err != nil {
+		return false, fmt.Errorf("reading certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("reading key: %w", err)
+	}
+	var caPEM []byte
+	if r.caFile != "" {
+		if caPEM, err = os.ReadFile(r.caFile); err != nil {
+			return false, fmt.Errorf("reading client CA: %w", err)
+		}
+	}
+
+	fingerprint := fingerprintOf(certPEM, keyPEM, caPEM)
+	r.mu.RLock()
+	unchanged := bytes.Equal(fingerprint, r.fingerprint)
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return false, fmt.Errorf("parsing key pair: %w", err)
+	}
+	var clientCAs *x509.CertPool
+	if r.caFile != "" {
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(caPEM) {
+			return false, errors.New("client CA file contains no certificates")
+		}
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.fingerprint = fingerprint
+	r.mu.Unlock()
+	return true, nil
+}
+
+// Run polls the files every interval until ctx is done
+func (r *Reloader) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := r.Reload()
+			if err != nil {
+				r.logger.WithError(err).Error("Failed to reload TLS certificates, keeping previous ones")
+				continue
+			}
+			if reloaded {
+				r.logger.Info("Reloaded TLS certificates")
+			}
+		}
+	}
+}
+
+// ServerConfig returns a TLS configuration that always presents the current
+// certificate and verifies clients against the current CA pool
+func (r *Reloader) ServerConfig(clientAuth tls.ClientAuthType) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: []string{"h2", "http/1.1"},
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return &tls.Config{
+				MinVersion:   tls.VersionTLS12,
+				NextProtos:   []string{"h2", "http/1.1"},
+				Certificates: []tls.Certificate{*r.cert},
+				ClientCAs:    r.clientCAs,
+				ClientAuth:   clientAuth,
+			}, nil
+		},
+	}
+}
+
+// ParseClientAuth maps the configuration names none, request and require to
+// the matching tls.ClientAuthType
+func ParseClientAuth(mode string) (tls.ClientAuthType, error) {
+	switch mode {
+	case "", "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("unknown client auth mode %q", mode)
+	}
+}
+
+// ClientConfig builds a client TLS configuration trusting caFile (or the
+// system roots when empty) and presenting certFile/keyFile when given
+func ClientConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile != "" {
+		caPEM, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading CA: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("CA file contains no certificates")
+		}
+	}
+
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
+
+func fingerprintOf(parts ...[]byte) []byte {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	return h.Sum(nil)
+}
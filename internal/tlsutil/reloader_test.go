@@ -0,0 +1,163 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns PEM encoded certificate and key signed by the CA
+func (ca *testCA) issue(t *testing.T, commonName string, serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, dir, name string, data []byte) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path
+}
+
+func TestReloader_ReloadsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, "server-1", 2, x509.ExtKeyUsageServerAuth)
+	certFile := writeFile(t, dir, "tls.crt", certPEM)
+	keyFile := writeFile(t, dir, "tls.key", keyPEM)
+
+	reloader, err := NewReloader(certFile, keyFile, "", logrus.New())
+	require.NoError(t, err)
+	assert.Equal(t, "server-1", reloader.cert.Leaf.Subject.CommonName)
+
+	reloaded, err := reloader.Reload()
+	require.NoError(t, err)
+	assert.False(t, reloaded, "unchanged files are not reloaded")
+
+	certPEM, keyPEM = ca.issue(t, "server-2", 3, x509.ExtKeyUsageServerAuth)
+	writeFile(t, dir, "tls.crt", certPEM)
+	writeFile(t, dir, "tls.key", keyPEM)
+	reloaded, err = reloader.Reload()
+	require.NoError(t, err)
+	assert.True(t, reloaded)
+	assert.Equal(t, "server-2", reloader.cert.Leaf.Subject.CommonName)
+}
+
+func TestReloader_KeepsPreviousOnError(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	certPEM, keyPEM := ca.issue(t, "server", 2, x509.ExtKeyUsageServerAuth)
+	certFile := writeFile(t, dir, "tls.crt", certPEM)
+	keyFile := writeFile(t, dir, "tls.key", keyPEM)
+
+	reloader, err := NewReloader(certFile, keyFile, "", logrus.New())
+	require.NoError(t, err)
+
+	writeFile(t, dir, "tls.key", []byte("garbage"))
+	_, err = reloader.Reload()
+	require.Error(t, err)
+	assert.Equal(t, "server", reloader.cert.Leaf.Subject.CommonName)
+}
+
+func TestReloader_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCA(t)
+	serverCert, serverKey := ca.issue(t, "server", 2, x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, "client", 3, x509.ExtKeyUsageClientAuth)
+	caFile := writeFile(t, dir, "ca.crt", ca.pem)
+
+	reloader, err := NewReloader(
+		writeFile(t, dir, "tls.crt", serverCert),
+		writeFile(t, dir, "tls.key", serverKey),
+		caFile,
+		logrus.New(),
+	)
+	require.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = reloader.ServerConfig(tls.RequireAndVerifyClientCert)
+	server.StartTLS()
+	defer server.Close()
+
+	// Without a client certificate the handshake fails
+	tlsConfig, err := ClientConfig(caFile, "", "")
+	require.NoError(t, err)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	_, err = client.Get(server.URL)
+	require.Error(t, err)
+
+	tlsConfig, err = ClientConfig(caFile, writeFile(t, dir, "client.crt", clientCert), writeFile(t, dir, "client.key", clientKey))
+	require.NoError(t, err)
+	client = &http.Client{Transport: &http.Transport{TLSClientConfig: tlsConfig}}
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestParseClientAuth(t *testing.T) {
+	mode, err := ParseClientAuth("require")
+	require.NoError(t, err)
+	assert.Equal(t, tls.RequireAndVerifyClientCert, mode)
+
+	_, err = ParseClientAuth("sometimes")
+	assert.Error(t, err)
+}
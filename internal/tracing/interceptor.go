@@ -0,0 +1,179 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/bufbuild/connect-go"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies spans created by this package
+const instrumentationName = "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/tracing"
+
+// Interceptor creates a span for every Connect call. On handlers it
+// continues the trace from the incoming traceparent header; on clients it
+// injects the current span into the outgoing headers.
+type Interceptor struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+}
+
+// Option configures an Interceptor
+type Option func(*Interceptor)
+
+// WithTracerProvider sets the provider, defaulting to the global one
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(i *Interceptor) {
+		i.provider = provider
+	}
+}
+
+// WithPropagator sets the propagator, defaulting to the global one
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(i *Interceptor) {
+		i.propagator = propagator
+	}
+}
+
+// NewInterceptor creates a tracing interceptor
+func NewInterceptor(opts ...Option) *Interceptor {
+	i := &Interceptor{}
+	for _, opt := range opts {
+		opt(i)
+	}
+	return i
+}
+
+// WrapUnary implements connect.Interceptor
+func (i *Interceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		ctx, span := i.start(ctx, req.Spec(), req.Peer(), req.Header())
+		resp, err := next(ctx, req)
+		end(span, err)
+		return resp, err
+	}
+}
+
+// WrapStreamingClient implements connect.Interceptor
+func (i *Interceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		ctx, span := i.tracer().Start(ctx, spanName(spec.Procedure),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(rpcAttributes(spec.Procedure, "")...),
+		)
+		conn := next(ctx, spec)
+		i.propagate().Inject(ctx, propagation.HeaderCarrier(conn.RequestHeader()))
+		return &streamingClientConn{StreamingClientConn: conn, span: span}
+	}
+}
+
+// WrapStreamingHandler implements connect.Interceptor
+func (i *Interceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx, span := i.start(ctx, conn.Spec(), conn.Peer(), conn.RequestHeader())
+		err := next(ctx, conn)
+		end(span, err)
+		return err
+	}
+}
+
+// start begins a server span continuing the caller's trace, or a client
+// span whose context is injected into header
+func (i *Interceptor) start(ctx context.Context, spec connect.Spec, peer connect.Peer, header http.Header) (context.Context, trace.Span) {
+	kind := trace.SpanKindServer
+	if spec.IsClient {
+		kind = trace.SpanKindClient
+	} else {
+		ctx = i.propagate().Extract(ctx, propagation.HeaderCarrier(header))
+	}
+
+	ctx, span := i.tracer().Start(ctx, spanName(spec.Procedure),
+		trace.WithSpanKind(kind),
+		trace.WithAttributes(rpcAttributes(spec.Procedure, peer.Protocol)...),
+	)
+	if spec.IsClient {
+		i.propagate().Inject(ctx, propagation.HeaderCarrier(header))
+	} else if host, _, err := net.SplitHostPort(peer.Addr); err == nil {
+		span.SetAttributes(semconv.NetworkPeerAddress(host))
+	}
+	return ctx, span
+}
+
+func (i *Interceptor) tracer() trace.Tracer {
+	provider := i.provider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(instrumentationName)
+}
+
+func (i *Interceptor) propagate() propagation.TextMapPropagator {
+	if i.propagator != nil {
+		return i.propagator
+	}
+	return otel.GetTextMapPropagator()
+}
+
+// end records the call's outcome and ends the span
+func end(span trace.Span, err error) {
+	code := connect.Code(0)
+	if err != nil {
+		code = connect.CodeOf(err)
+		if errors.Is(err, context.Canceled) {
+			code = connect.CodeCanceled
+		}
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.SetAttributes(semconv.RPCGRPCStatusCodeKey.Int(int(code)))
+	span.End()
+}
+
+// streamingClientConn ends the client span once the response is drained
+type streamingClientConn struct {
+	connect.StreamingClientConn
+	span trace.Span
+}
+
+func (c *streamingClientConn) Receive(msg any) error {
+	err := c.StreamingClientConn.Receive(msg)
+	if err != nil && c.span.IsRecording() {
+		if errors.Is(err, io.EOF) {
+			end(c.span, nil)
+		} else {
+			end(c.span, err)
+		}
+	}
+	return err
+}
+
+func (c *streamingClientConn) CloseResponse() error {
+	err := c.StreamingClientConn.CloseResponse()
+	if c.span.IsRecording() {
+		end(c.span, err)
+	}
+	return err
+}
+
+// spanName follows the RPC convention "package.Service/Method"
+func spanName(procedure string) string {
+	return strings.TrimPrefix(procedure, "/")
+}
+
+func rpcAttributes(procedure, protocol string) []attribute.KeyValue {
+	service, method, _ := strings.Cut(spanName(procedure), "/")
+	system := semconv.RPCSystemConnectRPC
+	if protocol == connect.ProtocolGRPC || protocol == connect.ProtocolGRPCWeb {
+		system = semconv.RPCSystemGRPC
+	}
+	return []attribute.KeyValue{system, semconv.RPCService(service), semconv.RPCMethod(method)}
+}
@@ -0,0 +1,35 @@
+package tracing
+
+import (
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Log field names holding the current trace and span IDs
+const (
+	FieldTraceID = "trace_id"
+	FieldSpanID  = "span_id"
+)
+
+// LogHook adds the trace and span IDs of the entry's context to log
+// entries written with logger.WithContext(ctx)
+type LogHook struct{}
+
+// Levels implements logrus.Hook
+func (LogHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (LogHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	spanContext := trace.SpanContextFromContext(entry.Context)
+	if !spanContext.IsValid() {
+		return nil
+	}
+	entry.Data[FieldTraceID] = spanContext.TraceID().String()
+	entry.Data[FieldSpanID] = spanContext.SpanID().String()
+	return nil
+}
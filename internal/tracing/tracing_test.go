@@ -0,0 +1,154 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bufbuild/connect-go"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+
+	apiv1 "github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/gen/api/apiv1connect"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/config"
+	"github.com/hefeicoder/golang_gcp_bootstrap/example-backend/internal/server"
+)
+
+// newTestTracing returns an interceptor recording spans in memory
+func newTestTracing() (*Interceptor, *tracetest.SpanRecorder, trace.TracerProvider) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	interceptor := NewInterceptor(WithTracerProvider(provider), WithPropagator(propagation.TraceContext{}))
+	return interceptor, recorder, provider
+}
+
+func newTestClient(t *testing.T, serverInterceptor, clientInterceptor connect.Interceptor, logger *logrus.Logger) apiv1connect.GrpcServiceClient {
+	mux := http.NewServeMux()
+	mux.Handle(apiv1connect.NewGrpcServiceHandler(
+		server.NewGrpcService(logger),
+		connect.WithInterceptors(serverInterceptor),
+	))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return apiv1connect.NewGrpcServiceClient(srv.Client(), srv.URL, connect.WithInterceptors(clientInterceptor))
+}
+
+func TestInterceptor_PropagatesTrace(t *testing.T) {
+	interceptor, recorder, provider := newTestTracing()
+	var logs bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&logs)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.AddHook(LogHook{})
+	client := newTestClient(t, interceptor, interceptor, logger)
+
+	ctx, root := provider.Tracer("test").Start(context.Background(), "root")
+	_, err := client.ProcessData(ctx, connect.NewRequest(&apiv1.ProcessDataRequest{Data: "x"}))
+	require.NoError(t, err)
+	root.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	serverSpan, clientSpan := spans[0], spans[1]
+	assert.Equal(t, "api.v1.GrpcService/ProcessData", serverSpan.Name())
+	assert.Equal(t, trace.SpanKindServer, serverSpan.SpanKind())
+	assert.Equal(t, trace.SpanKindClient, clientSpan.SpanKind())
+	assert.Equal(t, root.SpanContext().TraceID(), serverSpan.SpanContext().TraceID())
+	assert.Equal(t, clientSpan.SpanContext().SpanID(), serverSpan.Parent().SpanID())
+	assert.Equal(t, root.SpanContext().SpanID(), clientSpan.Parent().SpanID())
+	assert.Contains(t, serverSpan.Attributes(), semconv.RPCMethod("ProcessData"))
+	assert.Contains(t, serverSpan.Attributes(), semconv.RPCGRPCStatusCodeKey.Int(0))
+
+	// The handler's log line carries the server span's IDs
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+	assert.Equal(t, serverSpan.SpanContext().TraceID().String(), entry[FieldTraceID])
+	assert.Equal(t, serverSpan.SpanContext().SpanID().String(), entry[FieldSpanID])
+}
+
+// failing rejects every call
+type failing struct{}
+
+func (failing) WrapUnary(connect.UnaryFunc) connect.UnaryFunc {
+	return func(context.Context, connect.AnyRequest) (connect.AnyResponse, error) {
+		return nil, connect.NewError(connect.CodePermissionDenied, errors.New("denied"))
+	}
+}
+
+func (failing) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return next
+}
+
+func (failing) WrapStreamingHandler(connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(context.Context, connect.StreamingHandlerConn) error {
+		return connect.NewError(connect.CodePermissionDenied, errors.New("denied"))
+	}
+}
+
+func TestInterceptor_RecordsErrors(t *testing.T) {
+	interceptor, recorder, _ := newTestTracing()
+	mux := http.NewServeMux()
+	mux.Handle(apiv1connect.NewGrpcServiceHandler(
+		server.NewGrpcService(logrus.New()),
+		connect.WithInterceptors(interceptor, failing{}),
+	))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	client := apiv1connect.NewGrpcServiceClient(srv.Client(), srv.URL, connect.WithGRPC())
+
+	stream, err := client.StreamData(context.Background(), connect.NewRequest(&apiv1.StreamDataRequest{Query: "q"}))
+	require.NoError(t, err)
+	assert.False(t, stream.Receive())
+	require.NoError(t, stream.Close())
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), semconv.RPCSystemGRPC)
+	assert.Contains(t, spans[0].Attributes(), semconv.RPCGRPCStatusCodeKey.Int(int(connect.CodePermissionDenied)))
+}
+
+func TestInterceptor_StreamingClientSpan(t *testing.T) {
+	interceptor, recorder, _ := newTestTracing()
+	client := newTestClient(t, interceptor, interceptor, logrus.New())
+
+	stream, err := client.StreamData(context.Background(), connect.NewRequest(&apiv1.StreamDataRequest{Query: "q", Limit: 2}))
+	require.NoError(t, err)
+	for stream.Receive() {
+	}
+	require.NoError(t, stream.Err())
+	require.NoError(t, stream.Close())
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, spans[0].SpanContext().TraceID(), spans[1].SpanContext().TraceID())
+}
+
+func TestLogHook_NoSpan(t *testing.T) {
+	entry := logrus.NewEntry(logrus.New()).WithContext(context.Background())
+
+	require.NoError(t, LogHook{}.Fire(entry))
+
+	assert.NotContains(t, entry.Data, FieldTraceID)
+}
+
+func TestSetup(t *testing.T) {
+	shutdown, err := Setup(context.Background(), config.TracingConfig{Exporter: ExporterNone, SampleRatio: 1}, Service{Name: "test"})
+	require.NoError(t, err)
+	require.NoError(t, shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), config.TracingConfig{Exporter: "zipkin"}, Service{Name: "test"})
+	assert.Error(t, err)
+}